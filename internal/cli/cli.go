@@ -32,6 +32,8 @@ func ParseServerConfig() *config.Config {
 	def := flag.Bool("d", false, "No options load")
 
 	engineType := flag.String("et", "in_memory", "Type of engine")
+	maxMemory := flag.String("em", "", "Engine max memory")
+	evictionPolicy := flag.String("ep", "noeviction", "Engine eviction policy")
 
 	address := flag.String("na", "127.0.0.1:3223", "Address of server")
 	maxConns := flag.Int("nmc", 0, "Network max connections")
@@ -60,7 +62,9 @@ func ParseServerConfig() *config.Config {
 
 	return &config.Config{
 		Engine: &config.EngineConfig{
-			EngineType: *engineType,
+			EngineType:     *engineType,
+			MaxMemory:      *maxMemory,
+			EvictionPolicy: *evictionPolicy,
 		},
 
 		Network: &config.NetworkConfig{
@@ -39,7 +39,9 @@ func NewConfig(reader io.Reader) (*Config, error) {
 }
 
 type EngineConfig struct {
-	EngineType string `yaml:"type"`
+	EngineType     string `yaml:"type"`
+	MaxMemory      string `yaml:"max_memory"`
+	EvictionPolicy string `yaml:"eviction_policy"`
 }
 
 type NetworkConfig struct {
@@ -61,12 +63,24 @@ type WalConfig struct {
 	MaxSegmentSize string        `yaml:"max_segment_size"`
 	DataDirectory  string        `yaml:"data_directory"`
 	FileName       string        `yaml:"file_name"`
+	Durability     string        `yaml:"durability"`
+	KeyFile        string        `yaml:"key_file"`
+
+	SnapshotDirectory string        `yaml:"snapshot_directory"`
+	SnapshotInterval  time.Duration `yaml:"snapshot_interval"`
+
+	CompactionInterval time.Duration `yaml:"compaction_interval"`
 }
 
 type ReplicaConfig struct {
 	ReplicaType   string        `yaml:"replica_type"`
 	MasterAddress string        `yaml:"master_address"`
 	SyncInterval  time.Duration `yaml:"sync_interval"`
+	Streaming     bool          `yaml:"streaming"`
+	MaxChunkSize  string        `yaml:"max_chunk_size"`
+
+	MinReplicasToAck int           `yaml:"min_replicas_to_ack"`
+	AckTimeout       time.Duration `yaml:"ack_timeout"`
 }
 
 type ClientConfig struct {
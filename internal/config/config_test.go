@@ -12,6 +12,8 @@ const (
 	correctConfig = `
 engine:
   type: "in_memory"
+  max_memory: "64MB"
+  eviction_policy: "allkeys-lru"
 network:
   address: "127.0.0.1:3223"
   max_connections: 100
@@ -27,10 +29,19 @@ wal:
   max_segment_size: "10MB"
   data_directory: "/data/spider/wal"
   file_name: "write_ahead_log"
+  durability: "always"
+  key_file: "/data/spider/wal.key"
+  snapshot_directory: "/data/spider/snapshot"
+  snapshot_interval: "5m"
+  compaction_interval: "1m"
 replication:
   replica_type: "slave"
   master_address: "127.0.0.1:3232"
   sync_interval: "1s"
+  streaming: true
+  max_chunk_size: "1MB"
+  min_replicas_to_ack: 1
+  ack_timeout: "500ms"
 `
 )
 
@@ -54,7 +65,9 @@ func Test_NewConfig(t *testing.T) {
 
 			expectedConfig: &Config{
 				Engine: &EngineConfig{
-					EngineType: "in_memory",
+					EngineType:     "in_memory",
+					MaxMemory:      "64MB",
+					EvictionPolicy: "allkeys-lru",
 				},
 
 				Network: &NetworkConfig{
@@ -75,11 +88,23 @@ func Test_NewConfig(t *testing.T) {
 					MaxSegmentSize: "10MB",
 					DataDirectory:  "/data/spider/wal",
 					FileName:       "write_ahead_log",
+					Durability:     "always",
+					KeyFile:        "/data/spider/wal.key",
+
+					SnapshotDirectory: "/data/spider/snapshot",
+					SnapshotInterval:  5 * time.Minute,
+
+					CompactionInterval: time.Minute,
 				},
 				Replication: &ReplicaConfig{
 					ReplicaType:   "slave",
 					MasterAddress: "127.0.0.1:3232",
 					SyncInterval:  time.Second,
+					Streaming:     true,
+					MaxChunkSize:  "1MB",
+
+					MinReplicasToAck: 1,
+					AckTimeout:       500 * time.Millisecond,
 				},
 			},
 		},
@@ -1,8 +1,68 @@
 package commands
 
 const (
-	GetCommand       = 0
-	SetCommand       = 1
-	DelCommand       = 2
-	IncorrectCommand = -1
+	GetCommand           = 0
+	SetCommand           = 1
+	DelCommand           = 2
+	ExpireCommand        = 3
+	TTLCommand           = 4
+	PersistCommand       = 5
+	ExpireAtCommand      = 6
+	RangeCommand         = 7
+	PrefixCommand        = 8
+	ScanCommand          = 9
+	MGetCommand          = 10
+	MSetCommand          = 11
+	MDelCommand          = 12
+	IncrCommand          = 13
+	DecrCommand          = 14
+	IncrByCommand        = 15
+	IncrByFloatCommand   = 16
+	CasCommand           = 17
+	MultiCommand         = 18
+	ExecCommand          = 19
+	DiscardCommand       = 20
+	GetVerCommand        = 21
+	WatchCommand         = 22
+	HSetCommand          = 23
+	HGetCommand          = 24
+	HDelCommand          = 25
+	HGetAllCommand       = 26
+	LPushCommand         = 27
+	RPushCommand         = 28
+	LPopCommand          = 29
+	RPopCommand          = 30
+	LRangeCommand        = 31
+	SAddCommand          = 32
+	SRemCommand          = 33
+	SMembersCommand      = 34
+	SIsMemberCommand     = 35
+	ZAddCommand          = 36
+	ZRemCommand          = 37
+	ZScoreCommand        = 38
+	ZRangeCommand        = 39
+	ZRangeByScoreCommand = 40
+	ZRankCommand         = 41
+	ZIncrByCommand       = 42
+	SaveCommand          = 43
+	BgSaveCommand        = 44
+	InfoCommand          = 45
+	IncorrectCommand     = -1
+)
+
+const (
+	ExpireSeconds        = "EX"
+	ExpireMilliseconds   = "PX"
+	ExpireAtMilliseconds = "PXAT"
+
+	NotExistsModifier = "NX"
+	ExistsModifier    = "XX"
+	IfEqualModifier   = "IFEQ"
+	IfVersionModifier = "IFVER"
+
+	WithScoresModifier = "WITHSCORES"
+
+	LimitModifier = "LIMIT"
+	MatchModifier = "MATCH"
+	CountModifier = "COUNT"
 )
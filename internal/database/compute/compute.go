@@ -4,6 +4,8 @@ import (
 	"errors"
 	"inmemorykvdb/internal/database/commands"
 	"inmemorykvdb/internal/database/request"
+	"math"
+	"strconv"
 	"strings"
 
 	"go.uber.org/zap"
@@ -12,6 +14,14 @@ import (
 const (
 	minDataLen      = 2
 	enterSymbolsLen = 2
+
+	modifierLen = 2
+	casArgsLen  = 3
+
+	hsetMinArgsLen = 3
+	lrangeArgsLen  = 3
+	zaddMinArgsLen = 3
+	zincrbyArgsLen = 3
 )
 
 type Compute struct {
@@ -30,8 +40,7 @@ func (c *Compute) Parse(data string) (request.Request, error) {
 	splittedData := strings.Split(data, " ")
 
 	if len(splittedData) < 2 {
-		c.logger.Error("could not to parse less than two arguments")
-		return request.Request{RequestType: commands.IncorrectCommand}, errors.New("could not to parse less than two arguments")
+		return c.parseStandalone(splittedData[0])
 	}
 
 	stringCommand := splittedData[0]
@@ -56,6 +65,27 @@ func (c *Compute) Parse(data string) (request.Request, error) {
 	return request.Request{RequestType: parsedCommand, Args: parsedArgs}, nil
 }
 
+func (c *Compute) parseStandalone(stringCommand string) (request.Request, error) {
+	parsedCommand, err := c.parseCommand(strings.TrimRight(stringCommand, "\r\n"))
+
+	if err != nil || !isStandalone(parsedCommand) {
+		c.logger.Error("could not to parse less than two arguments")
+		return request.Request{RequestType: commands.IncorrectCommand}, errors.New("could not to parse less than two arguments")
+	}
+
+	return request.Request{RequestType: parsedCommand}, nil
+}
+
+func isStandalone(command int) bool {
+	switch command {
+	case commands.MultiCommand, commands.ExecCommand, commands.DiscardCommand, commands.SaveCommand, commands.BgSaveCommand,
+		commands.InfoCommand:
+		return true
+	}
+
+	return false
+}
+
 func (c *Compute) parseCommand(stringCommand string) (int, error) {
 
 	c.logger.Debug("started parse command")
@@ -83,6 +113,258 @@ func (c *Compute) parseCommand(stringCommand string) (int, error) {
 
 		c.logger.Debug("command parsed as set")
 
+	case "EXPIRE":
+
+		parsedCommand = commands.ExpireCommand
+
+		c.logger.Debug("command parsed as expire")
+
+	case "TTL":
+
+		parsedCommand = commands.TTLCommand
+
+		c.logger.Debug("command parsed as ttl")
+
+	case "PERSIST":
+
+		parsedCommand = commands.PersistCommand
+
+		c.logger.Debug("command parsed as persist")
+
+	case "RANGE":
+
+		parsedCommand = commands.RangeCommand
+
+		c.logger.Debug("command parsed as range")
+
+	case "PREFIX":
+
+		parsedCommand = commands.PrefixCommand
+
+		c.logger.Debug("command parsed as prefix")
+
+	case "SCAN":
+
+		parsedCommand = commands.ScanCommand
+
+		c.logger.Debug("command parsed as scan")
+
+	case "MGET":
+
+		parsedCommand = commands.MGetCommand
+
+		c.logger.Debug("command parsed as mget")
+
+	case "MSET":
+
+		parsedCommand = commands.MSetCommand
+
+		c.logger.Debug("command parsed as mset")
+
+	case "MDEL":
+
+		parsedCommand = commands.MDelCommand
+
+		c.logger.Debug("command parsed as mdel")
+
+	case "INCR":
+
+		parsedCommand = commands.IncrCommand
+
+		c.logger.Debug("command parsed as incr")
+
+	case "DECR":
+
+		parsedCommand = commands.DecrCommand
+
+		c.logger.Debug("command parsed as decr")
+
+	case "INCRBY":
+
+		parsedCommand = commands.IncrByCommand
+
+		c.logger.Debug("command parsed as incrby")
+
+	case "INCRBYFLOAT":
+
+		parsedCommand = commands.IncrByFloatCommand
+
+		c.logger.Debug("command parsed as incrbyfloat")
+
+	case "CAS":
+
+		parsedCommand = commands.CasCommand
+
+		c.logger.Debug("command parsed as cas")
+
+	case "MULTI":
+
+		parsedCommand = commands.MultiCommand
+
+		c.logger.Debug("command parsed as multi")
+
+	case "EXEC":
+
+		parsedCommand = commands.ExecCommand
+
+		c.logger.Debug("command parsed as exec")
+
+	case "DISCARD":
+
+		parsedCommand = commands.DiscardCommand
+
+		c.logger.Debug("command parsed as discard")
+
+	case "GETVER":
+
+		parsedCommand = commands.GetVerCommand
+
+		c.logger.Debug("command parsed as getver")
+
+	case "WATCH":
+
+		parsedCommand = commands.WatchCommand
+
+		c.logger.Debug("command parsed as watch")
+
+	case "HSET":
+
+		parsedCommand = commands.HSetCommand
+
+		c.logger.Debug("command parsed as hset")
+
+	case "HGET":
+
+		parsedCommand = commands.HGetCommand
+
+		c.logger.Debug("command parsed as hget")
+
+	case "HDEL":
+
+		parsedCommand = commands.HDelCommand
+
+		c.logger.Debug("command parsed as hdel")
+
+	case "HGETALL":
+
+		parsedCommand = commands.HGetAllCommand
+
+		c.logger.Debug("command parsed as hgetall")
+
+	case "LPUSH":
+
+		parsedCommand = commands.LPushCommand
+
+		c.logger.Debug("command parsed as lpush")
+
+	case "RPUSH":
+
+		parsedCommand = commands.RPushCommand
+
+		c.logger.Debug("command parsed as rpush")
+
+	case "LPOP":
+
+		parsedCommand = commands.LPopCommand
+
+		c.logger.Debug("command parsed as lpop")
+
+	case "RPOP":
+
+		parsedCommand = commands.RPopCommand
+
+		c.logger.Debug("command parsed as rpop")
+
+	case "LRANGE":
+
+		parsedCommand = commands.LRangeCommand
+
+		c.logger.Debug("command parsed as lrange")
+
+	case "SADD":
+
+		parsedCommand = commands.SAddCommand
+
+		c.logger.Debug("command parsed as sadd")
+
+	case "SREM":
+
+		parsedCommand = commands.SRemCommand
+
+		c.logger.Debug("command parsed as srem")
+
+	case "SMEMBERS":
+
+		parsedCommand = commands.SMembersCommand
+
+		c.logger.Debug("command parsed as smembers")
+
+	case "SISMEMBER":
+
+		parsedCommand = commands.SIsMemberCommand
+
+		c.logger.Debug("command parsed as sismember")
+
+	case "ZADD":
+
+		parsedCommand = commands.ZAddCommand
+
+		c.logger.Debug("command parsed as zadd")
+
+	case "ZREM":
+
+		parsedCommand = commands.ZRemCommand
+
+		c.logger.Debug("command parsed as zrem")
+
+	case "ZSCORE":
+
+		parsedCommand = commands.ZScoreCommand
+
+		c.logger.Debug("command parsed as zscore")
+
+	case "ZRANGE":
+
+		parsedCommand = commands.ZRangeCommand
+
+		c.logger.Debug("command parsed as zrange")
+
+	case "ZRANGEBYSCORE":
+
+		parsedCommand = commands.ZRangeByScoreCommand
+
+		c.logger.Debug("command parsed as zrangebyscore")
+
+	case "ZRANK":
+
+		parsedCommand = commands.ZRankCommand
+
+		c.logger.Debug("command parsed as zrank")
+
+	case "ZINCRBY":
+
+		parsedCommand = commands.ZIncrByCommand
+
+		c.logger.Debug("command parsed as zincrby")
+
+	case "SAVE":
+
+		parsedCommand = commands.SaveCommand
+
+		c.logger.Debug("command parsed as save")
+
+	case "BGSAVE":
+
+		parsedCommand = commands.BgSaveCommand
+
+		c.logger.Debug("command parsed as bgsave")
+
+	case "INFO":
+
+		parsedCommand = commands.InfoCommand
+
+		c.logger.Debug("command parsed as info")
+
 	default:
 
 		parsedCommand = commands.IncorrectCommand
@@ -98,29 +380,386 @@ func (c *Compute) parseArguments(command int, arguments []string) ([]string, err
 
 	c.logger.Debug("started to parse args")
 
-	var parsedArgs []string
+	arguments = trimEnterSymbols(arguments)
+
+	switch command {
+
+	case commands.SetCommand:
+
+		return c.parseSetArguments(arguments)
 
-	if command == commands.SetCommand {
+	case commands.ExpireCommand:
 
 		if len(arguments) < minDataLen {
-			return nil, errors.New("set command has two arguments")
+			return nil, errors.New("expire command has two arguments")
 		}
 
-		parsedArgs = []string{arguments[0], arguments[1]}
+		if _, err := strconv.ParseInt(arguments[1], 10, 64); err != nil {
+			return nil, errors.New("incorrect expiration time")
+		}
+
+		return []string{arguments[0], arguments[1]}, nil
+
+	case commands.RangeCommand:
 
-	} else {
-		parsedArgs = []string{arguments[0]}
+		if len(arguments) < minDataLen {
+			return nil, errors.New("range command has two arguments")
+		}
+
+		return parseLimit([]string{arguments[0], arguments[1]}, arguments[minDataLen:])
+
+	case commands.PrefixCommand:
+
+		if len(arguments) == 0 {
+			return nil, errors.New("command has no arguments")
+		}
+
+		return parseLimit([]string{arguments[0]}, arguments[1:])
+
+	case commands.ScanCommand:
+
+		return parseScanArguments(arguments)
+
+	case commands.MSetCommand:
+
+		if len(arguments) < minDataLen || len(arguments)%2 != 0 {
+			return nil, errors.New("mset command has pairs of arguments")
+		}
+
+		return arguments, nil
+
+	case commands.IncrByCommand:
+
+		if len(arguments) < minDataLen {
+			return nil, errors.New("incrby command has two arguments")
+		}
+
+		if _, err := strconv.ParseInt(arguments[1], 10, 64); err != nil {
+			return nil, errors.New("incorrect increment")
+		}
+
+		return []string{arguments[0], arguments[1]}, nil
+
+	case commands.IncrByFloatCommand:
+
+		if len(arguments) < minDataLen {
+			return nil, errors.New("incrbyfloat command has two arguments")
+		}
+
+		increment, err := strconv.ParseFloat(arguments[1], 64)
+
+		if err != nil || math.IsNaN(increment) || math.IsInf(increment, 0) {
+			return nil, errors.New("incorrect increment")
+		}
+
+		return []string{arguments[0], arguments[1]}, nil
+
+	case commands.MultiCommand, commands.ExecCommand, commands.DiscardCommand:
+
+		return nil, nil
+
+	case commands.CasCommand:
+
+		if len(arguments) < casArgsLen {
+			return nil, errors.New("cas command has three arguments")
+		}
+
+		return arguments[:casArgsLen], nil
+
+	case commands.HSetCommand:
+
+		if len(arguments) < hsetMinArgsLen || len(arguments)%2 == 0 {
+			return nil, errors.New("hset command has key and pairs of fields and values")
+		}
+
+		return arguments, nil
+
+	case commands.HGetCommand:
+
+		if len(arguments) < minDataLen {
+			return nil, errors.New("hget command has two arguments")
+		}
+
+		return arguments[:minDataLen], nil
+
+	case commands.SIsMemberCommand, commands.ZScoreCommand, commands.ZRankCommand:
+
+		if len(arguments) < minDataLen {
+			return nil, errors.New("command has key and member")
+		}
+
+		return arguments[:minDataLen], nil
+
+	case commands.ZAddCommand:
+
+		if len(arguments) < zaddMinArgsLen || len(arguments)%2 == 0 {
+			return nil, errors.New("zadd command has key and pairs of scores and members")
+		}
+
+		for i := 1; i < len(arguments); i += 2 {
+			if !isScore(arguments[i]) {
+				return nil, errors.New("incorrect score")
+			}
+		}
+
+		return arguments, nil
+
+	case commands.ZIncrByCommand:
+
+		if len(arguments) < zincrbyArgsLen {
+			return nil, errors.New("zincrby command has three arguments")
+		}
+
+		if !isScore(arguments[1]) {
+			return nil, errors.New("incorrect increment")
+		}
+
+		return arguments[:zincrbyArgsLen], nil
+
+	case commands.ZRangeCommand:
+
+		if len(arguments) < lrangeArgsLen {
+			return nil, errors.New("zrange command has three arguments")
+		}
+
+		for _, index := range arguments[1:lrangeArgsLen] {
+			if _, err := strconv.Atoi(index); err != nil {
+				return nil, errors.New("incorrect index")
+			}
+		}
+
+		return parseWithScores(arguments[:lrangeArgsLen], arguments[lrangeArgsLen:]), nil
+
+	case commands.ZRangeByScoreCommand:
+
+		if len(arguments) < lrangeArgsLen {
+			return nil, errors.New("zrangebyscore command has three arguments")
+		}
+
+		for _, score := range arguments[1:lrangeArgsLen] {
+			if !isScore(score) {
+				return nil, errors.New("incorrect score")
+			}
+		}
+
+		return parseWithScores(arguments[:lrangeArgsLen], arguments[lrangeArgsLen:]), nil
+
+	case commands.HDelCommand, commands.LPushCommand, commands.RPushCommand, commands.SAddCommand, commands.SRemCommand,
+		commands.ZRemCommand:
+
+		if len(arguments) < minDataLen {
+			return nil, errors.New("command has key and elements")
+		}
+
+		return arguments, nil
+
+	case commands.LRangeCommand:
+
+		if len(arguments) < lrangeArgsLen {
+			return nil, errors.New("lrange command has three arguments")
+		}
+
+		for _, index := range arguments[1:lrangeArgsLen] {
+			if _, err := strconv.Atoi(index); err != nil {
+				return nil, errors.New("incorrect index")
+			}
+		}
+
+		return arguments[:lrangeArgsLen], nil
+
+	case commands.MGetCommand, commands.MDelCommand, commands.WatchCommand:
+
+		if len(arguments) == 0 {
+			return nil, errors.New("command has no arguments")
+		}
+
+		return arguments, nil
+	}
+
+	if len(arguments) == 0 {
+		return nil, errors.New("command has no arguments")
+	}
+
+	return []string{arguments[0]}, nil
+}
+
+func (c *Compute) parseSetArguments(arguments []string) ([]string, error) {
+
+	if len(arguments) < minDataLen {
+		return nil, errors.New("set command has two arguments")
+	}
+
+	parsedArgs := []string{arguments[0], arguments[1]}
+
+	var expiration, condition []string
+
+	modifiers := arguments[minDataLen:]
+
+	for len(modifiers) != 0 {
+		modifier := strings.ToUpper(modifiers[0])
+
+		switch modifier {
+		case commands.ExpireSeconds, commands.ExpireMilliseconds:
+			if expiration != nil {
+				return nil, errors.New("set command has more than one expiration")
+			}
+
+			if len(modifiers) < modifierLen {
+				return nil, errors.New("expiration modifier has no time")
+			}
+
+			ttl, err := strconv.ParseInt(modifiers[1], 10, 64)
+
+			if err != nil || ttl <= 0 {
+				return nil, errors.New("incorrect expiration time")
+			}
+
+			c.logger.Debug("set command has expiration")
+
+			expiration = []string{modifier, modifiers[1]}
+			modifiers = modifiers[modifierLen:]
+
+		case commands.NotExistsModifier, commands.ExistsModifier:
+			if condition != nil {
+				return nil, errors.New("set command has more than one condition")
+			}
+
+			condition = []string{modifier}
+			modifiers = modifiers[1:]
+
+		case commands.IfEqualModifier:
+			if condition != nil {
+				return nil, errors.New("set command has more than one condition")
+			}
+
+			if len(modifiers) < modifierLen {
+				return nil, errors.New("ifeq modifier has no value")
+			}
+
+			condition = []string{modifier, modifiers[1]}
+			modifiers = modifiers[modifierLen:]
+
+		case commands.IfVersionModifier:
+			if condition != nil {
+				return nil, errors.New("set command has more than one condition")
+			}
+
+			if len(modifiers) < modifierLen {
+				return nil, errors.New("ifver modifier has no version")
+			}
+
+			if _, err := strconv.ParseUint(modifiers[1], 10, 64); err != nil {
+				return nil, errors.New("incorrect version")
+			}
+
+			condition = []string{modifier, modifiers[1]}
+			modifiers = modifiers[modifierLen:]
+
+		default:
+			return nil, errors.New("unknown set modifier")
+		}
+	}
+
+	if condition != nil {
+		c.logger.Debug("set command has condition")
+	}
+
+	parsedArgs = append(parsedArgs, expiration...)
+
+	return append(parsedArgs, condition...), nil
+}
+
+func parseLimit(parsedArgs []string, modifiers []string) ([]string, error) {
+	if len(modifiers) == 0 || strings.ToUpper(modifiers[0]) != commands.LimitModifier {
+		return parsedArgs, nil
+	}
+
+	if len(modifiers) < modifierLen {
+		return nil, errors.New("limit modifier has no count")
 	}
 
-	lastArg := arguments[len(parsedArgs)-1]
+	limit, err := strconv.Atoi(modifiers[1])
 
-	if len(lastArg) >= enterSymbolsLen && string(lastArg[len(lastArg)-enterSymbolsLen:]) == "\r\n" {
-		parsedArgs[len(arguments)-1] = lastArg[:len(lastArg)-enterSymbolsLen]
+	if err != nil || limit <= 0 {
+		return nil, errors.New("incorrect limit")
+	}
+
+	return append(parsedArgs, commands.LimitModifier, modifiers[1]), nil
+}
+
+func parseScanArguments(arguments []string) ([]string, error) {
+	if len(arguments) == 0 {
+		return nil, errors.New("scan command has no cursor")
+	}
 
-		if parsedArgs[len(arguments)-1] == "" {
-			return nil, errors.New("set command has two arguments")
+	if _, err := strconv.ParseUint(arguments[0], 10, 64); err != nil {
+		return nil, errors.New("incorrect cursor")
+	}
+
+	var match, count []string
+
+	modifiers := arguments[1:]
+
+	for len(modifiers) >= modifierLen {
+		modifier := strings.ToUpper(modifiers[0])
+
+		if modifier == commands.MatchModifier {
+			match = []string{commands.MatchModifier, modifiers[1]}
+		} else if modifier == commands.CountModifier {
+			parsedCount, err := strconv.Atoi(modifiers[1])
+
+			if err != nil || parsedCount <= 0 {
+				return nil, errors.New("incorrect count")
+			}
+
+			count = []string{commands.CountModifier, modifiers[1]}
+		} else {
+			break
 		}
+
+		modifiers = modifiers[modifierLen:]
+	}
+
+	parsedArgs := append([]string{arguments[0]}, match...)
+
+	return append(parsedArgs, count...), nil
+}
+
+func isScore(score string) bool {
+	parsed, err := strconv.ParseFloat(score, 64)
+
+	return err == nil && !math.IsNaN(parsed)
+}
+
+func parseWithScores(parsedArgs []string, modifiers []string) []string {
+	if len(modifiers) == 0 || strings.ToUpper(modifiers[0]) != commands.WithScoresModifier {
+		return parsedArgs
 	}
 
-	return parsedArgs, nil
+	return append(parsedArgs, commands.WithScoresModifier)
+}
+
+func trimEnterSymbols(arguments []string) []string {
+	if len(arguments) == 0 {
+		return arguments
+	}
+
+	lastArg := arguments[len(arguments)-1]
+
+	if len(lastArg) < enterSymbolsLen || lastArg[len(lastArg)-enterSymbolsLen:] != "\r\n" {
+		return arguments
+	}
+
+	trimmed := make([]string, len(arguments))
+	copy(trimmed, arguments)
+
+	lastArg = lastArg[:len(lastArg)-enterSymbolsLen]
+
+	if lastArg == "" {
+		return trimmed[:len(trimmed)-1]
+	}
+
+	trimmed[len(trimmed)-1] = lastArg
+
+	return trimmed
 }
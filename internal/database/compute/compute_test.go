@@ -141,8 +141,8 @@ func Test_Parse(t *testing.T) {
 
 			data: "SET qwerty asdfgh [poiuyt]",
 
-			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"qwerty", "asdfgh"}},
-			expectedErr:     nil,
+			expectedRequest: request.Request{RequestType: commands.SetCommand},
+			expectedErr:     errors.New("unknown set modifier"),
 		},
 
 		{
@@ -189,6 +189,491 @@ func Test_Parse(t *testing.T) {
 			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
 			expectedErr:     nil,
 		},
+
+		{
+			name: "set request with expiration in seconds",
+
+			data: "set biba boba ex 30\r\n",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "EX", "30"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "set request with expiration in milliseconds",
+
+			data: "SET biba boba PX 1500",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "PX", "1500"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "set request with incorrect expiration",
+
+			data: "SET biba boba EX -5",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand},
+			expectedErr:     errors.New("incorrect expiration time"),
+		},
+
+		{
+			name: "set request with expiration without time",
+
+			data: "SET biba boba EX",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand},
+			expectedErr:     errors.New("expiration modifier has no time"),
+		},
+
+		{
+			name: "correct expire request",
+
+			data: "expire biba 10",
+
+			expectedRequest: request.Request{RequestType: commands.ExpireCommand, Args: []string{"biba", "10"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "expire request without time",
+
+			data: "EXPIRE biba",
+
+			expectedRequest: request.Request{RequestType: commands.ExpireCommand},
+			expectedErr:     errors.New("expire command has two arguments"),
+		},
+
+		{
+			name: "expire request with incorrect time",
+
+			data: "EXPIRE biba boba",
+
+			expectedRequest: request.Request{RequestType: commands.ExpireCommand},
+			expectedErr:     errors.New("incorrect expiration time"),
+		},
+
+		{
+			name: "correct ttl request",
+
+			data: "ttl biba",
+
+			expectedRequest: request.Request{RequestType: commands.TTLCommand, Args: []string{"biba"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "correct range request",
+
+			data: "range a z",
+
+			expectedRequest: request.Request{RequestType: commands.RangeCommand, Args: []string{"a", "z"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "range request with limit",
+
+			data: "RANGE a z limit 10\r\n",
+
+			expectedRequest: request.Request{RequestType: commands.RangeCommand, Args: []string{"a", "z", "LIMIT", "10"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "range request with one argument",
+
+			data: "RANGE a",
+
+			expectedRequest: request.Request{RequestType: commands.RangeCommand},
+			expectedErr:     errors.New("range command has two arguments"),
+		},
+
+		{
+			name: "range request with incorrect limit",
+
+			data: "RANGE a z LIMIT 0",
+
+			expectedRequest: request.Request{RequestType: commands.RangeCommand},
+			expectedErr:     errors.New("incorrect limit"),
+		},
+
+		{
+			name: "range request with limit without count",
+
+			data: "RANGE a z LIMIT",
+
+			expectedRequest: request.Request{RequestType: commands.RangeCommand},
+			expectedErr:     errors.New("limit modifier has no count"),
+		},
+
+		{
+			name: "correct prefix request",
+
+			data: "prefix user:",
+
+			expectedRequest: request.Request{RequestType: commands.PrefixCommand, Args: []string{"user:"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "prefix request with limit",
+
+			data: "PREFIX user: LIMIT 5",
+
+			expectedRequest: request.Request{RequestType: commands.PrefixCommand, Args: []string{"user:", "LIMIT", "5"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "scan request with cursor",
+
+			data: "scan 0",
+
+			expectedRequest: request.Request{RequestType: commands.ScanCommand, Args: []string{"0"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "scan request with modifiers",
+
+			data: "SCAN 17 count 5 match user:*\r\n",
+
+			expectedRequest: request.Request{RequestType: commands.ScanCommand, Args: []string{"17", "MATCH", "user:*", "COUNT", "5"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "scan request with incorrect cursor",
+
+			data: "SCAN biba",
+
+			expectedRequest: request.Request{RequestType: commands.ScanCommand},
+			expectedErr:     errors.New("incorrect cursor"),
+		},
+
+		{
+			name: "scan request with incorrect count",
+
+			data: "SCAN 0 COUNT -1",
+
+			expectedRequest: request.Request{RequestType: commands.ScanCommand},
+			expectedErr:     errors.New("incorrect count"),
+		},
+
+		{
+			name: "correct persist request",
+
+			data: "PERSIST biba\r\n",
+
+			expectedRequest: request.Request{RequestType: commands.PersistCommand, Args: []string{"biba"}},
+			expectedErr:     nil,
+		},
+		{
+			name: "correct mget request",
+
+			data: "MGET biba boba",
+
+			expectedRequest: request.Request{RequestType: commands.MGetCommand, Args: []string{"biba", "boba"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "correct mset request",
+
+			data: "mset biba 1 boba 2",
+
+			expectedRequest: request.Request{RequestType: commands.MSetCommand, Args: []string{"biba", "1", "boba", "2"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "mset request with odd arguments",
+
+			data: "MSET biba 1 boba",
+
+			expectedRequest: request.Request{RequestType: commands.MSetCommand},
+			expectedErr:     errors.New("mset command has pairs of arguments"),
+		},
+
+		{
+			name: "correct incr request",
+
+			data: "INCR biba",
+
+			expectedRequest: request.Request{RequestType: commands.IncrCommand, Args: []string{"biba"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "correct incrby request",
+
+			data: "incrby biba -5",
+
+			expectedRequest: request.Request{RequestType: commands.IncrByCommand, Args: []string{"biba", "-5"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "incrby request with incorrect increment",
+
+			data: "INCRBY biba 1.5",
+
+			expectedRequest: request.Request{RequestType: commands.IncrByCommand},
+			expectedErr:     errors.New("incorrect increment"),
+		},
+
+		{
+			name: "correct incrbyfloat request",
+
+			data: "INCRBYFLOAT biba 1.5",
+
+			expectedRequest: request.Request{RequestType: commands.IncrByFloatCommand, Args: []string{"biba", "1.5"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "set request with condition and expiration",
+
+			data: "SET lock 1 nx ex 10",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"lock", "1", "EX", "10", "NX"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "set request with ifeq",
+
+			data: "SET lock 2 IFEQ 1",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"lock", "2", "IFEQ", "1"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "set request with two conditions",
+
+			data: "SET lock 1 NX XX",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand},
+			expectedErr:     errors.New("set command has more than one condition"),
+		},
+
+		{
+			name: "set request with two expirations",
+
+			data: "SET lock 1 EX 10 PX 100",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand},
+			expectedErr:     errors.New("set command has more than one expiration"),
+		},
+
+		{
+			name: "set request with unknown modifier",
+
+			data: "SET lock 1 NX KEEP",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand},
+			expectedErr:     errors.New("unknown set modifier"),
+		},
+
+		{
+			name: "correct cas request",
+
+			data: "CAS lock 1 2",
+
+			expectedRequest: request.Request{RequestType: commands.CasCommand, Args: []string{"lock", "1", "2"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "cas request without new value",
+
+			data: "CAS lock 1",
+
+			expectedRequest: request.Request{RequestType: commands.CasCommand},
+			expectedErr:     errors.New("cas command has three arguments"),
+		},
+
+		{
+			name: "correct multi request",
+
+			data: "MULTI\r\n",
+
+			expectedRequest: request.Request{RequestType: commands.MultiCommand},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "correct exec request",
+
+			data: "exec",
+
+			expectedRequest: request.Request{RequestType: commands.ExecCommand},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "correct bgsave request",
+
+			data: "BGSAVE\n",
+
+			expectedRequest: request.Request{RequestType: commands.BgSaveCommand},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "correct info request",
+
+			data: "info\n",
+
+			expectedRequest: request.Request{RequestType: commands.InfoCommand},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "set request with ifver",
+
+			data: "SET biba 1 ifver 42",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "1", "IFVER", "42"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "set request with incorrect version",
+
+			data: "SET biba 1 IFVER boba",
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand},
+			expectedErr:     errors.New("incorrect version"),
+		},
+
+		{
+			name: "correct watch request",
+
+			data: "WATCH biba boba",
+
+			expectedRequest: request.Request{RequestType: commands.WatchCommand, Args: []string{"biba", "boba"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "correct hset request",
+
+			data: "HSET user name biba age 20",
+
+			expectedRequest: request.Request{RequestType: commands.HSetCommand, Args: []string{"user", "name", "biba", "age", "20"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "hset request without value",
+
+			data: "HSET user name",
+
+			expectedRequest: request.Request{RequestType: commands.HSetCommand},
+			expectedErr:     errors.New("hset command has key and pairs of fields and values"),
+		},
+
+		{
+			name: "correct lrange request",
+
+			data: "LRANGE queue 0 -1",
+
+			expectedRequest: request.Request{RequestType: commands.LRangeCommand, Args: []string{"queue", "0", "-1"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "lrange request with incorrect index",
+
+			data: "LRANGE queue 0 end",
+
+			expectedRequest: request.Request{RequestType: commands.LRangeCommand},
+			expectedErr:     errors.New("incorrect index"),
+		},
+
+		{
+			name: "correct sadd request",
+
+			data: "sadd tags a b",
+
+			expectedRequest: request.Request{RequestType: commands.SAddCommand, Args: []string{"tags", "a", "b"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "correct zadd request",
+
+			data: "ZADD board 10 biba 5.5 boba",
+
+			expectedRequest: request.Request{RequestType: commands.ZAddCommand, Args: []string{"board", "10", "biba", "5.5", "boba"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "zadd request with incorrect score",
+
+			data: "ZADD board ten biba",
+
+			expectedRequest: request.Request{RequestType: commands.ZAddCommand},
+			expectedErr:     errors.New("incorrect score"),
+		},
+
+		{
+			name: "zadd request without member",
+
+			data: "ZADD board 10",
+
+			expectedRequest: request.Request{RequestType: commands.ZAddCommand},
+			expectedErr:     errors.New("zadd command has key and pairs of scores and members"),
+		},
+
+		{
+			name: "correct zrange request with scores",
+
+			data: "ZRANGE board 0 -1 WITHSCORES",
+
+			expectedRequest: request.Request{RequestType: commands.ZRangeCommand, Args: []string{"board", "0", "-1", commands.WithScoresModifier}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "correct zrangebyscore request",
+
+			data: "ZRANGEBYSCORE board 1 2.5",
+
+			expectedRequest: request.Request{RequestType: commands.ZRangeByScoreCommand, Args: []string{"board", "1", "2.5"}},
+			expectedErr:     nil,
+		},
+
+		{
+			name: "zincrby request with incorrect increment",
+
+			data: "ZINCRBY board much biba",
+
+			expectedRequest: request.Request{RequestType: commands.ZIncrByCommand},
+			expectedErr:     errors.New("incorrect increment"),
+		},
+
+		{
+			name: "zscore request without member",
+
+			data: "ZSCORE board",
+
+			expectedRequest: request.Request{RequestType: commands.ZScoreCommand},
+			expectedErr:     errors.New("command has key and member"),
+		},
+
+		{
+			name: "correct mdel request",
+
+			data: "MDEL biba boba",
+
+			expectedRequest: request.Request{RequestType: commands.MDelCommand, Args: []string{"biba", "boba"}},
+			expectedErr:     nil,
+		},
 	}
 
 	compute, _ := NewCompute(zap.NewNop())
@@ -242,6 +727,60 @@ func Test_parseCommands(t *testing.T) {
 			expectedErr:           nil,
 		},
 
+		{
+			name: "expire command",
+
+			stringCommand: "Expire",
+
+			expectedParsedCommand: commands.ExpireCommand,
+			expectedErr:           nil,
+		},
+
+		{
+			name: "ttl command",
+
+			stringCommand: "ttl",
+
+			expectedParsedCommand: commands.TTLCommand,
+			expectedErr:           nil,
+		},
+
+		{
+			name: "persist command",
+
+			stringCommand: "PERSIST",
+
+			expectedParsedCommand: commands.PersistCommand,
+			expectedErr:           nil,
+		},
+
+		{
+			name: "range command",
+
+			stringCommand: "Range",
+
+			expectedParsedCommand: commands.RangeCommand,
+			expectedErr:           nil,
+		},
+
+		{
+			name: "prefix command",
+
+			stringCommand: "prefix",
+
+			expectedParsedCommand: commands.PrefixCommand,
+			expectedErr:           nil,
+		},
+
+		{
+			name: "scan command",
+
+			stringCommand: "sCaN",
+
+			expectedParsedCommand: commands.ScanCommand,
+			expectedErr:           nil,
+		},
+
 		{
 			name: "incorrect command",
 
@@ -329,6 +868,26 @@ func Test_parseArguments(t *testing.T) {
 			expectedErr:        errors.New("set command has two arguments"),
 		},
 
+		{
+			name: "set request with unknown modifier",
+
+			command:   commands.SetCommand,
+			arguments: []string{"biba", "boba", "lol", "10"},
+
+			expectedParsedArgs: nil,
+			expectedErr:        errors.New("unknown set modifier"),
+		},
+
+		{
+			name: "get request with only enter symbols",
+
+			command:   commands.GetCommand,
+			arguments: []string{"\r\n"},
+
+			expectedParsedArgs: nil,
+			expectedErr:        errors.New("command has no arguments"),
+		},
+
 		{
 			name: "uncorrect set request with enter symbols as last args",
 
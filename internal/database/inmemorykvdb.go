@@ -13,6 +13,8 @@ type computeLayer interface {
 
 type storageLayer interface {
 	HandleRequest(request.Request) (string, error)
+	HandleTransaction([]request.Request, map[string]uint64) ([]string, bool, error)
+	Watch([]string) map[string]uint64
 }
 
 type InMemoryKeyValueDatabase struct {
@@ -42,6 +44,11 @@ func (db *InMemoryKeyValueDatabase) HandleRequest(data string) (string, error) {
 		return "", err
 	}
 
+	return db.handleParsed(req)
+}
+
+func (db *InMemoryKeyValueDatabase) handleParsed(req request.Request) (string, error) {
+
 	db.logger.Debug("send request to storage")
 	resp, err := db.storage.HandleRequest(req)
 	db.logger.Debug("storage returned a response")
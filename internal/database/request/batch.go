@@ -3,6 +3,7 @@ package request
 import (
 	"errors"
 	"fmt"
+	"inmemorykvdb/internal/database/commands"
 	"strings"
 )
 
@@ -15,6 +16,10 @@ type Batch struct {
 	Data     []*Request
 	ByteSize int
 	MaxSize  int
+	LastLSN  uint64
+
+	// Reset clears the storage before the batch, a slave sends it before a full sync over its own data
+	Reset bool
 }
 
 func NewBatch(maxSize int) *Batch {
@@ -55,20 +60,41 @@ func (b *Batch) LoadFilesToBatch(fileNames []string, files [][]byte) error {
 }
 
 func (b *Batch) LoadData(data []byte) error {
-	var startIndex int
+	var offset int
 
 	var hasUnparsedRequests bool
 
-	for i, elem := range data {
-		if string([]byte{elem}) == EndElement {
-			unparsed, err := NewRequest(string(data[startIndex:i]))
-			startIndex = i + 1
+	var group []*Request
+	var groupLSN uint64
+	var inGroup bool
+
+	for offset < len(data) {
+		unparsed, n, err := NewRequest(data[offset:])
+
+		if n == 0 {
+			return fmt.Errorf("has invalid record at offset %d: %w", offset, err)
+		}
+
+		offset += n
+
+		if err != nil {
+			hasUnparsedRequests = true
+			continue
+		}
 
-			if err == nil {
-				b.Data = append(b.Data, unparsed)
-			} else if !hasUnparsedRequests {
-				hasUnparsedRequests = true
-			}
+		switch {
+		case unparsed.RequestType == commands.MultiCommand:
+			group, groupLSN, inGroup = nil, unparsed.LSN, true
+		case unparsed.RequestType == commands.ExecCommand:
+			b.Data = append(b.Data, group...)
+			b.LastLSN = max(b.LastLSN, groupLSN, unparsed.LSN)
+			group, inGroup = nil, false
+		case inGroup:
+			group = append(group, unparsed)
+			groupLSN = max(groupLSN, unparsed.LSN)
+		default:
+			b.Data = append(b.Data, unparsed)
+			b.LastLSN = max(b.LastLSN, unparsed.LSN)
 		}
 	}
 
@@ -76,6 +102,10 @@ func (b *Batch) LoadData(data []byte) error {
 		return errors.New("has unparsed requests")
 	}
 
+	if inGroup {
+		return errors.New("has incomplete transaction")
+	}
+
 	return nil
 }
 
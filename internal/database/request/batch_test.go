@@ -2,12 +2,23 @@ package request
 
 import (
 	"errors"
+	"fmt"
 	"inmemorykvdb/internal/database/commands"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+func records(records ...[]byte) []byte {
+	var data []byte
+
+	for _, record := range records {
+		data = append(data, record...)
+	}
+
+	return data
+}
+
 func Test_NewBatch(t *testing.T) {
 	t.Parallel()
 
@@ -54,7 +65,7 @@ func Test_ParseBatch(t *testing.T) {
 
 			requests: []Request{{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}},
 
-			expectedData: []byte("SET biba boba\n"),
+			expectedData: record(commands.SetCommand, "biba", "boba"),
 			expectedErr:  nil,
 		},
 		{
@@ -101,7 +112,7 @@ func Test_LoadData(t *testing.T) {
 		{
 			name: "correct data",
 
-			data: []byte("SET BIBA BOBA\nDEL BIBA\n"),
+			data: records(record(commands.SetCommand, "BIBA", "BOBA"), record(commands.DelCommand, "BIBA")),
 
 			expectedRequests: []*Request{
 				{
@@ -121,7 +132,7 @@ func Test_LoadData(t *testing.T) {
 		{
 			name: "uncorrect data",
 
-			data: []byte("SET BIBA BOBA\nLOL BIBA\n"),
+			data: records(record(commands.SetCommand, "BIBA", "BOBA"), record(commands.DelCommand)),
 
 			expectedRequests: []*Request{
 				{
@@ -132,8 +143,76 @@ func Test_LoadData(t *testing.T) {
 			},
 			expectedErr: errors.New("has unparsed requests"),
 		},
+		{
+			name: "transaction data",
+
+			data: records(
+				record(commands.DelCommand, "BIBA"),
+				record(commands.MultiCommand),
+				record(commands.SetCommand, "BIBA", "BOBA"),
+				record(commands.SetCommand, "BOBA", "BIBA"),
+				record(commands.ExecCommand),
+			),
+
+			expectedRequests: []*Request{
+				{
+					RequestType: commands.DelCommand,
+
+					Args: []string{"BIBA"},
+				},
+
+				{
+					RequestType: commands.SetCommand,
+
+					Args: []string{"BIBA", "BOBA"},
+				},
+
+				{
+					RequestType: commands.SetCommand,
+
+					Args: []string{"BOBA", "BIBA"},
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "incomplete transaction data",
+
+			data: records(
+				record(commands.DelCommand, "BIBA"),
+				record(commands.MultiCommand),
+				record(commands.SetCommand, "BIBA", "BOBA"),
+			),
+
+			expectedRequests: []*Request{
+				{
+					RequestType: commands.DelCommand,
+
+					Args: []string{"BIBA"},
+				},
+			},
+			expectedErr: errors.New("has incomplete transaction"),
+		},
 	}
 
+	torn := records(record(commands.SetCommand, "BIBA", "BOBA"), record(commands.DelCommand, "BIBA"))
+	torn = torn[:len(torn)-2]
+
+	testCases = append(testCases, testCase{
+		name: "torn tail data",
+
+		data: torn,
+
+		expectedRequests: []*Request{
+			{
+				RequestType: commands.SetCommand,
+
+				Args: []string{"BIBA", "BOBA"},
+			},
+		},
+		expectedErr: fmt.Errorf("has invalid record at offset %d: %w", len(record(commands.SetCommand, "BIBA", "BOBA")), ErrTruncatedRecord),
+	})
+
 	testMaxSize := 1000
 
 	for _, test := range testCases {
@@ -153,7 +232,7 @@ func Test_LoadFilesToBatch(t *testing.T) {
 		name string
 
 		fileNames []string
-		data      []string
+		data      [][]byte
 
 		expectedBatch []*Request
 		expectedErr   error
@@ -164,7 +243,11 @@ func Test_LoadFilesToBatch(t *testing.T) {
 			name: "correct loading",
 
 			fileNames: []string{"wal0.log", "wal1.log", "wal2.log"},
-			data:      []string{"SET biba boba" + EndElement, "SET biba boba" + EndElement, "DEL biba" + EndElement},
+			data: [][]byte{
+				record(commands.SetCommand, "biba", "boba"),
+				record(commands.SetCommand, "biba", "boba"),
+				record(commands.DelCommand, "biba"),
+			},
 
 			expectedBatch: []*Request{
 				{
@@ -190,7 +273,11 @@ func Test_LoadFilesToBatch(t *testing.T) {
 
 			fileNames: []string{"wal0.log", "wal1.log", "wal2.log"},
 
-			data: []string{"SET biba boba" + EndElement, "SET biba boba" + EndElement, "lol boba" + EndElement},
+			data: [][]byte{
+				record(commands.SetCommand, "biba", "boba"),
+				record(commands.SetCommand, "biba", "boba"),
+				[]byte("lol boba"),
+			},
 
 			expectedBatch: []*Request{
 				{
@@ -209,18 +296,58 @@ func Test_LoadFilesToBatch(t *testing.T) {
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
-			dataInBytes := make([][]byte, len(test.data))
-
-			for i := range dataInBytes {
-				dataInBytes[i] = []byte(test.data[i])
-			}
-
 			batch := NewBatch(1000)
 
-			err := batch.LoadFilesToBatch(test.fileNames, dataInBytes)
+			err := batch.LoadFilesToBatch(test.fileNames, test.data)
 
 			assert.Equal(t, test.expectedBatch, batch.Data)
 			assert.Equal(t, test.expectedErr, err)
 		})
 	}
 }
+
+func Test_LoadDataTracksLastLSN(t *testing.T) {
+	t.Parallel()
+
+	var data []byte
+
+	for _, req := range []*Request{
+		{LSN: 4, RequestType: commands.MultiCommand},
+		{LSN: 5, RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+		{LSN: 6, RequestType: commands.ExecCommand},
+	} {
+		encoded, _ := req.ParseToBytes()
+		data = append(data, encoded...)
+	}
+
+	batch := NewBatch(100)
+
+	err := batch.LoadData(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), batch.LastLSN)
+	assert.Equal(t, []*Request{{LSN: 5, RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}}, batch.Data)
+}
+
+func Test_LoadDataSkipsIncompleteGroupLSN(t *testing.T) {
+	t.Parallel()
+
+	var data []byte
+
+	for _, req := range []*Request{
+		{LSN: 1, RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+		{LSN: 2, RequestType: commands.MultiCommand},
+		{LSN: 3, RequestType: commands.SetCommand, Args: []string{"boba", "biba"}},
+	} {
+		encoded, _ := req.ParseToBytes()
+		data = append(data, encoded...)
+	}
+
+	batch := NewBatch(100)
+
+	err := batch.LoadData(data)
+
+	assert.Equal(t, errors.New("has incomplete transaction"), err)
+	assert.Equal(t, uint64(1), batch.LastLSN)
+	assert.Equal(t, []*Request{{LSN: 1, RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}}, batch.Data)
+}
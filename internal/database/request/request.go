@@ -1,82 +1,356 @@
 package request
 
 import (
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"hash/crc32"
 	"inmemorykvdb/internal/database/commands"
-	"strings"
 )
 
 const (
-	DelimElement = " "
-	EndElement   = "\n"
+	recordMagic         uint16 = 0x4B56
+	legacyRecordVersion byte   = 1
+	recordVersion       byte   = 2
 
-	minRequestLen        = 2
-	minSetRequestArgsLen = 2
+	magicSize   = 2
+	versionSize = 1
+	lengthSize  = 4
+	crcSize     = 4
+	HeaderSize  = magicSize + versionSize + lengthSize + crcSize
 
-	commandIndex = 0
-	argsIndex    = 1
+	maxRecordSize = 64 << 20
+
+	minSetRequestArgsLen      = 2
+	minExpireAtRequestArgsLen = 2
+	minHSetRequestArgsLen     = 3
+	minElementsRequestArgsLen = 2
+	minZAddRequestArgsLen     = 3
+)
+
+var (
+	ErrTruncatedRecord = errors.New("record is truncated")
+	ErrCorruptedRecord = errors.New("record is corrupted")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
 )
 
 type Request struct {
+	LSN         uint64
 	RequestType int
 	Args        []string
 }
 
 func (r *Request) ParseToBytes() ([]byte, error) {
-	var command string
-
-	switch r.RequestType {
-	case commands.SetCommand:
-		command = "SET"
-	case commands.DelCommand:
-		command = "DEL"
-	default:
+	if !isLogged(r.RequestType) {
 		return []byte(nil), errors.New("incorrect command type")
 	}
 
-	parsed := []byte(command)
+	payload := binary.AppendUvarint(nil, r.LSN)
+	payload = binary.AppendUvarint(payload, uint64(r.RequestType))
+	payload = binary.AppendUvarint(payload, uint64(len(r.Args)))
 
 	for _, arg := range r.Args {
-		parsed = append(parsed, []byte(DelimElement+arg)...)
+		payload = binary.AppendUvarint(payload, uint64(len(arg)))
+		payload = append(payload, arg...)
+	}
+
+	record := make([]byte, 0, HeaderSize+len(payload))
+
+	record = binary.BigEndian.AppendUint16(record, recordMagic)
+	record = append(record, recordVersion)
+	record = binary.BigEndian.AppendUint32(record, uint32(len(payload)))
+	record = binary.BigEndian.AppendUint32(record, crc32.Checksum(payload, crcTable))
+
+	return append(record, payload...), nil
+}
+
+func NewRequest(data []byte) (*Request, int, error) {
+	payload, version, err := readRecord(data)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := decodePayload(payload, version)
+
+	if errors.Is(err, ErrCorruptedRecord) {
+		return nil, 0, err
+	}
+
+	if err != nil {
+		return nil, HeaderSize + len(payload), err
+	}
+
+	return req, HeaderSize + len(payload), nil
+}
+
+func readRecord(data []byte) ([]byte, byte, error) {
+	if len(data) < HeaderSize {
+		return nil, 0, ErrTruncatedRecord
+	}
+
+	if binary.BigEndian.Uint16(data) != recordMagic {
+		return nil, 0, ErrCorruptedRecord
+	}
+
+	version := data[magicSize]
+
+	if version != recordVersion && version != legacyRecordVersion {
+		return nil, 0, fmt.Errorf("record has unsupported version %d", version)
+	}
+
+	length := binary.BigEndian.Uint32(data[magicSize+versionSize:])
+	checksum := binary.BigEndian.Uint32(data[magicSize+versionSize+lengthSize:])
+
+	if length > maxRecordSize {
+		return nil, 0, ErrCorruptedRecord
+	}
+
+	if len(data) < HeaderSize+int(length) {
+		return nil, 0, ErrTruncatedRecord
+	}
+
+	payload := data[HeaderSize : HeaderSize+int(length)]
+
+	if crc32.Checksum(payload, crcTable) != checksum {
+		return nil, 0, ErrCorruptedRecord
+	}
+
+	return payload, version, nil
+}
+
+func recordLSN(payload []byte, version byte) (uint64, int) {
+	if version == legacyRecordVersion {
+		return 0, 0
 	}
 
-	parsed = append(parsed, []byte(EndElement)...)
+	return binary.Uvarint(payload)
+}
+
+func AfterLSN(data []byte, lsn uint64) []byte {
+	var offset int
+
+	for offset < len(data) {
+		payload, version, err := readRecord(data[offset:])
+
+		if err != nil {
+			return nil
+		}
+
+		if recorded, n := recordLSN(payload, version); n > 0 && recorded > lsn {
+			length, _ := ValidLength(data[offset:])
+
+			return data[offset : offset+length]
+		}
+
+		offset += HeaderSize + len(payload)
+	}
 
-	return parsed, nil
+	return nil
 }
 
-func NewRequest(data string) (*Request, error) {
-	splittedData := strings.Split(data, DelimElement)
+// LastLSN returns the lsn of the last record, the data has to end on a record boundary.
+func LastLSN(data []byte) (uint64, error) {
+	var offset int
+	var last uint64
+
+	for offset < len(data) {
+		payload, version, err := readRecord(data[offset:])
+
+		if err != nil {
+			return 0, err
+		}
+
+		if recorded, n := recordLSN(payload, version); n > 0 {
+			last = recorded
+		}
 
-	if len(splittedData) < minRequestLen {
-		return nil, errors.New("incorrect data")
+		offset += HeaderSize + len(payload)
 	}
 
-	req := &Request{}
-	req.Args = splittedData[argsIndex:]
+	return last, nil
+}
+
+// ChunkLength returns the length of the whole records fitting into the limit, a transaction
+// is never cut and an incomplete one at the end is left out. The first record or transaction
+// is always taken so a chunk could not be empty.
+func ChunkLength(data []byte, limit int) int {
+	var offset int
+
+	for offset < len(data) {
+		size := groupLength(data[offset:])
 
-	lastArg := req.Args[len(req.Args)-1]
-	lastElement := lastArg[len(lastArg)-1]
+		if size == 0 || offset > 0 && offset+size > limit {
+			return offset
+		}
 
-	if string([]byte{lastElement}) == EndElement {
-		lastArg = lastArg[:len(lastArg)-1]
+		offset += size
 	}
 
-	req.Args[len(req.Args)-1] = lastArg
+	return offset
+}
+
+// groupLength returns the length of the records up to the exec of the transaction starting
+// the data or of the first record out of a transaction, 0 if the transaction is incomplete.
+func groupLength(data []byte) int {
+	var offset int
+	var inGroup bool
+
+	for offset < len(data) {
+		req, n, err := NewRequest(data[offset:])
+
+		if n == 0 {
+			return 0
+		}
 
-	switch splittedData[commandIndex] {
-	case "SET":
-		req.RequestType = commands.SetCommand
+		offset += n
+
+		switch {
+		case err != nil:
+		case req.RequestType == commands.MultiCommand:
+			inGroup = true
+		case req.RequestType == commands.ExecCommand:
+			return offset
+		}
+
+		if !inGroup {
+			return offset
+		}
+	}
+
+	return 0
+}
+
+func ValidLength(data []byte) (int, error) {
+	var offset int
+
+	for offset < len(data) {
+		_, n, err := NewRequest(data[offset:])
+
+		if n == 0 {
+			return offset, err
+		}
+
+		offset += n
+	}
+
+	return offset, nil
+}
+
+func decodePayload(payload []byte, version byte) (*Request, error) {
+	lsn, n := recordLSN(payload, version)
+
+	if n < 0 || version != legacyRecordVersion && n == 0 {
+		return nil, ErrCorruptedRecord
+	}
+
+	payload = payload[n:]
+
+	requestType, n := binary.Uvarint(payload)
+
+	if n <= 0 {
+		return nil, ErrCorruptedRecord
+	}
+
+	payload = payload[n:]
+
+	argsCount, n := binary.Uvarint(payload)
+
+	if n <= 0 || argsCount > uint64(len(payload)) {
+		return nil, ErrCorruptedRecord
+	}
+
+	payload = payload[n:]
+
+	req := &Request{LSN: lsn, RequestType: int(requestType)}
+
+	if argsCount != 0 {
+		req.Args = make([]string, 0, argsCount)
+	}
+
+	for range argsCount {
+		length, n := binary.Uvarint(payload)
+
+		if n <= 0 || length > uint64(len(payload)-n) {
+			return nil, ErrCorruptedRecord
+		}
+
+		req.Args = append(req.Args, string(payload[n:n+int(length)]))
+		payload = payload[n+int(length):]
+	}
+
+	if len(payload) != 0 {
+		return nil, ErrCorruptedRecord
+	}
+
+	err := validate(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func isLogged(requestType int) bool {
+	switch requestType {
+	case commands.SetCommand, commands.DelCommand, commands.ExpireAtCommand, commands.PersistCommand,
+		commands.MSetCommand, commands.MDelCommand, commands.HSetCommand, commands.HDelCommand,
+		commands.LPushCommand, commands.RPushCommand, commands.LPopCommand, commands.RPopCommand,
+		commands.SAddCommand, commands.SRemCommand, commands.ZAddCommand, commands.ZRemCommand,
+		commands.MultiCommand, commands.ExecCommand:
+		return true
+	}
+
+	return false
+}
+
+func validate(req *Request) error {
+	switch req.RequestType {
+	case commands.MultiCommand, commands.ExecCommand:
+		return nil
+
+	case commands.SetCommand:
 		if len(req.Args) < minSetRequestArgsLen {
-			return nil, errors.New("set command in data has less than two arguments")
+			return errors.New("set command in data has less than two arguments")
+		}
+
+	case commands.ExpireAtCommand:
+		if len(req.Args) < minExpireAtRequestArgsLen {
+			return errors.New("expireat command in data has less than two arguments")
 		}
 
-	case "DEL":
-		req.RequestType = commands.DelCommand
+	case commands.MSetCommand:
+		if len(req.Args)%2 != 0 {
+			return errors.New("mset command in data has not paired arguments")
+		}
+
+	case commands.HSetCommand:
+		if len(req.Args) < minHSetRequestArgsLen || len(req.Args)%2 == 0 {
+			return errors.New("hset command in data has not paired fields")
+		}
+
+	case commands.HDelCommand, commands.LPushCommand, commands.RPushCommand, commands.SAddCommand,
+		commands.SRemCommand, commands.ZRemCommand:
+		if len(req.Args) < minElementsRequestArgsLen {
+			return errors.New("command in data has no elements")
+		}
+
+	case commands.ZAddCommand:
+		if len(req.Args) < minZAddRequestArgsLen || len(req.Args)%2 == 0 {
+			return errors.New("zadd command in data has not paired scores")
+		}
 
 	default:
-		return nil, errors.New("incorrect command")
+		if !isLogged(req.RequestType) {
+			return errors.New("incorrect command")
+		}
 	}
 
-	return req, nil
+	if len(req.Args) == 0 {
+		return errors.New("incorrect data")
+	}
+
+	return nil
 }
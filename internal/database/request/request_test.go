@@ -1,6 +1,7 @@
 package request
 
 import (
+	"encoding/binary"
 	"errors"
 	"inmemorykvdb/internal/database/commands"
 	"testing"
@@ -8,6 +9,14 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func record(requestType int, args ...string) []byte {
+	req := &Request{RequestType: requestType, Args: args}
+
+	data, _ := req.ParseToBytes()
+
+	return data
+}
+
 func Test_ParseToBytes(t *testing.T) {
 	type testCase struct {
 		name string
@@ -22,9 +31,20 @@ func Test_ParseToBytes(t *testing.T) {
 		{
 			name: "correct request",
 
-			request: &Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+			request: &Request{LSN: 7, RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
 
-			expectedArray: []byte("SET" + DelimElement + "biba" + DelimElement + "boba" + EndElement),
+			expectedArray: []byte{
+				0x4B, 0x56, 2, 0, 0, 0, 13, 0x53, 0x4A, 0x2E, 0x82,
+				7, byte(commands.SetCommand), 2, 4, 'b', 'i', 'b', 'a', 4, 'b', 'o', 'b', 'a',
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "exec request",
+
+			request: &Request{RequestType: commands.ExecCommand},
+
+			expectedArray: []byte{0x4B, 0x56, 2, 0, 0, 0, 3, 0x6B, 0x46, 0xFA, 0x62, 0, byte(commands.ExecCommand), 0},
 			expectedErr:   nil,
 		},
 		{
@@ -32,6 +52,14 @@ func Test_ParseToBytes(t *testing.T) {
 
 			request: &Request{RequestType: -1, Args: []string{"biba", "boba"}},
 
+			expectedArray: []byte(nil),
+			expectedErr:   errors.New("incorrect command type"),
+		},
+		{
+			name: "read command type",
+
+			request: &Request{RequestType: commands.GetCommand, Args: []string{"biba"}},
+
 			expectedArray: []byte(nil),
 			expectedErr:   errors.New("incorrect command type"),
 		},
@@ -53,62 +81,388 @@ func Test_NewRequest(t *testing.T) {
 	type testCase struct {
 		name string
 
-		data string
+		data []byte
 
-		expectedReq *Request
-		expectedErr error
+		expectedReq  *Request
+		expectedSize int
+		expectedErr  error
 	}
 
+	set := record(commands.SetCommand, "biba", "boba with spaces\nand lines")
+
+	corrupted := append([]byte(nil), set...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	badMagic := append([]byte(nil), set...)
+	badMagic[0] = 'S'
+
+	unsupported := append([]byte(nil), set...)
+	unsupported[2] = 3
+
+	hugeLength := append([]byte(nil), set...)
+	binary.BigEndian.PutUint32(hugeLength[3:], maxRecordSize+1)
+
+	withLSN := &Request{LSN: 300, RequestType: commands.DelCommand, Args: []string{"biba"}}
+	withLSNData, _ := withLSN.ParseToBytes()
+
+	legacy := []byte{0x4B, 0x56, 1, 0, 0, 0, 7, 0x28, 0xEC, 0x5C, 0x46, byte(commands.DelCommand), 1, 4, 'b', 'i', 'b', 'a'}
+
 	testCases := []testCase{
 		{
 			name: "set data",
 
-			data: "SET biba boba\n",
+			data: set,
 
-			expectedReq: &Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
-			expectedErr: nil,
+			expectedReq:  &Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba with spaces\nand lines"}},
+			expectedSize: len(set),
+			expectedErr:  nil,
 		},
 		{
-			name: "del data",
+			name: "set data with empty value",
 
-			data: "DEL biba\n",
+			data: record(commands.SetCommand, "biba", ""),
 
-			expectedReq: &Request{RequestType: commands.DelCommand, Args: []string{"biba"}},
-			expectedErr: nil,
+			expectedReq:  &Request{RequestType: commands.SetCommand, Args: []string{"biba", ""}},
+			expectedSize: len(record(commands.SetCommand, "biba", "")),
+			expectedErr:  nil,
+		},
+		{
+			name: "set data followed by next record",
+
+			data: append(record(commands.DelCommand, "biba"), record(commands.DelCommand, "boba")...),
+
+			expectedReq:  &Request{RequestType: commands.DelCommand, Args: []string{"biba"}},
+			expectedSize: len(record(commands.DelCommand, "biba")),
+			expectedErr:  nil,
+		},
+		{
+			name: "data with lsn",
+
+			data: withLSNData,
+
+			expectedReq:  withLSN,
+			expectedSize: len(withLSNData),
+			expectedErr:  nil,
+		},
+		{
+			name: "legacy data without lsn",
+
+			data: legacy,
+
+			expectedReq:  &Request{RequestType: commands.DelCommand, Args: []string{"biba"}},
+			expectedSize: len(legacy),
+			expectedErr:  nil,
+		},
+		{
+			name: "multi data",
+
+			data: record(commands.MultiCommand),
+
+			expectedReq:  &Request{RequestType: commands.MultiCommand},
+			expectedSize: len(record(commands.MultiCommand)),
+			expectedErr:  nil,
+		},
+		{
+			name: "zadd data",
+
+			data: record(commands.ZAddCommand, "board", "2.5", "biba"),
+
+			expectedReq:  &Request{RequestType: commands.ZAddCommand, Args: []string{"board", "2.5", "biba"}},
+			expectedSize: len(record(commands.ZAddCommand, "board", "2.5", "biba")),
+			expectedErr:  nil,
+		},
+		{
+			name: "expireat data without deadline",
+
+			data: record(commands.ExpireAtCommand, "biba"),
+
+			expectedReq:  nil,
+			expectedSize: len(record(commands.ExpireAtCommand, "biba")),
+			expectedErr:  errors.New("expireat command in data has less than two arguments"),
+		},
+		{
+			name: "hset data without value",
+
+			data: record(commands.HSetCommand, "user", "name"),
+
+			expectedReq:  nil,
+			expectedSize: len(record(commands.HSetCommand, "user", "name")),
+			expectedErr:  errors.New("hset command in data has not paired fields"),
+		},
+		{
+			name: "sadd data without members",
+
+			data: record(commands.SAddCommand, "tags"),
+
+			expectedReq:  nil,
+			expectedSize: len(record(commands.SAddCommand, "tags")),
+			expectedErr:  errors.New("command in data has no elements"),
+		},
+		{
+			name: "zadd data without member",
+
+			data: record(commands.ZAddCommand, "board", "2.5"),
+
+			expectedReq:  nil,
+			expectedSize: len(record(commands.ZAddCommand, "board", "2.5")),
+			expectedErr:  errors.New("zadd command in data has not paired scores"),
+		},
+		{
+			name: "mset data with odd arguments",
+
+			data: record(commands.MSetCommand, "biba", "1", "boba"),
+
+			expectedReq:  nil,
+			expectedSize: len(record(commands.MSetCommand, "biba", "1", "boba")),
+			expectedErr:  errors.New("mset command in data has not paired arguments"),
+		},
+		{
+			name: "del data without key",
+
+			data: record(commands.DelCommand),
+
+			expectedReq:  nil,
+			expectedSize: len(record(commands.DelCommand)),
+			expectedErr:  errors.New("incorrect data"),
 		},
 		{
-			name: "incorrect data",
+			name: "truncated header",
 
-			data: "DEL\n",
+			data: set[:HeaderSize-1],
 
-			expectedReq: nil,
-			expectedErr: errors.New("incorrect data"),
+			expectedReq:  nil,
+			expectedSize: 0,
+			expectedErr:  ErrTruncatedRecord,
 		},
 		{
-			name: "set command with less than two arguments",
+			name: "truncated payload",
 
-			data: "SET BUBA\n",
+			data: set[:len(set)-1],
 
-			expectedReq: nil,
-			expectedErr: errors.New("set command in data has less than two arguments"),
+			expectedReq:  nil,
+			expectedSize: 0,
+			expectedErr:  ErrTruncatedRecord,
 		},
 		{
-			name: "incorrect command",
+			name: "corrupted payload",
 
-			data: "LOL biba boba\n",
+			data: corrupted,
 
-			expectedReq: nil,
-			expectedErr: errors.New("incorrect command"),
+			expectedReq:  nil,
+			expectedSize: 0,
+			expectedErr:  ErrCorruptedRecord,
+		},
+		{
+			name: "incorrect magic",
+
+			data: badMagic,
+
+			expectedReq:  nil,
+			expectedSize: 0,
+			expectedErr:  ErrCorruptedRecord,
+		},
+		{
+			name: "unsupported version",
+
+			data: unsupported,
+
+			expectedReq:  nil,
+			expectedSize: 0,
+			expectedErr:  errors.New("record has unsupported version 3"),
+		},
+		{
+			name: "huge length",
+
+			data: hugeLength,
+
+			expectedReq:  nil,
+			expectedSize: 0,
+			expectedErr:  ErrCorruptedRecord,
 		},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			req, err := NewRequest(test.data)
+
+			req, size, err := NewRequest(test.data)
 
 			assert.Equal(t, test.expectedReq, req)
+			assert.Equal(t, test.expectedSize, size)
 			assert.Equal(t, test.expectedErr, err)
 		})
 	}
 }
+
+func Test_ValidLength(t *testing.T) {
+	t.Parallel()
+
+	first := record(commands.SetCommand, "biba", "boba")
+	second := record(commands.DelCommand, "biba")
+
+	data := append(append([]byte(nil), first...), second...)
+
+	length, err := ValidLength(data)
+
+	assert.Equal(t, len(data), length)
+	assert.NoError(t, err)
+
+	length, err = ValidLength(data[:len(data)-3])
+
+	assert.Equal(t, len(first), length)
+	assert.Equal(t, ErrTruncatedRecord, err)
+}
+
+func Test_AfterLSN(t *testing.T) {
+	t.Parallel()
+
+	var data []byte
+
+	for lsn := uint64(1); lsn <= 3; lsn++ {
+		req := &Request{LSN: lsn, RequestType: commands.DelCommand, Args: []string{"biba"}}
+		encoded, _ := req.ParseToBytes()
+
+		data = append(data, encoded...)
+	}
+
+	size := len(data) / 3
+
+	assert.Equal(t, data, AfterLSN(data, 0))
+	assert.Equal(t, data[size:], AfterLSN(data, 1))
+	assert.Equal(t, data[2*size:], AfterLSN(data, 2))
+	assert.Nil(t, AfterLSN(data, 3))
+	assert.Nil(t, AfterLSN(data[:len(data)-1], 2))
+	assert.Equal(t, data[size:2*size], AfterLSN(data[:len(data)-1], 1))
+}
+
+func Test_LastLSN(t *testing.T) {
+	t.Parallel()
+
+	var data []byte
+
+	for lsn := uint64(1); lsn <= 3; lsn++ {
+		req := &Request{LSN: lsn, RequestType: commands.DelCommand, Args: []string{"biba"}}
+		encoded, _ := req.ParseToBytes()
+
+		data = append(data, encoded...)
+	}
+
+	size := len(data) / 3
+
+	lsn, err := LastLSN(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), lsn)
+
+	lsn, _ = LastLSN(data[:size])
+
+	assert.Equal(t, uint64(1), lsn)
+
+	lsn, _ = LastLSN(nil)
+
+	assert.Zero(t, lsn)
+
+	_, err = LastLSN(data[:size+1])
+
+	assert.ErrorIs(t, err, ErrTruncatedRecord)
+}
+
+func Test_ChunkLength(t *testing.T) {
+	t.Parallel()
+
+	var data []byte
+
+	for lsn := uint64(1); lsn <= 3; lsn++ {
+		req := &Request{LSN: lsn, RequestType: commands.DelCommand, Args: []string{"biba"}}
+		encoded, _ := req.ParseToBytes()
+
+		data = append(data, encoded...)
+	}
+
+	size := len(data) / 3
+
+	tests := []struct {
+		name     string
+		data     []byte
+		limit    int
+		expected int
+	}{
+		{name: "all records fit", data: data, limit: len(data), expected: len(data)},
+		{name: "cut at record boundary", data: data, limit: 2*size + 1, expected: 2 * size},
+		{name: "first record is always taken", data: data, limit: 1, expected: size},
+		{name: "truncated tail", data: data[:len(data)-1], limit: len(data), expected: 2 * size},
+		{name: "empty data", data: nil, limit: 1, expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, ChunkLength(test.data, test.limit))
+		})
+	}
+}
+
+func Test_ChunkLengthKeepsTransactions(t *testing.T) {
+	t.Parallel()
+
+	set := record(commands.SetCommand, "biba", "boba")
+	multi := record(commands.MultiCommand)
+	del := record(commands.DelCommand, "biba")
+	exec := record(commands.ExecCommand)
+
+	group := append(append(append(append([]byte(nil), multi...), set...), del...), exec...)
+	data := append(append([]byte(nil), set...), group...)
+
+	type testCase struct {
+		name string
+
+		data  []byte
+		limit int
+
+		expectedLength int
+	}
+
+	testCases := []testCase{
+		{
+			name: "limit inside transaction",
+
+			data:  data,
+			limit: len(set) + len(multi) + len(set),
+
+			expectedLength: len(set),
+		},
+		{
+			name: "transaction over limit first",
+
+			data:  group,
+			limit: 1,
+
+			expectedLength: len(group),
+		},
+		{
+			name: "incomplete transaction",
+
+			data:  data[:len(data)-len(exec)],
+			limit: len(data),
+
+			expectedLength: len(set),
+		},
+		{
+			name: "whole data",
+
+			data:  data,
+			limit: len(data),
+
+			expectedLength: len(data),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expectedLength, ChunkLength(test.data, test.limit))
+		})
+	}
+}
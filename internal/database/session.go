@@ -0,0 +1,130 @@
+package database
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"strings"
+)
+
+const (
+	okAnswer         = "SUCCESS"
+	queuedAnswer     = "QUEUED"
+	notAppliedAnswer = "NOT APPLIED"
+)
+
+type Session struct {
+	db *InMemoryKeyValueDatabase
+
+	queue         []request.Request
+	watched       map[string]uint64
+	inTransaction bool
+	aborted       bool
+}
+
+func (db *InMemoryKeyValueDatabase) NewSession() *Session {
+	return &Session{db: db}
+}
+
+func (s *Session) HandleRequest(data string) (string, error) {
+
+	req, err := s.db.compute.Parse(data)
+
+	if err != nil {
+		s.db.logger.Error("data parsed with error")
+
+		if s.inTransaction {
+			s.aborted = true
+		}
+
+		return "", err
+	}
+
+	switch req.RequestType {
+
+	case commands.MultiCommand:
+		if s.inTransaction {
+			return "", errors.New("multi calls can not be nested")
+		}
+
+		s.db.logger.Debug("transaction started")
+		s.inTransaction = true
+
+		return okAnswer, nil
+
+	case commands.DiscardCommand:
+		if !s.inTransaction {
+			return "", errors.New("discard without multi")
+		}
+
+		s.db.logger.Debug("transaction discarded")
+		s.reset()
+
+		return okAnswer, nil
+
+	case commands.WatchCommand:
+		if s.inTransaction {
+			return "", errors.New("watch inside multi is not allowed")
+		}
+
+		s.watch(req.Args)
+
+		return okAnswer, nil
+
+	case commands.ExecCommand:
+		if !s.inTransaction {
+			return "", errors.New("exec without multi")
+		}
+
+		return s.exec()
+	}
+
+	if s.inTransaction {
+		s.queue = append(s.queue, req)
+		return queuedAnswer, nil
+	}
+
+	return s.db.handleParsed(req)
+}
+
+func (s *Session) exec() (string, error) {
+	queue, watched, aborted := s.queue, s.watched, s.aborted
+	s.reset()
+
+	if aborted {
+		return "", errors.New("transaction discarded because of previous errors")
+	}
+
+	s.db.logger.Debug("send transaction to storage")
+	answers, applied, err := s.db.storage.HandleTransaction(queue, watched)
+
+	if err != nil {
+		s.db.logger.Error("storage responsed with error")
+		return "", err
+	}
+
+	if !applied {
+		return notAppliedAnswer, nil
+	}
+
+	return strings.Join(answers, "\n"), nil
+}
+
+func (s *Session) watch(keys []string) {
+	if s.watched == nil {
+		s.watched = make(map[string]uint64, len(keys))
+	}
+
+	for key, version := range s.db.storage.Watch(keys) {
+		if _, found := s.watched[key]; !found {
+			s.watched[key] = version
+		}
+	}
+}
+
+func (s *Session) reset() {
+	s.queue = nil
+	s.watched = nil
+	s.inTransaction = false
+	s.aborted = false
+}
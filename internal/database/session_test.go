@@ -0,0 +1,102 @@
+package database
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/compute"
+	"inmemorykvdb/internal/database/storage"
+	"inmemorykvdb/internal/database/storage/engine"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_SessionTransaction(t *testing.T) {
+
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	stor, _ := storage.NewStorage(zap.NewNop(), eng)
+	comp, _ := compute.NewCompute(zap.NewNop())
+
+	db, _ := NewInMemoryKvDb(comp, stor, zap.NewNop())
+
+	session := db.NewSession()
+	other := db.NewSession()
+
+	type step struct {
+		session *Session
+		data    string
+
+		expectedResp string
+		expectedErr  error
+	}
+
+	steps := []step{
+		{session: session, data: "EXEC", expectedErr: errors.New("exec without multi")},
+		{session: session, data: "MULTI", expectedResp: okAnswer},
+		{session: session, data: "MULTI", expectedErr: errors.New("multi calls can not be nested")},
+		{session: session, data: "SET biba 1", expectedResp: queuedAnswer},
+		{session: session, data: "INCR biba", expectedResp: queuedAnswer},
+		{session: other, data: "GET biba", expectedResp: "NOT FOUND"},
+		{session: session, data: "EXEC", expectedResp: "SUCCESS\n2"},
+		{session: other, data: "GET biba", expectedResp: "2"},
+		{session: session, data: "MULTI", expectedResp: okAnswer},
+		{session: session, data: "SET biba 3", expectedResp: queuedAnswer},
+		{session: session, data: "DISCARD", expectedResp: okAnswer},
+		{session: session, data: "GET biba", expectedResp: "2"},
+		{session: session, data: "MULTI", expectedResp: okAnswer},
+		{session: session, data: "SET biba", expectedErr: errors.New("set command has two arguments")},
+		{session: session, data: "EXEC", expectedErr: errors.New("transaction discarded because of previous errors")},
+	}
+
+	for _, step := range steps {
+		resp, err := step.session.HandleRequest(step.data)
+
+		assert.Equal(t, step.expectedResp, resp, step.data)
+		assert.Equal(t, step.expectedErr, err, step.data)
+	}
+}
+
+func Test_SessionWatch(t *testing.T) {
+
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	stor, _ := storage.NewStorage(zap.NewNop(), eng)
+	comp, _ := compute.NewCompute(zap.NewNop())
+
+	db, _ := NewInMemoryKvDb(comp, stor, zap.NewNop())
+
+	session := db.NewSession()
+	other := db.NewSession()
+
+	type step struct {
+		session *Session
+		data    string
+
+		expectedResp string
+		expectedErr  error
+	}
+
+	steps := []step{
+		{session: session, data: "WATCH biba", expectedResp: okAnswer},
+		{session: session, data: "MULTI", expectedResp: okAnswer},
+		{session: session, data: "WATCH boba", expectedErr: errors.New("watch inside multi is not allowed")},
+		{session: session, data: "SET biba 1", expectedResp: queuedAnswer},
+		{session: other, data: "SET biba 2", expectedResp: "SUCCESS"},
+		{session: session, data: "EXEC", expectedResp: notAppliedAnswer},
+		{session: session, data: "GET biba", expectedResp: "2"},
+		{session: session, data: "WATCH biba", expectedResp: okAnswer},
+		{session: session, data: "MULTI", expectedResp: okAnswer},
+		{session: session, data: "SET biba 1", expectedResp: queuedAnswer},
+		{session: session, data: "EXEC", expectedResp: "SUCCESS"},
+	}
+
+	for _, step := range steps {
+		resp, err := step.session.HandleRequest(step.data)
+
+		assert.Equal(t, step.expectedResp, resp, step.data)
+		assert.Equal(t, step.expectedErr, err, step.data)
+	}
+}
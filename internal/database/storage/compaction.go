@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+type compactionLayer interface {
+	Compact(upTo uint64) error
+}
+
+func (s *Storage) Compact() error {
+	if s.compactor == nil {
+		return errors.New("wal compaction is not configured")
+	}
+
+	segmented, ok := s.wal.(segmentedWal)
+
+	if !ok || !s.isLogging() {
+		return errors.New("wal compaction is not supported by this node")
+	}
+
+	if !s.maintaining.CompareAndSwap(false, true) {
+		return errBusy
+	}
+
+	defer s.maintaining.Store(false)
+
+	s.logger.Debug("started wal compaction")
+
+	return s.compactor.Compact(segmented.Checkpoint())
+}
+
+func (s *Storage) scheduleCompactions() {
+	ticker := time.NewTicker(s.compactionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		err := s.Compact()
+
+		if err != nil && !errors.Is(err, errBusy) {
+			s.logger.Error(err.Error())
+		}
+	}
+}
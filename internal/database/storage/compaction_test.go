@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"inmemorykvdb/internal/database/storage/wal/compaction"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_CompactAndRecover(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	stor, _ := newPersistentStorage(t, dir)
+
+	compactor, _ := compaction.NewCompactor(zap.NewNop(), "wal", compaction.WithDirectory(dir))
+	stor.compactor = compactor
+
+	for _, value := range []string{"1", "2", "3"} {
+		stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", value}})
+		stor.wal.(segmentedWal).Checkpoint()
+	}
+
+	stor.HandleRequest(request.Request{RequestType: commands.RPushCommand, Args: []string{"queue", "a", "b"}})
+	stor.HandleRequest(request.Request{RequestType: commands.DelCommand, Args: []string{"biba"}})
+	stor.HandleRequest(request.Request{RequestType: commands.RPushCommand, Args: []string{"queue", "c"}})
+
+	err := stor.Compact()
+
+	assert.NoError(t, err)
+
+	assert.Len(t, writtenSegments(dir), 1)
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"boba", "biba"}})
+	stor.wal.(segmentedWal).Checkpoint()
+
+	_, eng := newPersistentStorage(t, dir)
+
+	_, found := eng.GET("biba")
+	assert.False(t, found)
+
+	value, _ := eng.GET("boba")
+	assert.Equal(t, "biba", value)
+
+	elements, _ := eng.LRANGE("queue", 0, -1)
+	assert.Equal(t, []string{"a", "b", "c"}, elements)
+}
+
+func Test_CompactErrors(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	assert.Equal(t, errors.New("wal compaction is not configured"), stor.Compact())
+
+	stor.compactor = &compaction.Compactor{}
+
+	assert.Equal(t, errors.New("wal compaction is not supported by this node"), stor.Compact())
+}
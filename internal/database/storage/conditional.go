@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"inmemorykvdb/internal/database/commands"
+	"strconv"
+)
+
+func parseCondition(args []string) (func(current string, found bool, version uint64) bool, bool) {
+	modifiers := args[modifierIndex:]
+
+	if hasExpiration(args) {
+		modifiers = args[durationIndex+1:]
+	}
+
+	if len(modifiers) == 0 {
+		return nil, false
+	}
+
+	switch modifiers[0] {
+	case commands.NotExistsModifier:
+		return func(_ string, found bool, _ uint64) bool {
+			return !found
+		}, true
+
+	case commands.ExistsModifier:
+		return func(_ string, found bool, _ uint64) bool {
+			return found
+		}, true
+
+	case commands.IfEqualModifier:
+		if len(modifiers) == 1 {
+			return nil, false
+		}
+
+		expected := modifiers[1]
+
+		return func(current string, found bool, _ uint64) bool {
+			return found && current == expected
+		}, true
+
+	case commands.IfVersionModifier:
+		if len(modifiers) == 1 {
+			return nil, false
+		}
+
+		expected, err := strconv.ParseUint(modifiers[1], 10, 64)
+
+		if err != nil {
+			return nil, false
+		}
+
+		return func(_ string, found bool, version uint64) bool {
+			return found && version == expected
+		}, true
+	}
+
+	return nil, false
+}
+
+func appliedAnswer(applied bool, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	if !applied {
+		return notApplied, nil
+	}
+
+	return okAnswer, nil
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_HandleConditionalRequests(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	testWal := &recordingWal{}
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(testWal))
+
+	type testCase struct {
+		name string
+
+		request request.Request
+
+		expectedAnswer string
+	}
+
+	testCases := []testCase{
+		{
+			name: "set if exists on missing key",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"lock", "1", commands.ExistsModifier}},
+
+			expectedAnswer: notApplied,
+		},
+		{
+			name: "set if not exists",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"lock", "1", commands.NotExistsModifier}},
+
+			expectedAnswer: okAnswer,
+		},
+		{
+			name: "set if not exists on existing key",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"lock", "2", commands.ExpireSeconds, "10", commands.NotExistsModifier}},
+
+			expectedAnswer: notApplied,
+		},
+		{
+			name: "set if equal",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"lock", "2", commands.IfEqualModifier, "1"}},
+
+			expectedAnswer: okAnswer,
+		},
+		{
+			name: "failed cas",
+
+			request: request.Request{RequestType: commands.CasCommand, Args: []string{"lock", "1", "3"}},
+
+			expectedAnswer: notApplied,
+		},
+		{
+			name: "cas",
+
+			request: request.Request{RequestType: commands.CasCommand, Args: []string{"lock", "2", "3"}},
+
+			expectedAnswer: okAnswer,
+		},
+	}
+
+	for _, test := range testCases {
+		answer, err := stor.HandleRequest(test.request)
+
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expectedAnswer, answer, test.name)
+	}
+
+	expected := []request.Request{
+		{RequestType: commands.SetCommand, Args: []string{"lock", "1"}},
+		{RequestType: commands.SetCommand, Args: []string{"lock", "2"}},
+		{RequestType: commands.SetCommand, Args: []string{"lock", "3"}},
+	}
+
+	assert.Equal(t, expected, testWal.requests)
+}
+
+func Test_parseCondition(t *testing.T) {
+	t.Parallel()
+
+	_, conditional := parseCondition([]string{"biba", "boba"})
+	assert.False(t, conditional)
+
+	_, conditional = parseCondition([]string{"biba", "boba", commands.ExpireAtMilliseconds, "1700000000000"})
+	assert.False(t, conditional)
+
+	condition, conditional := parseCondition([]string{"biba", "boba", commands.ExpireAtMilliseconds, "1700000000000", commands.IfEqualModifier, "aboba"})
+	assert.True(t, conditional)
+	assert.True(t, condition("aboba", true, 0))
+	assert.False(t, condition("boba", true, 0))
+}
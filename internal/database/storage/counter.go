@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"strconv"
+	"time"
+)
+
+func isLoggedOnCommit(req request.Request) bool {
+	switch req.RequestType {
+	case commands.IncrCommand, commands.DecrCommand, commands.IncrByCommand, commands.IncrByFloatCommand, commands.CasCommand,
+		commands.ZIncrByCommand, commands.SetCommand, commands.MSetCommand, commands.HSetCommand, commands.LPushCommand,
+		commands.RPushCommand, commands.SAddCommand, commands.ZAddCommand:
+		return true
+	}
+
+	return false
+}
+
+func parseDelta(req request.Request) (int64, error) {
+	switch req.RequestType {
+	case commands.IncrCommand:
+		return 1, nil
+	case commands.DecrCommand:
+		return -1, nil
+	}
+
+	delta, err := strconv.ParseInt(req.Args[1], 10, 64)
+
+	if err != nil {
+		return 0, errors.New("incorrect increment")
+	}
+
+	return delta, nil
+}
+
+func commitSet(key string, write func(request.Request) func() error) func(value string, deadline time.Time) func() error {
+	if write == nil {
+		return nil
+	}
+
+	return func(value string, deadline time.Time) func() error {
+		args := []string{key, value}
+
+		if !deadline.IsZero() {
+			args = append(args, commands.ExpireAtMilliseconds, formatUnixMilli(deadline))
+		}
+
+		return write(request.Request{RequestType: commands.SetCommand, Args: args})
+	}
+}
+
+// commitRequest logs the request once the engine has reserved the memory for it.
+func commitRequest(req request.Request, write func(request.Request) func() error) func() func() error {
+	if write == nil {
+		return nil
+	}
+
+	return func() func() error {
+		return write(req)
+	}
+}
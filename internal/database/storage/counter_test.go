@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type recordingWal struct {
+	mutex    sync.Mutex
+	requests []request.Request
+	groups   [][]request.Request
+	lsn      uint64
+}
+
+func (w *recordingWal) Write(req request.Request) func() (uint64, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.requests = append(w.requests, req)
+	w.lsn++
+
+	lsn := w.lsn
+
+	return func() (uint64, error) { return lsn, nil }
+}
+
+func (w *recordingWal) WriteGroup(reqs []request.Request) func() (uint64, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.groups = append(w.groups, reqs)
+	w.lsn += uint64(len(reqs)) + 2
+
+	lsn := w.lsn
+
+	return func() (uint64, error) { return lsn, nil }
+}
+
+func (w *recordingWal) Read() *request.Batch {
+	return nil
+}
+
+func Test_HandleCounterRequests(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	testWal := &recordingWal{}
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(testWal))
+
+	answer, err := stor.HandleRequest(request.Request{RequestType: commands.IncrCommand, Args: []string{"biba"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", answer)
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.IncrByCommand, Args: []string{"biba", "10"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "11", answer)
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.DecrCommand, Args: []string{"biba"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "10", answer)
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.IncrByFloatCommand, Args: []string{"biba", "0.5"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "10.5", answer)
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.IncrCommand, Args: []string{"biba"}})
+
+	assert.Equal(t, "", answer)
+	assert.Equal(t, errors.New("value is not an integer or out of range"), err)
+
+	expected := []request.Request{
+		{RequestType: commands.SetCommand, Args: []string{"biba", "1"}},
+		{RequestType: commands.SetCommand, Args: []string{"biba", "11"}},
+		{RequestType: commands.SetCommand, Args: []string{"biba", "10"}},
+		{RequestType: commands.SetCommand, Args: []string{"biba", "10.5"}},
+	}
+
+	assert.Equal(t, expected, testWal.requests)
+}
+
+func Test_HandleCounterRequestWithDeadline(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	testWal := &recordingWal{}
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(testWal))
+
+	deadline := "4102444800000"
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "5", commands.ExpireAtMilliseconds, deadline}})
+	stor.HandleRequest(request.Request{RequestType: commands.IncrCommand, Args: []string{"biba"}})
+
+	assert.Equal(t, request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "6", commands.ExpireAtMilliseconds, deadline}}, testWal.requests[1])
+
+	ttl, found := eng.TTL("biba")
+
+	assert.True(t, found)
+	assert.Positive(t, ttl)
+}
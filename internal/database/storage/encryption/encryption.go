@@ -0,0 +1,272 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	frameMagic   uint16 = 0x4B45
+	frameVersion byte   = 1
+
+	magicSize   = 2
+	versionSize = 1
+	keyIDSize   = 4
+	nonceSize   = 12
+	lengthSize  = 4
+	crcSize     = 4
+	HeaderSize  = magicSize + versionSize + keyIDSize + nonceSize + lengthSize + crcSize
+
+	maxFrameSize = 64 << 20
+)
+
+var (
+	ErrTruncatedFrame = errors.New("encrypted frame is truncated")
+	ErrCorruptedFrame = errors.New("encrypted frame is corrupted")
+
+	ErrMissingKey = errors.New("data is encrypted but the key file is not configured")
+	ErrUnknownKey = errors.New("data is encrypted with a key which is not in the key file")
+	ErrWrongKey   = errors.New("data could not be decrypted, the key is wrong")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// Keyring holds the keys of the key file, new data is encrypted with the key
+// of the highest id and the older keys are kept to read the data they encrypted.
+// A nil keyring means the encryption is disabled.
+type Keyring struct {
+	keys   map[uint32]cipher.AEAD
+	active uint32
+}
+
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read key file %s", path)
+	}
+
+	return NewKeyring(data)
+}
+
+// NewKeyring parses the lines "<id>:<hex key>" of a key file, the keys are 16, 24 or 32 bytes long.
+func NewKeyring(data []byte) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[uint32]cipher.AEAD)}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		unparsedID, unparsedKey, found := strings.Cut(text, ":")
+
+		id, err := strconv.ParseUint(strings.TrimSpace(unparsedID), 10, 32)
+
+		if !found || err != nil {
+			return nil, fmt.Errorf("key file has incorrect line %d", line)
+		}
+
+		key, err := hex.DecodeString(strings.TrimSpace(unparsedKey))
+
+		if err != nil {
+			return nil, fmt.Errorf("key file has incorrect line %d", line)
+		}
+
+		if _, found := keyring.keys[uint32(id)]; found {
+			return nil, fmt.Errorf("key %d is duplicated", id)
+		}
+
+		aead, err := newAEAD(key)
+
+		if err != nil {
+			return nil, fmt.Errorf("key %d has incorrect size", id)
+		}
+
+		keyring.keys[uint32(id)] = aead
+		keyring.active = max(keyring.active, uint32(id))
+	}
+
+	if len(keyring.keys) == 0 {
+		return nil, errors.New("key file is empty")
+	}
+
+	return keyring, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// ActiveKey returns the id of the key which encrypts new data.
+func (k *Keyring) ActiveKey() uint32 {
+	if k == nil {
+		return 0
+	}
+
+	return k.active
+}
+
+// IsEncrypted reports whether the data starts with an encrypted frame.
+func IsEncrypted(data []byte) bool {
+	return len(data) >= magicSize && binary.BigEndian.Uint16(data) == frameMagic
+}
+
+// IsKeyError reports whether the data could not be read because of the key file,
+// such data is never truncated as a torn tail.
+func IsKeyError(err error) bool {
+	return errors.Is(err, ErrMissingKey) || errors.Is(err, ErrUnknownKey) || errors.Is(err, ErrWrongKey)
+}
+
+// Encrypt wraps the data into frames with random nonces, the header of a frame is authenticated
+// together with its data. Data over the frame size is split, so any data could be read back.
+func (k *Keyring) Encrypt(plain []byte) ([]byte, error) {
+	if k == nil {
+		return plain, nil
+	}
+
+	aead := k.keys[k.active]
+	limit := maxFrameSize - aead.Overhead()
+
+	var data []byte
+
+	for {
+		chunk := plain[:min(len(plain), limit)]
+
+		frame, err := k.seal(aead, chunk)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, frame...)
+		plain = plain[len(chunk):]
+
+		if len(plain) == 0 {
+			return data, nil
+		}
+	}
+}
+
+func (k *Keyring) seal(aead cipher.AEAD, plain []byte) ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+
+	_, err := rand.Read(nonce)
+
+	if err != nil {
+		return nil, errors.New("could not generate nonce")
+	}
+
+	header := make([]byte, 0, HeaderSize-crcSize)
+
+	header = binary.BigEndian.AppendUint16(header, frameMagic)
+	header = append(header, frameVersion)
+	header = binary.BigEndian.AppendUint32(header, k.active)
+	header = append(header, nonce...)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(plain)+aead.Overhead()))
+
+	sealed := aead.Seal(nil, nonce, plain, header)
+
+	frame := make([]byte, 0, HeaderSize+len(sealed))
+
+	frame = append(frame, header...)
+	frame = binary.BigEndian.AppendUint32(frame, crc32.Update(crc32.Checksum(header, crcTable), crcTable, sealed))
+
+	return append(frame, sealed...), nil
+}
+
+// Decrypt returns the plaintext of the valid frames and the length of the data they take.
+// Data written before the encryption was enabled passes through as is.
+func (k *Keyring) Decrypt(data []byte) ([]byte, int, error) {
+	if !IsEncrypted(data) {
+		return data, len(data), nil
+	}
+
+	if k == nil {
+		return nil, 0, ErrMissingKey
+	}
+
+	var plain []byte
+	var offset int
+
+	for offset < len(data) {
+		opened, n, err := k.open(data[offset:])
+
+		if err != nil {
+			return plain, offset, err
+		}
+
+		plain = append(plain, opened...)
+		offset += n
+	}
+
+	return plain, offset, nil
+}
+
+func (k *Keyring) open(data []byte) ([]byte, int, error) {
+	if len(data) < HeaderSize {
+		if IsEncrypted(data) || len(data) < magicSize {
+			return nil, 0, ErrTruncatedFrame
+		}
+
+		return nil, 0, ErrCorruptedFrame
+	}
+
+	if !IsEncrypted(data) || data[magicSize] != frameVersion {
+		return nil, 0, ErrCorruptedFrame
+	}
+
+	keyID := binary.BigEndian.Uint32(data[magicSize+versionSize:])
+	nonce := data[magicSize+versionSize+keyIDSize : magicSize+versionSize+keyIDSize+nonceSize]
+	length := binary.BigEndian.Uint32(data[HeaderSize-crcSize-lengthSize:])
+	checksum := binary.BigEndian.Uint32(data[HeaderSize-crcSize:])
+
+	if length > maxFrameSize {
+		return nil, 0, ErrCorruptedFrame
+	}
+
+	if uint64(len(data)) < uint64(HeaderSize)+uint64(length) {
+		return nil, 0, ErrTruncatedFrame
+	}
+
+	header := data[:HeaderSize-crcSize]
+	sealed := data[HeaderSize : HeaderSize+int(length)]
+
+	if crc32.Update(crc32.Checksum(header, crcTable), crcTable, sealed) != checksum {
+		return nil, 0, ErrCorruptedFrame
+	}
+
+	aead, found := k.keys[keyID]
+
+	if !found {
+		return nil, 0, fmt.Errorf("%w: key %d", ErrUnknownKey, keyID)
+	}
+
+	plain, err := aead.Open(nil, nonce, sealed, header)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: key %d", ErrWrongKey, keyID)
+	}
+
+	return plain, HeaderSize + int(length), nil
+}
@@ -0,0 +1,271 @@
+package encryption
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	firstKey  = "1:000102030405060708090a0b0c0d0e0f000102030405060708090a0b0c0d0e0f"
+	secondKey = "2:0f0e0d0c0b0a09080706050403020100"
+	otherKey  = "1:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+)
+
+func keyring(t *testing.T, lines ...string) *Keyring {
+	t.Helper()
+
+	k, err := NewKeyring([]byte(strings.Join(lines, "\n")))
+
+	require.NoError(t, err)
+
+	return k
+}
+
+func Test_NewKeyring(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		data string
+
+		expectedActive uint32
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "keys with rotation",
+
+			data: "# wal keys\n" + firstKey + "\n\n" + secondKey + "\n",
+
+			expectedActive: 2,
+			expectedErr:    nil,
+		},
+		{
+			name: "empty key file",
+
+			data: "# no keys\n",
+
+			expectedErr: errors.New("key file is empty"),
+		},
+		{
+			name: "line without id",
+
+			data: "000102030405060708090a0b0c0d0e0f",
+
+			expectedErr: errors.New("key file has incorrect line 1"),
+		},
+		{
+			name: "key is not hex",
+
+			data: firstKey + "\n2:biba",
+
+			expectedErr: errors.New("key file has incorrect line 2"),
+		},
+		{
+			name: "key with incorrect size",
+
+			data: "3:0001",
+
+			expectedErr: errors.New("key 3 has incorrect size"),
+		},
+		{
+			name: "duplicated key",
+
+			data: firstKey + "\n" + otherKey,
+
+			expectedErr: errors.New("key 1 is duplicated"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			k, err := NewKeyring([]byte(test.data))
+
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedActive, k.ActiveKey())
+		})
+	}
+}
+
+func Test_LoadKeyring(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir() + "/wal.key"
+
+	_, err := LoadKeyring(path)
+
+	assert.Equal(t, errors.New("could not read key file "+path), err)
+
+	os.WriteFile(path, []byte(firstKey), 0600)
+
+	k, err := LoadKeyring(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), k.ActiveKey())
+}
+
+func Test_EncryptAndDecrypt(t *testing.T) {
+	t.Parallel()
+
+	k := keyring(t, firstKey)
+
+	first, err := k.Encrypt([]byte("biba"))
+
+	require.NoError(t, err)
+
+	second, _ := k.Encrypt([]byte("boba"))
+
+	assert.True(t, IsEncrypted(first))
+	assert.NotContains(t, string(first), "biba")
+	assert.NotEqual(t, first[:HeaderSize], second[:HeaderSize])
+
+	data := append(first, second...)
+
+	plain, length, err := k.Decrypt(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("bibaboba"), plain)
+	assert.Equal(t, len(data), length)
+}
+
+func Test_EncryptOverFrameSize(t *testing.T) {
+	t.Parallel()
+
+	k := keyring(t, firstKey)
+
+	large := make([]byte, maxFrameSize+1)
+	large[len(large)-1] = 'b'
+
+	data, err := k.Encrypt(large)
+
+	require.NoError(t, err)
+
+	plain, length, err := k.Decrypt(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, len(data), length)
+	assert.Equal(t, large, plain)
+}
+
+func Test_DecryptAfterRotation(t *testing.T) {
+	t.Parallel()
+
+	old, _ := keyring(t, firstKey).Encrypt([]byte("biba"))
+
+	rotated := keyring(t, firstKey, secondKey)
+
+	fresh, _ := rotated.Encrypt([]byte("boba"))
+
+	plain, _, err := rotated.Decrypt(append(old, fresh...))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("bibaboba"), plain)
+
+	_, _, err = keyring(t, firstKey).Decrypt(fresh)
+
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.True(t, IsKeyError(err))
+}
+
+func Test_DecryptErrors(t *testing.T) {
+	t.Parallel()
+
+	k := keyring(t, firstKey)
+
+	first, _ := k.Encrypt([]byte("biba"))
+	second, _ := k.Encrypt([]byte("boba"))
+
+	corrupted := append([]byte(nil), second...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	type testCase struct {
+		name string
+
+		keyring *Keyring
+		data    []byte
+
+		expectedPlain  []byte
+		expectedLength int
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "plaintext passes through",
+
+			keyring: k,
+			data:    []byte("biba"),
+
+			expectedPlain:  []byte("biba"),
+			expectedLength: 4,
+		},
+		{
+			name: "torn tail",
+
+			keyring: k,
+			data:    append(append([]byte(nil), first...), second[:len(second)-3]...),
+
+			expectedPlain:  []byte("biba"),
+			expectedLength: len(first),
+			expectedErr:    ErrTruncatedFrame,
+		},
+		{
+			name: "corrupted frame",
+
+			keyring: k,
+			data:    append(append([]byte(nil), first...), corrupted...),
+
+			expectedPlain:  []byte("biba"),
+			expectedLength: len(first),
+			expectedErr:    ErrCorruptedFrame,
+		},
+		{
+			name: "without key file",
+
+			keyring: nil,
+			data:    first,
+
+			expectedErr: ErrMissingKey,
+		},
+		{
+			name: "wrong key",
+
+			keyring: keyring(t, otherKey),
+			data:    first,
+
+			expectedErr: ErrWrongKey,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			plain, length, err := test.keyring.Decrypt(test.data)
+
+			assert.Equal(t, test.expectedPlain, plain)
+			assert.Equal(t, test.expectedLength, length)
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
+
+func Test_EncryptWithoutKeyring(t *testing.T) {
+	t.Parallel()
+
+	var k *Keyring
+
+	data, err := k.Encrypt([]byte("biba"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("biba"), data)
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+func (e *InMemoryEngine) SETIF(key string, value string, deadline time.Time, condition func(current string, found bool, version uint64) bool,
+	commit func(value string, deadline time.Time) func() error) (bool, error) {
+	e.Logger.Debug(fmt.Sprintf("started conditional set query for key: %s; value: %s", key, value))
+
+	applied, err := e.partitions[e.makeTxId(key)].setIf(key, value, deadline, false, condition, commit)
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("conditional set query for key %s failed: %s", key, err.Error()))
+		return false, err
+	}
+
+	e.Logger.Debug("conditional set query is done")
+
+	return applied, nil
+}
+
+func (e *InMemoryEngine) CAS(key string, expected string, value string, commit func(value string, deadline time.Time) func() error) (bool, error) {
+	e.Logger.Debug(fmt.Sprintf("started cas query for key: %s; expected: %s; value: %s", key, expected, value))
+
+	applied, err := e.partitions[e.makeTxId(key)].setIf(key, value, time.Time{}, true, func(current string, found bool, _ uint64) bool {
+		return found && current == expected
+	}, commit)
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("cas query for key %s failed: %s", key, err.Error()))
+		return false, err
+	}
+
+	e.Logger.Debug("cas query is done")
+
+	return applied, nil
+}
+
+func (h *hashTable) setIf(key, value string, deadline time.Time, keepDeadline bool, condition func(current string, found bool, version uint64) bool,
+	commit func(value string, deadline time.Time) func() error) (bool, error) {
+	var applied bool
+
+	err := h.commit(key, func() (func(), func() error, error) {
+		now := time.Now()
+
+		if h.isExpired(key, now) {
+			h.remove(key)
+		}
+
+		if _, typed := h.typed[key]; typed {
+			return nil, nil, errWrongType
+		}
+
+		current, found := h.pairs[key]
+
+		if !condition(current, found, h.versions[key]) {
+			return nil, nil, nil
+		}
+
+		if keepDeadline {
+			deadline = h.expires[key]
+		}
+
+		if deadline.IsZero() || deadline.After(now) {
+			err := h.reserve([]string{key}, []string{value})
+
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		var wait func() error
+
+		if commit != nil {
+			wait = commit(value, deadline)
+		}
+
+		return func() {
+			applied = true
+
+			if !deadline.IsZero() && !deadline.After(time.Now()) {
+				h.remove(key)
+				return
+			}
+
+			h.store(key, value)
+
+			if deadline.IsZero() {
+				delete(h.expires, key)
+				return
+			}
+
+			h.expires[key] = deadline
+		}, wait, nil
+	})
+
+	return applied, err
+}
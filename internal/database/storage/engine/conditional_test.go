@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_SetIfEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	notExists := func(_ string, found bool, _ uint64) bool {
+		return !found
+	}
+
+	applied, err := engine.SETIF("biba", "boba", time.Time{}, notExists, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, applied)
+
+	applied, err = engine.SETIF("biba", "aboba", time.Time{}, notExists, nil)
+
+	assert.NoError(t, err)
+	assert.False(t, applied)
+
+	value, _ := engine.GET("biba")
+
+	assert.Equal(t, "boba", value)
+
+	applied, _ = engine.SETIF("biba", "aboba", time.Now().Add(time.Minute), func(_ string, found bool, _ uint64) bool {
+		return found
+	}, nil)
+
+	assert.True(t, applied)
+
+	ttl, _ := engine.TTL("biba")
+
+	assert.Positive(t, ttl)
+}
+
+func Test_SetIfEngineCommit(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	commits := 0
+
+	commit := func(string, time.Time) func() error {
+		commits++
+		return nil
+	}
+
+	engine.SETIF("biba", "boba", time.Time{}, func(_ string, found bool, _ uint64) bool { return found }, commit)
+	engine.SETIF("biba", "boba", time.Time{}, func(_ string, found bool, _ uint64) bool { return !found }, commit)
+
+	assert.Equal(t, 1, commits)
+}
+
+func Test_CasEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	applied, err := engine.CAS("biba", "boba", "aboba", nil)
+
+	assert.NoError(t, err)
+	assert.False(t, applied)
+
+	deadline := time.Now().Add(time.Minute)
+	engine.SETEX("biba", "boba", deadline, nil)
+
+	var committedDeadline time.Time
+
+	applied, err = engine.CAS("biba", "boba", "aboba", func(_ string, deadline time.Time) func() error {
+		committedDeadline = deadline
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	assert.True(t, deadline.Equal(committedDeadline))
+
+	value, _ := engine.GET("biba")
+
+	assert.Equal(t, "aboba", value)
+
+	applied, _ = engine.CAS("biba", "boba", "biba", nil)
+
+	assert.False(t, applied)
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+var (
+	errNotInteger = errors.New("value is not an integer or out of range")
+	errNotFloat   = errors.New("value is not a valid float")
+	errNotFinite  = errors.New("increment would produce NaN or Infinity")
+)
+
+func (e *InMemoryEngine) INCRBY(key string, delta int64, commit func(value string, deadline time.Time) func() error) (int64, error) {
+	e.Logger.Debug(fmt.Sprintf("started incrby query for key: %s; delta: %d", key, delta))
+
+	var result int64
+
+	err := e.partitions[e.makeTxId(key)].update(key, func(value string, found bool) (string, error) {
+		current := int64(0)
+
+		if found {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+
+			if err != nil {
+				return "", errNotInteger
+			}
+
+			current = parsed
+		}
+
+		if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+			return "", errNotInteger
+		}
+
+		result = current + delta
+
+		return strconv.FormatInt(result, 10), nil
+	}, commit)
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("incrby query for key %s failed: %s", key, err.Error()))
+		return 0, err
+	}
+
+	e.Logger.Debug("incrby query is done")
+
+	return result, nil
+}
+
+func (e *InMemoryEngine) INCRBYFLOAT(key string, delta float64, commit func(value string, deadline time.Time) func() error) (float64, error) {
+	e.Logger.Debug(fmt.Sprintf("started incrbyfloat query for key: %s; delta: %g", key, delta))
+
+	var result float64
+
+	err := e.partitions[e.makeTxId(key)].update(key, func(value string, found bool) (string, error) {
+		current := float64(0)
+
+		if found {
+			parsed, err := strconv.ParseFloat(value, 64)
+
+			if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+				return "", errNotFloat
+			}
+
+			current = parsed
+		}
+
+		result = current + delta
+
+		if math.IsNaN(result) || math.IsInf(result, 0) {
+			return "", errNotFinite
+		}
+
+		return strconv.FormatFloat(result, 'f', -1, 64), nil
+	}, commit)
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("incrbyfloat query for key %s failed: %s", key, err.Error()))
+		return 0, err
+	}
+
+	e.Logger.Debug("incrbyfloat query is done")
+
+	return result, nil
+}
+
+func (h *hashTable) update(key string, modify func(value string, found bool) (string, error),
+	commit func(value string, deadline time.Time) func() error) error {
+	return h.commit(key, func() (func(), func() error, error) {
+		if h.isExpired(key, time.Now()) {
+			h.remove(key)
+		}
+
+		if _, typed := h.typed[key]; typed {
+			return nil, nil, errWrongType
+		}
+
+		oldValue, found := h.pairs[key]
+
+		value, err := modify(oldValue, found)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = h.reserve([]string{key}, []string{value})
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		deadline := h.expires[key]
+
+		var wait func() error
+
+		if commit != nil {
+			wait = commit(value, deadline)
+		}
+
+		return func() {
+			if !deadline.IsZero() && !deadline.After(time.Now()) {
+				h.remove(key)
+				return
+			}
+
+			h.store(key, value)
+		}, wait, nil
+	})
+}
@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_IncrByEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	result, err := engine.INCRBY("biba", 5, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), result)
+
+	result, err = engine.INCRBY("biba", -7, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-2), result)
+
+	engine.SET("boba", "aboba", nil)
+
+	_, err = engine.INCRBY("boba", 1, nil)
+
+	assert.Equal(t, errNotInteger, err)
+
+	engine.SET("max", strconv.FormatInt(math.MaxInt64, 10), nil)
+
+	_, err = engine.INCRBY("max", 1, nil)
+
+	assert.Equal(t, errNotInteger, err)
+}
+
+func Test_IncrByFloatEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	result, err := engine.INCRBYFLOAT("biba", 1.5, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, result)
+
+	engine.SET("boba", "aboba", nil)
+
+	_, err = engine.INCRBYFLOAT("boba", 1, nil)
+
+	assert.Equal(t, errNotFloat, err)
+
+	engine.SET("max", strconv.FormatFloat(math.MaxFloat64, 'f', -1, 64), nil)
+
+	_, err = engine.INCRBYFLOAT("max", math.MaxFloat64, nil)
+
+	assert.Equal(t, errNotFinite, err)
+}
+
+func Test_IncrByEngineCommit(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	deadline := time.Now().Add(time.Minute)
+	engine.SETEX("biba", "1", deadline, nil)
+
+	var committed string
+	var committedDeadline time.Time
+
+	var logged string
+
+	_, err := engine.INCRBY("biba", 1, func(value string, deadline time.Time) func() error {
+		committed, committedDeadline = value, deadline
+
+		return func() error {
+			logged, _ = engine.GET("biba")
+			return nil
+		}
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2", committed)
+	assert.Equal(t, "1", logged)
+	assert.True(t, deadline.Equal(committedDeadline))
+
+	_, err = engine.INCRBY("biba", 1, func(value string, deadline time.Time) func() error {
+		return func() error {
+			return errOutOfMemory
+		}
+	})
+
+	assert.Equal(t, errOutOfMemory, err)
+
+	value, _ := engine.GET("biba")
+
+	assert.Equal(t, "2", value)
+}
+
+func Test_IncrByEngineConcurrent(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	wg := sync.WaitGroup{}
+
+	for range 100 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			engine.INCRBY("biba", 1, nil)
+		}()
+	}
+
+	wg.Wait()
+
+	value, _ := engine.GET("biba")
+
+	assert.Equal(t, "100", value)
+}
+
+func Test_IncrByEngineConcurrentCommits(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	wg := sync.WaitGroup{}
+
+	for range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			engine.INCRBY("biba", 1, func(string, time.Time) func() error {
+				return func() error {
+					time.Sleep(time.Millisecond)
+					return nil
+				}
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	value, _ := engine.GET("biba")
+
+	assert.Equal(t, "50", value)
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"inmemorykvdb/pkg/concurrency"
+	"slices"
+	"strconv"
+	"time"
+)
+
+func (e *InMemoryEngine) DUMP(visit func(key string, kind string, items []string, deadline time.Time)) {
+	e.Logger.Debug("started dump query")
+
+	for _, partition := range e.partitions {
+		partition.dump(visit)
+	}
+
+	e.Logger.Debug("dump query is done")
+}
+
+// DUMPKEYS visits the given keys the same way DUMP visits the whole storage.
+func (e *InMemoryEngine) DUMPKEYS(keys []string, visit func(key string, kind string, items []string, deadline time.Time)) {
+	e.Logger.Debug("started dumpkeys query")
+
+	for _, key := range keys {
+		e.partitions[e.makeTxId(key)].dumpKey(key, visit)
+	}
+
+	e.Logger.Debug("dumpkeys query is done")
+}
+
+func (h *hashTable) dump(visit func(key string, kind string, items []string, deadline time.Time)) {
+	concurrency.WithRLock(h.mutex, func() {
+		now := time.Now()
+
+		for key, value := range h.pairs {
+			if !h.isExpired(key, now) {
+				visit(key, stringType, []string{value}, h.expires[key])
+			}
+		}
+
+		for key, value := range h.typed {
+			if !h.isExpired(key, now) {
+				visit(key, value.kind, value.items(), h.expires[key])
+			}
+		}
+	})
+}
+
+func (h *hashTable) dumpKey(key string, visit func(key string, kind string, items []string, deadline time.Time)) {
+	concurrency.WithRLock(h.mutex, func() {
+		if h.isExpired(key, time.Now()) {
+			return
+		}
+
+		if value, found := h.pairs[key]; found {
+			visit(key, stringType, []string{value}, h.expires[key])
+		}
+
+		if value, found := h.typed[key]; found {
+			visit(key, value.kind, value.items(), h.expires[key])
+		}
+	})
+}
+
+func (v *typedValue) items() []string {
+	items := make([]string, 0)
+
+	switch v.kind {
+	case hashType:
+		fields := make([]string, 0, len(v.hash))
+
+		for field := range v.hash {
+			fields = append(fields, field)
+		}
+
+		slices.Sort(fields)
+
+		for _, field := range fields {
+			items = append(items, field, v.hash[field])
+		}
+
+	case listType:
+		items = append(items, v.list...)
+
+	case setType:
+		for member := range v.set {
+			items = append(items, member)
+		}
+
+		slices.Sort(items)
+
+	case zsetType:
+		for node := v.zset.order.head.levels[0].next; node != nil; node = node.levels[0].next {
+			items = append(items, strconv.FormatFloat(node.score, 'f', -1, 64), node.member)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_DumpEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithPartitions(4, 10))
+
+	deadline := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+
+	engine.SETEX("biba", "boba", deadline, nil)
+	engine.SETEX("gone", "boba", time.Now().Add(-time.Second), nil)
+	engine.HSET("user", []string{"name", "age"}, []string{"biba", "20"}, nil)
+	engine.RPUSH("queue", []string{"b", "a"}, nil)
+	engine.SADD("tags", []string{"y", "x"}, nil)
+	engine.ZADD("board", []float64{2.5, 1}, []string{"biba", "boba"}, nil)
+
+	type dumped struct {
+		kind     string
+		items    []string
+		deadline time.Time
+	}
+
+	entries := make(map[string]dumped)
+
+	engine.DUMP(func(key string, kind string, items []string, deadline time.Time) {
+		entries[key] = dumped{kind: kind, items: items, deadline: deadline}
+	})
+
+	assert.Equal(t, map[string]dumped{
+		"biba":  {kind: stringType, items: []string{"boba"}, deadline: deadline},
+		"user":  {kind: hashType, items: []string{"age", "20", "name", "biba"}},
+		"queue": {kind: listType, items: []string{"b", "a"}},
+		"tags":  {kind: setType, items: []string{"x", "y"}},
+		"board": {kind: zsetType, items: []string{"1", "boba", "2.5", "biba"}},
+	}, entries)
+}
+
+func Test_DumpKeysEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithPartitions(4, 10))
+
+	engine.SET("biba", "boba", nil)
+	engine.SETEX("gone", "boba", time.Now().Add(-time.Second), nil)
+	engine.RPUSH("queue", []string{"b", "a"}, nil)
+	engine.SADD("tags", []string{"x"}, nil)
+
+	var keys []string
+
+	engine.DUMPKEYS([]string{"queue", "gone", "missing", "biba"}, func(key string, _ string, _ []string, _ time.Time) {
+		keys = append(keys, key)
+	})
+
+	assert.Equal(t, []string{"queue", "biba"}, keys)
+}
@@ -1,6 +1,9 @@
 package engine
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type EngineOption func(engine *InMemoryEngine) error
 
@@ -23,3 +26,37 @@ func WithPartitions(count int, cap int) EngineOption {
 		return nil
 	}
 }
+
+func WithExpirationSweep(interval time.Duration, sampleSize int) EngineOption {
+	return func(engine *InMemoryEngine) error {
+		if interval <= 0 {
+			return errors.New("sweep interval could not be equal or less than zero")
+		}
+
+		if sampleSize <= 0 {
+			return errors.New("sweep sample size could not be equal or less than zero")
+		}
+
+		engine.sweepInterval = interval
+		engine.sweepSampleSize = sampleSize
+
+		return nil
+	}
+}
+
+func WithMaxMemory(maxMemory int, policy string) EngineOption {
+	return func(engine *InMemoryEngine) error {
+		if maxMemory <= 0 {
+			return errors.New("max memory could not be equal or less than zero")
+		}
+
+		if !isEvictionPolicy(policy) {
+			return errors.New("unknown eviction policy")
+		}
+
+		engine.maxMemory = maxMemory
+		engine.evictionPolicy = policy
+
+		return nil
+	}
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -64,3 +65,97 @@ func Test_WithPartitions(t *testing.T) {
 		})
 	}
 }
+
+func Test_WithExpirationSweep(t *testing.T) {
+	tests := map[string]struct {
+		interval   time.Duration
+		sampleSize int
+
+		expectedEng *InMemoryEngine
+		expectedErr error
+	}{
+		"correct interval and sample size": {
+			interval:   time.Second,
+			sampleSize: 10,
+
+			expectedEng: &InMemoryEngine{sweepInterval: time.Second, sweepSampleSize: 10},
+			expectedErr: nil,
+		},
+
+		"incorrect interval": {
+			interval:   0,
+			sampleSize: 10,
+
+			expectedEng: &InMemoryEngine{},
+			expectedErr: errors.New("sweep interval could not be equal or less than zero"),
+		},
+
+		"incorrect sample size": {
+			interval:   time.Second,
+			sampleSize: 0,
+
+			expectedEng: &InMemoryEngine{},
+			expectedErr: errors.New("sweep sample size could not be equal or less than zero"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			eng := &InMemoryEngine{}
+
+			option := WithExpirationSweep(test.interval, test.sampleSize)
+
+			err := option(eng)
+
+			assert.Equal(t, test.expectedEng, eng)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func Test_WithMaxMemory(t *testing.T) {
+	tests := map[string]struct {
+		maxMemory int
+		policy    string
+
+		expectedEng *InMemoryEngine
+		expectedErr error
+	}{
+		"correct max memory and policy": {
+			maxMemory: 1024,
+			policy:    AllKeysLRU,
+
+			expectedEng: &InMemoryEngine{maxMemory: 1024, evictionPolicy: AllKeysLRU},
+			expectedErr: nil,
+		},
+
+		"incorrect max memory": {
+			maxMemory: 0,
+			policy:    AllKeysLRU,
+
+			expectedEng: &InMemoryEngine{},
+			expectedErr: errors.New("max memory could not be equal or less than zero"),
+		},
+
+		"unknown policy": {
+			maxMemory: 1024,
+			policy:    "biba",
+
+			expectedEng: &InMemoryEngine{},
+			expectedErr: errors.New("unknown eviction policy"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			eng := &InMemoryEngine{}
+
+			option := WithMaxMemory(test.maxMemory, test.policy)
+
+			err := option(eng)
+
+			assert.Equal(t, test.expectedEng, eng)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	NoEviction    = "noeviction"
+	AllKeysLRU    = "allkeys-lru"
+	AllKeysLFU    = "allkeys-lfu"
+	VolatileTTL   = "volatile-ttl"
+	AllKeysRandom = "random"
+
+	entryOverhead      = 64
+	evictionSampleSize = 5
+)
+
+var errOutOfMemory = errors.New("out of memory")
+
+type keyStats struct {
+	lastAccess atomic.Uint64
+	hits       atomic.Uint64
+}
+
+func isEvictionPolicy(policy string) bool {
+	switch policy {
+	case NoEviction, AllKeysLRU, AllKeysLFU, VolatileTTL, AllKeysRandom:
+		return true
+	}
+
+	return false
+}
+
+func entrySize(key, value string) int {
+	return len(key) + len(value) + entryOverhead
+}
+
+func (h *hashTable) touch(key string) {
+	stats, found := h.stats[key]
+
+	if !found {
+		return
+	}
+
+	stats.lastAccess.Store(h.clock.Add(1))
+	stats.hits.Add(1)
+}
+
+func (h *hashTable) reserve(keys, values []string) error {
+	if h.maxMemory == 0 {
+		return nil
+	}
+
+	needed := 0
+
+	for i, key := range keys {
+		needed += entrySize(key, values[i]) - h.storedSize(key)
+	}
+
+	return h.reserveBytes(needed, keys)
+}
+
+func (h *hashTable) storedSize(key string) int {
+	if value, found := h.pairs[key]; found {
+		return entrySize(key, value)
+	}
+
+	if value, found := h.typed[key]; found {
+		return len(key) + value.size() + entryOverhead
+	}
+
+	return 0
+}
+
+// reserveBytes evicts other keys until the bytes fit, nothing is evicted
+// when the protected keys could not fit even into the empty partition.
+func (h *hashTable) reserveBytes(needed int, protected []string) error {
+	if h.maxMemory == 0 {
+		return nil
+	}
+
+	kept := needed
+
+	for i, key := range protected {
+		if !slices.Contains(protected[:i], key) {
+			kept += h.storedSize(key)
+		}
+	}
+
+	if kept > h.maxMemory {
+		return errOutOfMemory
+	}
+
+	for h.usedMemory+needed > h.maxMemory {
+		if h.policy == NoEviction {
+			return errOutOfMemory
+		}
+
+		victim, found := h.pickVictim(protected)
+
+		if !found {
+			return errOutOfMemory
+		}
+
+		h.remove(victim)
+	}
+
+	return nil
+}
+
+func (h *hashTable) pickVictim(protected []string) (string, bool) {
+	switch h.policy {
+	case VolatileTTL:
+		return h.pickByDeadline(protected)
+	case AllKeysRandom:
+		return h.pickRandom(protected)
+	}
+
+	return h.pickByStats(protected)
+}
+
+func (h *hashTable) pickByDeadline(protected []string) (string, bool) {
+	var victim string
+	var nearest time.Time
+	var sampled int
+
+	for key, deadline := range h.expires {
+		if slices.Contains(protected, key) {
+			continue
+		}
+
+		if sampled == 0 || deadline.Before(nearest) {
+			victim, nearest = key, deadline
+		}
+
+		sampled++
+
+		if sampled == evictionSampleSize {
+			break
+		}
+	}
+
+	return victim, sampled != 0
+}
+
+func (h *hashTable) pickRandom(protected []string) (string, bool) {
+	for key := range h.pairs {
+		if !slices.Contains(protected, key) {
+			return key, true
+		}
+	}
+
+	for key := range h.typed {
+		if !slices.Contains(protected, key) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+func (h *hashTable) pickByStats(protected []string) (string, bool) {
+	var victim string
+	var victimScore uint64
+	var sampled int
+
+	for key, stats := range h.stats {
+		if slices.Contains(protected, key) {
+			continue
+		}
+
+		score := stats.lastAccess.Load()
+
+		if h.policy == AllKeysLFU {
+			score = stats.hits.Load()
+		}
+
+		if sampled == 0 || score < victimScore {
+			victim, victimScore = key, score
+		}
+
+		sampled++
+
+		if sampled == evictionSampleSize {
+			break
+		}
+	}
+
+	return victim, sampled != 0
+}
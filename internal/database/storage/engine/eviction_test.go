@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_reserve(t *testing.T) {
+	fullTable := func(policy string) *hashTable {
+		ht := NewHashTable(10)
+		ht.limitMemory(3*entrySize("k1", "v1"), policy)
+
+		ht.set("k1", "v1", nil)
+		ht.set("k2", "v2", nil)
+		ht.set("k3", "v3", nil)
+
+		return ht
+	}
+
+	tests := map[string]struct {
+		policy  string
+		prepare func(ht *hashTable)
+
+		expectedErr     error
+		expectedEvicted string
+	}{
+		"noeviction": {
+			policy:  NoEviction,
+			prepare: func(ht *hashTable) {},
+
+			expectedErr: errOutOfMemory,
+		},
+
+		"allkeys-lru": {
+			policy: AllKeysLRU,
+			prepare: func(ht *hashTable) {
+				ht.get("k1")
+			},
+
+			expectedEvicted: "k2",
+		},
+
+		"allkeys-lfu": {
+			policy: AllKeysLFU,
+			prepare: func(ht *hashTable) {
+				ht.get("k1")
+				ht.get("k3")
+				ht.get("k1")
+			},
+
+			expectedEvicted: "k2",
+		},
+
+		"volatile-ttl": {
+			policy: VolatileTTL,
+			prepare: func(ht *hashTable) {
+				ht.expireAt("k1", time.Now().Add(time.Hour))
+				ht.expireAt("k3", time.Now().Add(time.Minute))
+			},
+
+			expectedEvicted: "k3",
+		},
+
+		"volatile-ttl without expiring keys": {
+			policy:  VolatileTTL,
+			prepare: func(ht *hashTable) {},
+
+			expectedErr: errOutOfMemory,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ht := fullTable(test.policy)
+			test.prepare(ht)
+
+			err := ht.set("k4", "v4", nil)
+
+			assert.Equal(t, test.expectedErr, err)
+			assert.LessOrEqual(t, ht.usedMemory, ht.maxMemory)
+
+			if test.expectedErr != nil {
+				assert.Len(t, ht.pairs, 3)
+				assert.NotContains(t, ht.pairs, "k4")
+				return
+			}
+
+			assert.Len(t, ht.pairs, 3)
+			assert.Contains(t, ht.pairs, "k4")
+			assert.NotContains(t, ht.pairs, test.expectedEvicted)
+			assert.NotContains(t, ht.stats, test.expectedEvicted)
+		})
+	}
+}
+
+func Test_reserveRandom(t *testing.T) {
+	ht := NewHashTable(10)
+	ht.limitMemory(2*entrySize("k1", "v1"), AllKeysRandom)
+
+	ht.set("k1", "v1", nil)
+	ht.set("k2", "v2", nil)
+
+	err := ht.set("k3", "v3", nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, ht.pairs, 2)
+	assert.Contains(t, ht.pairs, "k3")
+}
+
+func Test_reserveOverLimit(t *testing.T) {
+	ht := NewHashTable(10)
+	ht.limitMemory(3*entrySize("k1", "v1"), AllKeysLRU)
+
+	ht.set("k1", "v1", nil)
+	ht.set("k2", "v2", nil)
+
+	err := ht.set("k3", string(make([]byte, 3*entrySize("k1", "v1"))), nil)
+
+	assert.Equal(t, errOutOfMemory, err)
+	assert.Len(t, ht.pairs, 2)
+	assert.Equal(t, 2*entrySize("k1", "v1"), ht.memoryUsage())
+
+	err = ht.modifyTyped("list", listType, 3*entrySize("k1", "v1"), true, nil, func(value *typedValue) error {
+		return nil
+	})
+
+	assert.Equal(t, errOutOfMemory, err)
+	assert.Len(t, ht.pairs, 2)
+}
+
+func Test_reserveBeforeCommit(t *testing.T) {
+	ht := NewHashTable(10)
+	ht.limitMemory(2*entrySize("k1", "v1"), NoEviction)
+
+	committed := 0
+	commit := func() func() error {
+		committed++
+		return func() error { return nil }
+	}
+
+	assert.NoError(t, ht.set("k1", "v1", commit))
+	assert.NoError(t, ht.set("k2", "v2", commit))
+	assert.Equal(t, errOutOfMemory, ht.set("k3", "v3", commit))
+	assert.Equal(t, 2, committed)
+	assert.NotContains(t, ht.pairs, "k3")
+
+	err := ht.modifyTyped("list", listType, entrySize("k1", "v1"), true, commit, func(value *typedValue) error {
+		return nil
+	})
+
+	assert.Equal(t, errOutOfMemory, err)
+	assert.Equal(t, 2, committed)
+}
+
+func Test_memoryAccounting(t *testing.T) {
+	ht := NewHashTable(10)
+
+	ht.set("key", "value", nil)
+	assert.Equal(t, entrySize("key", "value"), ht.memoryUsage())
+
+	ht.set("key", "longer value", nil)
+	assert.Equal(t, entrySize("key", "longer value"), ht.memoryUsage())
+
+	ht.setWithDeadline("other", "value", time.Now().Add(time.Hour), nil)
+	assert.Equal(t, entrySize("key", "longer value")+entrySize("other", "value"), ht.memoryUsage())
+
+	ht.del("key")
+	ht.del("other")
+	assert.Equal(t, 0, ht.memoryUsage())
+}
+
+func Test_isEvictionPolicy(t *testing.T) {
+	for _, policy := range []string{NoEviction, AllKeysLRU, AllKeysLFU, VolatileTTL, AllKeysRandom} {
+		assert.True(t, isEvictionPolicy(policy))
+	}
+
+	assert.False(t, isEvictionPolicy("allkeys-biba"))
+}
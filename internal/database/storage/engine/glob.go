@@ -0,0 +1,91 @@
+package engine
+
+func matchGlob(pattern, value string) bool {
+	for len(pattern) != 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) != 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 0 {
+				return true
+			}
+
+			for i := range len(value) + 1 {
+				if matchGlob(pattern, value[i:]) {
+					return true
+				}
+			}
+
+			return false
+
+		case '?':
+			if len(value) == 0 {
+				return false
+			}
+
+			pattern, value = pattern[1:], value[1:]
+
+		case '[':
+			if len(value) == 0 {
+				return false
+			}
+
+			matched, rest, ok := matchClass(pattern[1:], value[0])
+
+			if !ok || !matched {
+				return false
+			}
+
+			pattern, value = rest, value[1:]
+
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+
+			fallthrough
+
+		default:
+			if len(value) == 0 || pattern[0] != value[0] {
+				return false
+			}
+
+			pattern, value = pattern[1:], value[1:]
+		}
+	}
+
+	return len(value) == 0
+}
+
+func matchClass(class string, symbol byte) (bool, string, bool) {
+	negated := len(class) != 0 && class[0] == '^'
+
+	if negated {
+		class = class[1:]
+	}
+
+	var matched bool
+
+	for i := 0; i < len(class); i++ {
+		if class[i] == ']' && i != 0 {
+			return matched != negated, class[i+1:], true
+		}
+
+		if i+2 < len(class) && class[i+1] == '-' && class[i+2] != ']' {
+			if class[i] <= symbol && symbol <= class[i+2] {
+				matched = true
+			}
+
+			i += 2
+			continue
+		}
+
+		if class[i] == symbol {
+			matched = true
+		}
+	}
+
+	return false, "", false
+}
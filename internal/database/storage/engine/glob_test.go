@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_matchGlob(t *testing.T) {
+	tests := map[string]struct {
+		pattern string
+		value   string
+
+		expected bool
+	}{
+		"exact match":            {pattern: "user", value: "user", expected: true},
+		"exact mismatch":         {pattern: "user", value: "users", expected: false},
+		"star matches anything":  {pattern: "*", value: "user:1/2", expected: true},
+		"star in the middle":     {pattern: "user:*:name", value: "user:42:name", expected: true},
+		"star with empty suffix": {pattern: "user:*", value: "user:", expected: true},
+		"question mark":          {pattern: "user:?", value: "user:1", expected: true},
+		"question mark too long": {pattern: "user:?", value: "user:12", expected: false},
+		"class":                  {pattern: "user:[12]", value: "user:2", expected: true},
+		"class mismatch":         {pattern: "user:[12]", value: "user:3", expected: false},
+		"class range":            {pattern: "user:[a-c]", value: "user:b", expected: true},
+		"negated class":          {pattern: "user:[^a-c]", value: "user:b", expected: false},
+		"unclosed class":         {pattern: "user:[ab", value: "user:a", expected: false},
+		"escaped star":           {pattern: "user\\*", value: "user*", expected: true},
+		"escaped star mismatch":  {pattern: "user\\*", value: "users", expected: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, matchGlob(test.pattern, test.value))
+		})
+	}
+}
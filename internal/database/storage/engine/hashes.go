@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+)
+
+func (e *InMemoryEngine) HSET(key string, fields []string, values []string, commit func() func() error) (int, error) {
+	e.Logger.Debug(fmt.Sprintf("started hset query for key: %s", key))
+
+	var added int
+
+	needed := 0
+
+	for i, field := range fields {
+		needed += len(field) + len(values[i])
+	}
+
+	err := e.partitions[e.makeTxId(key)].modifyTyped(key, hashType, needed, true, commit, func(value *typedValue) error {
+		for i, field := range fields {
+			if _, found := value.hash[field]; !found {
+				added++
+			}
+
+			value.hash[field] = values[i]
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("hset query for key %s failed: %s", key, err.Error()))
+		return 0, err
+	}
+
+	e.Logger.Debug("hset query is done")
+
+	return added, nil
+}
+
+func (e *InMemoryEngine) HGET(key string, field string) (string, bool, error) {
+	e.Logger.Debug(fmt.Sprintf("started hget query for key: %s; field: %s", key, field))
+
+	var res string
+	var found bool
+
+	err := e.partitions[e.makeTxId(key)].readTyped(key, hashType, func(value *typedValue) {
+		res, found = value.hash[field]
+	})
+
+	if err != nil {
+		return "", false, err
+	}
+
+	e.Logger.Debug("hget query is done")
+
+	return res, found, nil
+}
+
+func (e *InMemoryEngine) HDEL(key string, fields []string) (int, error) {
+	e.Logger.Debug(fmt.Sprintf("started hdel query for key: %s", key))
+
+	var deleted int
+
+	err := e.partitions[e.makeTxId(key)].modifyTyped(key, hashType, 0, false, nil, func(value *typedValue) error {
+		for _, field := range fields {
+			if _, found := value.hash[field]; found {
+				delete(value.hash, field)
+				deleted++
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	e.Logger.Debug("hdel query is done")
+
+	return deleted, nil
+}
+
+func (e *InMemoryEngine) HGETALL(key string) ([]string, []string, error) {
+	e.Logger.Debug(fmt.Sprintf("started hgetall query for key: %s", key))
+
+	fields := make([]string, 0)
+	values := make([]string, 0)
+
+	err := e.partitions[e.makeTxId(key)].readTyped(key, hashType, func(value *typedValue) {
+		for field := range value.hash {
+			fields = append(fields, field)
+		}
+
+		slices.Sort(fields)
+
+		for _, field := range fields {
+			values = append(values, value.hash[field])
+		}
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e.Logger.Debug("hgetall query is done")
+
+	return fields, values, nil
+}
@@ -2,37 +2,420 @@ package engine
 
 import (
 	"inmemorykvdb/pkg/concurrency"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	noExpiration time.Duration = -1
 )
 
 type hashTable struct {
-	pairs map[string]string
-	mutex *sync.RWMutex
+	pairs    map[string]string
+	typed    map[string]*typedValue
+	expires  map[string]time.Time
+	stats    map[string]*keyStats
+	versions map[string]uint64
+	pending  map[string]chan struct{}
+	index    *skipList
+	mutex    *sync.RWMutex
+
+	slots     []scanSlot
+	slotOf    map[string]int
+	freeSlots []int
+
+	usedMemory int
+	maxMemory  int
+	policy     string
+	clock      atomic.Uint64
+	version    uint64
+
+	// removed is the version of the last removal, the missing keys report it
+	// so a key deleted after a WATCH never returns to the watched version
+	removed uint64
 }
 
 func NewHashTable(capacity int) *hashTable {
-	return &hashTable{pairs: make(map[string]string, capacity), mutex: &sync.RWMutex{}}
+	return &hashTable{
+		pairs:    make(map[string]string, capacity),
+		typed:    make(map[string]*typedValue),
+		expires:  make(map[string]time.Time),
+		stats:    make(map[string]*keyStats),
+		versions: make(map[string]uint64, capacity),
+		pending:  make(map[string]chan struct{}),
+		mutex:    &sync.RWMutex{},
+		slotOf:   make(map[string]int, capacity),
+	}
+}
+
+func (h *hashTable) enableIndex() {
+	concurrency.WithLock(h.mutex, func() {
+		h.index = newSkipList()
+
+		for key := range h.pairs {
+			h.index.insert(key)
+		}
+
+		for key := range h.typed {
+			h.index.insert(key)
+		}
+	})
+}
+
+func (h *hashTable) limitMemory(maxMemory int, policy string) {
+	concurrency.WithLock(h.mutex, func() {
+		h.maxMemory = maxMemory
+		h.policy = policy
+	})
+}
+
+func (h *hashTable) memoryUsage() int {
+	var used int
+
+	concurrency.WithRLock(h.mutex, func() {
+		used = h.usedMemory
+	})
+
+	return used
 }
 
 func (h *hashTable) get(key string) (string, bool) {
 	var res string
 	var found bool
+	var expired bool
 
 	concurrency.WithRLock(h.mutex, func() {
 		res, found = h.pairs[key]
+		expired = found && h.isExpired(key, time.Now())
+
+		if found && !expired {
+			h.touch(key)
+		}
 	})
 
+	if expired {
+		h.removeExpired(key)
+		return "", false
+	}
+
 	return res, found
 }
 
-func (h *hashTable) set(key, value string) {
-	concurrency.WithLock(h.mutex, func() {
-		h.pairs[key] = value
+func (h *hashTable) set(key, value string, commit func() func() error) error {
+	return h.setWithDeadline(key, value, time.Time{}, commit)
+}
+
+// setWithDeadline reserves the memory before the commit logs the value,
+// a zero deadline keeps the value forever and a passed one removes the key.
+func (h *hashTable) setWithDeadline(key, value string, deadline time.Time, commit func() func() error) error {
+	return h.commit(key, func() (func(), func() error, error) {
+		if deadline.IsZero() || deadline.After(time.Now()) {
+			err := h.reserve([]string{key}, []string{value})
+
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		var wait func() error
+
+		if commit != nil {
+			wait = commit()
+		}
+
+		return func() {
+			if !deadline.IsZero() && !deadline.After(time.Now()) {
+				h.remove(key)
+				return
+			}
+
+			h.store(key, value)
+
+			if deadline.IsZero() {
+				delete(h.expires, key)
+				return
+			}
+
+			h.expires[key] = deadline
+		}, wait, nil
 	})
 }
 
 func (h *hashTable) del(key string) {
 	concurrency.WithLock(h.mutex, func() {
-		delete(h.pairs, key)
+		h.remove(key)
+	})
+}
+
+func (h *hashTable) expireAt(key string, deadline time.Time) bool {
+	var found bool
+
+	concurrency.WithLock(h.mutex, func() {
+		found = h.exists(key, time.Now())
+
+		if !found {
+			return
+		}
+
+		if !deadline.After(time.Now()) {
+			h.remove(key)
+			return
+		}
+
+		h.expires[key] = deadline
+		h.bump(key)
+	})
+
+	return found
+}
+
+func (h *hashTable) ttl(key string) (time.Duration, bool) {
+	var ttl time.Duration
+	var found bool
+
+	concurrency.WithRLock(h.mutex, func() {
+		now := time.Now()
+		found = h.exists(key, now)
+
+		if !found {
+			return
+		}
+
+		deadline, hasDeadline := h.expires[key]
+
+		if !hasDeadline {
+			ttl = noExpiration
+			return
+		}
+
+		ttl = deadline.Sub(now)
+	})
+
+	return ttl, found
+}
+
+func (h *hashTable) persist(key string) bool {
+	var found bool
+
+	concurrency.WithLock(h.mutex, func() {
+		found = h.exists(key, time.Now())
+
+		if found {
+			delete(h.expires, key)
+			h.bump(key)
+		}
+	})
+
+	return found
+}
+
+func (h *hashTable) rangeKeys(start, end string, limit int) ([]string, []string) {
+	return h.ascend(start, limit, func(key string) bool {
+		return key <= end
+	})
+}
+
+func (h *hashTable) prefixKeys(prefix string, limit int) ([]string, []string) {
+	return h.ascend(prefix, limit, func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+func (h *hashTable) ascend(from string, limit int, inRange func(key string) bool) ([]string, []string) {
+	keys := make([]string, 0)
+	values := make([]string, 0)
+
+	concurrency.WithRLock(h.mutex, func() {
+		if h.index == nil {
+			return
+		}
+
+		now := time.Now()
+
+		h.index.ascend(from, func(key string) bool {
+			if !inRange(key) {
+				return false
+			}
+
+			value, isString := h.pairs[key]
+
+			if !isString || h.isExpired(key, now) {
+				return true
+			}
+
+			keys = append(keys, key)
+			values = append(values, value)
+
+			return limit == 0 || len(keys) < limit
+		})
+	})
+
+	return keys, values
+}
+
+func (h *hashTable) sweep(sampleSize int) int {
+	var removed int
+
+	concurrency.WithLock(h.mutex, func() {
+		now := time.Now()
+		checked := 0
+
+		for key := range h.expires {
+			if checked == sampleSize {
+				return
+			}
+
+			checked++
+
+			if h.isExpired(key, now) {
+				h.remove(key)
+				removed++
+			}
+		}
+	})
+
+	return removed
+}
+
+func (h *hashTable) removeExpired(key string) {
+	concurrency.WithLock(h.mutex, func() {
+		if h.isExpired(key, time.Now()) {
+			h.remove(key)
+		}
 	})
 }
+
+func (h *hashTable) exists(key string, now time.Time) bool {
+	_, found := h.pairs[key]
+
+	if !found {
+		_, found = h.typed[key]
+	}
+
+	return found && !h.isExpired(key, now)
+}
+
+func (h *hashTable) isExpired(key string, now time.Time) bool {
+	deadline, hasDeadline := h.expires[key]
+
+	return hasDeadline && !deadline.After(now)
+}
+
+func (h *hashTable) store(key, value string) {
+	if _, found := h.typed[key]; found {
+		h.remove(key)
+	}
+
+	if oldValue, found := h.pairs[key]; found {
+		h.usedMemory -= entrySize(key, oldValue)
+	} else {
+		h.takeSlot(key)
+
+		if h.index != nil {
+			h.index.insert(key)
+		}
+	}
+
+	h.pairs[key] = value
+	h.usedMemory += entrySize(key, value)
+	h.bump(key)
+
+	h.track(key)
+}
+
+// commit runs prepare under the lock and applies its change once the change is logged,
+// the log is awaited outside of the lock while the next commits of the key wait for it.
+func (h *hashTable) commit(key string, prepare func() (apply func(), wait func() error, err error)) error {
+	for {
+		var pending chan struct{}
+		var apply func()
+		var wait func() error
+		var err error
+
+		concurrency.WithLock(h.mutex, func() {
+			if pending = h.pending[key]; pending != nil {
+				return
+			}
+
+			apply, wait, err = prepare()
+
+			if err != nil || apply == nil {
+				return
+			}
+
+			if wait == nil {
+				apply()
+				return
+			}
+
+			h.pending[key] = make(chan struct{})
+		})
+
+		if pending != nil {
+			<-pending
+			continue
+		}
+
+		if err != nil || apply == nil || wait == nil {
+			return err
+		}
+
+		err = wait()
+
+		concurrency.WithLock(h.mutex, func() {
+			if err == nil {
+				apply()
+			}
+
+			close(h.pending[key])
+			delete(h.pending, key)
+		})
+
+		return err
+	}
+}
+
+func (h *hashTable) track(key string) {
+	if h.maxMemory == 0 {
+		return
+	}
+
+	if _, found := h.stats[key]; !found {
+		h.stats[key] = &keyStats{}
+	}
+
+	h.touch(key)
+}
+
+func (h *hashTable) remove(key string) {
+	if value, found := h.pairs[key]; found {
+		h.usedMemory -= entrySize(key, value)
+		h.releaseSlot(key)
+
+		if h.index != nil {
+			h.index.delete(key)
+		}
+	}
+
+	if value, found := h.typed[key]; found {
+		h.usedMemory -= len(key) + value.size() + entryOverhead
+		h.releaseSlot(key)
+
+		if h.index != nil {
+			h.index.delete(key)
+		}
+	}
+
+	if _, found := h.pairs[key]; found || h.typed[key] != nil {
+		h.version++
+		h.removed = h.version
+	}
+
+	delete(h.pairs, key)
+	delete(h.typed, key)
+	delete(h.expires, key)
+	delete(h.stats, key)
+	delete(h.versions, key)
+}
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -38,7 +39,7 @@ func Test_set(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			ht.set(test.key, test.value)
+			ht.set(test.key, test.value, nil)
 
 			val := ht.pairs[test.key]
 
@@ -71,7 +72,7 @@ func Test_del(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			ht.set(test.keyToSet, test.valToSet)
+			ht.set(test.keyToSet, test.valToSet, nil)
 			ht.del(test.keyToDel)
 
 			assert.Equal(t, "", ht.pairs[test.keyToDel])
@@ -109,7 +110,7 @@ func Test_get(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			ht.set(test.keyToSet, test.valToSet)
+			ht.set(test.keyToSet, test.valToSet, nil)
 
 			val, found := ht.get(test.keyToGet)
 
@@ -118,3 +119,154 @@ func Test_get(t *testing.T) {
 		})
 	}
 }
+
+func Test_setWithDeadline(t *testing.T) {
+	cap := 10
+	ht := NewHashTable(cap)
+
+	tests := map[string]struct {
+		key      string
+		value    string
+		deadline time.Time
+
+		isFound bool
+	}{
+		"deadline in future": {
+			key:      "key1",
+			value:    "val1",
+			deadline: time.Now().Add(time.Hour),
+
+			isFound: true,
+		},
+
+		"deadline in past": {
+			key:      "key2",
+			value:    "val2",
+			deadline: time.Now().Add(-time.Hour),
+
+			isFound: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ht.setWithDeadline(test.key, test.value, test.deadline, nil)
+
+			_, found := ht.pairs[test.key]
+			_, hasDeadline := ht.expires[test.key]
+
+			assert.Equal(t, test.isFound, found)
+			assert.Equal(t, test.isFound, hasDeadline)
+		})
+	}
+}
+
+func Test_getExpired(t *testing.T) {
+	ht := NewHashTable(10)
+
+	ht.set("key1", "val1", nil)
+	ht.expires["key1"] = time.Now().Add(-time.Second)
+
+	val, found := ht.get("key1")
+
+	assert.Equal(t, "", val)
+	assert.False(t, found)
+	assert.NotContains(t, ht.pairs, "key1")
+	assert.NotContains(t, ht.expires, "key1")
+}
+
+func Test_expireAt(t *testing.T) {
+	tests := map[string]struct {
+		keyToSet string
+		keyToExp string
+		deadline time.Time
+
+		expectedFound bool
+		expectedExist bool
+	}{
+		"expire existing key": {
+			keyToSet: "key1",
+			keyToExp: "key1",
+			deadline: time.Now().Add(time.Hour),
+
+			expectedFound: true,
+			expectedExist: true,
+		},
+
+		"expire existing key in past": {
+			keyToSet: "key1",
+			keyToExp: "key1",
+			deadline: time.Now().Add(-time.Hour),
+
+			expectedFound: true,
+			expectedExist: false,
+		},
+
+		"expire not existing key": {
+			keyToSet: "key1",
+			keyToExp: "key2",
+			deadline: time.Now().Add(time.Hour),
+
+			expectedFound: false,
+			expectedExist: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ht := NewHashTable(10)
+			ht.set(test.keyToSet, "val", nil)
+
+			found := ht.expireAt(test.keyToExp, test.deadline)
+			_, exist := ht.get(test.keyToExp)
+
+			assert.Equal(t, test.expectedFound, found)
+			assert.Equal(t, test.expectedExist, exist)
+		})
+	}
+}
+
+func Test_ttlAndPersist(t *testing.T) {
+	ht := NewHashTable(10)
+
+	ht.set("key1", "val1", nil)
+
+	ttl, found := ht.ttl("key1")
+	assert.True(t, found)
+	assert.Equal(t, noExpiration, ttl)
+
+	ht.expireAt("key1", time.Now().Add(time.Minute))
+
+	ttl, found = ht.ttl("key1")
+	assert.True(t, found)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+	assert.True(t, ht.persist("key1"))
+
+	ttl, found = ht.ttl("key1")
+	assert.True(t, found)
+	assert.Equal(t, noExpiration, ttl)
+
+	assert.False(t, ht.persist("key2"))
+
+	_, found = ht.ttl("key2")
+	assert.False(t, found)
+}
+
+func Test_sweep(t *testing.T) {
+	ht := NewHashTable(10)
+
+	ht.set("key1", "val1", nil)
+	ht.set("key2", "val2", nil)
+	ht.set("key3", "val3", nil)
+
+	ht.expires["key1"] = time.Now().Add(-time.Second)
+	ht.expires["key2"] = time.Now().Add(-time.Second)
+	ht.expires["key3"] = time.Now().Add(time.Hour)
+
+	removed := ht.sweep(10)
+
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, map[string]string{"key3": "val3"}, ht.pairs)
+	assert.Len(t, ht.expires, 1)
+}
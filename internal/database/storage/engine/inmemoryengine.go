@@ -4,17 +4,29 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 const (
 	baseCapacity = 100
+
+	defaultSweepInterval   = 100 * time.Millisecond
+	defaultSweepSampleSize = 20
 )
 
 type InMemoryEngine struct {
 	Logger     *zap.Logger
 	partitions []*hashTable
+
+	sweepInterval   time.Duration
+	sweepSampleSize int
+	closed          atomic.Bool
+
+	maxMemory      int
+	evictionPolicy string
 }
 
 func NewInMemoryEngine(logger *zap.Logger, options ...EngineOption) (*InMemoryEngine, error) {
@@ -37,6 +49,24 @@ func NewInMemoryEngine(logger *zap.Logger, options ...EngineOption) (*InMemoryEn
 		engine.partitions[0] = NewHashTable(baseCapacity)
 	}
 
+	if engine.evictionPolicy == "" {
+		engine.evictionPolicy = NoEviction
+	}
+
+	if engine.maxMemory != 0 {
+		engine.limitMemory()
+	}
+
+	if engine.sweepInterval == 0 {
+		engine.sweepInterval = defaultSweepInterval
+	}
+
+	if engine.sweepSampleSize == 0 {
+		engine.sweepSampleSize = defaultSweepSampleSize
+	}
+
+	go engine.sweepExpired()
+
 	return engine, nil
 }
 
@@ -50,12 +80,34 @@ func (e *InMemoryEngine) GET(key string) (string, bool) {
 	return value, found
 }
 
-func (e *InMemoryEngine) SET(key string, value string) {
+func (e *InMemoryEngine) SET(key string, value string, commit func() func() error) error {
 	e.Logger.Debug(fmt.Sprintf("started set query for key: %s; value: %s", key, value))
 
-	e.partitions[e.makeTxId(key)].set(key, value)
+	err := e.partitions[e.makeTxId(key)].set(key, value, commit)
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("set query for key %s failed: %s", key, err.Error()))
+		return err
+	}
 
 	e.Logger.Debug("set query is done")
+
+	return nil
+}
+
+func (e *InMemoryEngine) SETEX(key string, value string, deadline time.Time, commit func() func() error) error {
+	e.Logger.Debug(fmt.Sprintf("started setex query for key: %s; value: %s; deadline: %s", key, value, deadline))
+
+	err := e.partitions[e.makeTxId(key)].setWithDeadline(key, value, deadline, commit)
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("setex query for key %s failed: %s", key, err.Error()))
+		return err
+	}
+
+	e.Logger.Debug("setex query is done")
+
+	return nil
 }
 
 func (e *InMemoryEngine) DEL(key string) {
@@ -66,6 +118,82 @@ func (e *InMemoryEngine) DEL(key string) {
 	e.Logger.Debug("del query is done")
 }
 
+func (e *InMemoryEngine) EXPIREAT(key string, deadline time.Time) bool {
+	e.Logger.Debug(fmt.Sprintf("started expireat query for key: %s; deadline: %s", key, deadline))
+
+	found := e.partitions[e.makeTxId(key)].expireAt(key, deadline)
+
+	e.Logger.Debug("expireat query is done")
+
+	return found
+}
+
+func (e *InMemoryEngine) TTL(key string) (time.Duration, bool) {
+	e.Logger.Debug(fmt.Sprintf("started ttl query for key: %s", key))
+
+	ttl, found := e.partitions[e.makeTxId(key)].ttl(key)
+
+	e.Logger.Debug("ttl query is done")
+
+	return ttl, found
+}
+
+func (e *InMemoryEngine) PERSIST(key string) bool {
+	e.Logger.Debug(fmt.Sprintf("started persist query for key: %s", key))
+
+	found := e.partitions[e.makeTxId(key)].persist(key)
+
+	e.Logger.Debug("persist query is done")
+
+	return found
+}
+
+func (e *InMemoryEngine) MemoryUsage() int {
+	var used int
+
+	for _, partition := range e.partitions {
+		used += partition.memoryUsage()
+	}
+
+	return used
+}
+
+func (e *InMemoryEngine) limitMemory() {
+	partitionMemory := e.maxMemory / len(e.partitions)
+
+	if partitionMemory == 0 {
+		partitionMemory = 1
+	}
+
+	for _, partition := range e.partitions {
+		partition.limitMemory(partitionMemory, e.evictionPolicy)
+	}
+}
+
+func (e *InMemoryEngine) sweepExpired() {
+	ticker := time.NewTicker(e.sweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if e.closed.Load() {
+			return
+		}
+
+		for _, partition := range e.partitions {
+			removed := partition.sweep(e.sweepSampleSize)
+
+			if removed != 0 {
+				e.Logger.Debug(fmt.Sprintf("sweep removed %d expired keys", removed))
+			}
+		}
+	}
+}
+
+// Close stops the background sweep of the expired keys at its next tick.
+func (e *InMemoryEngine) Close() {
+	e.closed.Store(true)
+}
+
 func (e *InMemoryEngine) makeTxId(key string) int {
 	hash := fnv.New32a()
 	hash.Write([]byte(key))
@@ -3,6 +3,7 @@ package engine
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -71,7 +72,7 @@ func Test_SetEngine(t *testing.T) {
 	key := "key"
 	value := "value"
 
-	engine.SET(key, value)
+	engine.SET(key, value, nil)
 
 	actual, found := engine.GET(key)
 
@@ -124,7 +125,7 @@ func Test_GetEngine(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			engine, _ := NewInMemoryEngine(zap.NewNop())
 
-			engine.SET(test.setKey, test.setValue)
+			engine.SET(test.setKey, test.setValue, nil)
 
 			actualValue, actualFound := engine.GET(test.getKey)
 
@@ -169,7 +170,7 @@ func Test_DelEngine(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			engine, _ := NewInMemoryEngine(zap.NewNop())
 
-			engine.SET(test.setKey, test.setValue)
+			engine.SET(test.setKey, test.setValue, nil)
 
 			engine.DEL(test.delKey)
 
@@ -179,3 +180,92 @@ func Test_DelEngine(t *testing.T) {
 		})
 	}
 }
+
+func Test_ExpirationEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	engine.SETEX("biba", "boba", time.Now().Add(time.Hour), nil)
+
+	value, found := engine.GET("biba")
+	assert.Equal(t, "boba", value)
+	assert.True(t, found)
+
+	ttl, found := engine.TTL("biba")
+	assert.True(t, found)
+	assert.True(t, ttl > 0)
+
+	assert.True(t, engine.PERSIST("biba"))
+
+	ttl, _ = engine.TTL("biba")
+	assert.Equal(t, noExpiration, ttl)
+
+	assert.True(t, engine.EXPIREAT("biba", time.Now().Add(-time.Second)))
+
+	_, found = engine.GET("biba")
+	assert.False(t, found)
+
+	assert.False(t, engine.EXPIREAT("boba", time.Now().Add(time.Hour)))
+}
+
+func Test_SweepExpired(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithPartitions(4, 10), WithExpirationSweep(time.Millisecond, 10))
+
+	engine.SETEX("biba", "boba", time.Now().Add(5*time.Millisecond), nil)
+
+	partition := engine.partitions[engine.makeTxId("biba")]
+
+	assert.Eventually(t, func() bool {
+		partition.mutex.RLock()
+		defer partition.mutex.RUnlock()
+
+		_, found := partition.pairs["biba"]
+
+		return !found
+	}, time.Second, time.Millisecond)
+}
+
+func Test_CloseEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithExpirationSweep(time.Millisecond, 10))
+
+	engine.Close()
+	engine.Close()
+
+	time.Sleep(10 * time.Millisecond)
+
+	engine.SETEX("biba", "boba", time.Now().Add(time.Millisecond), nil)
+
+	time.Sleep(20 * time.Millisecond)
+
+	partition := engine.partitions[engine.makeTxId("biba")]
+
+	partition.mutex.RLock()
+	defer partition.mutex.RUnlock()
+
+	_, found := partition.pairs["biba"]
+
+	assert.True(t, found)
+}
+
+func Test_MaxMemoryEngine(t *testing.T) {
+
+	partitions := 4
+	maxMemory := partitions * entrySize("key", "value")
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithPartitions(partitions, 10), WithMaxMemory(maxMemory, NoEviction))
+
+	for _, partition := range engine.partitions {
+		assert.Equal(t, entrySize("key", "value"), partition.maxMemory)
+		assert.Equal(t, NoEviction, partition.policy)
+	}
+
+	assert.NoError(t, engine.SET("key", "value", nil))
+	assert.Equal(t, entrySize("key", "value"), engine.MemoryUsage())
+
+	assert.Equal(t, errOutOfMemory, engine.SET("key", "much longer value", nil))
+
+	value, _ := engine.GET("key")
+	assert.Equal(t, "value", value)
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+)
+
+func (e *InMemoryEngine) LPUSH(key string, elements []string, commit func() func() error) (int, error) {
+	e.Logger.Debug(fmt.Sprintf("started lpush query for key: %s", key))
+
+	length, err := e.push(key, elements, commit, func(list []string) []string {
+		pushed := slices.Clone(elements)
+		slices.Reverse(pushed)
+
+		return append(pushed, list...)
+	})
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("lpush query for key %s failed: %s", key, err.Error()))
+		return 0, err
+	}
+
+	e.Logger.Debug("lpush query is done")
+
+	return length, nil
+}
+
+func (e *InMemoryEngine) RPUSH(key string, elements []string, commit func() func() error) (int, error) {
+	e.Logger.Debug(fmt.Sprintf("started rpush query for key: %s", key))
+
+	length, err := e.push(key, elements, commit, func(list []string) []string {
+		return append(list, elements...)
+	})
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("rpush query for key %s failed: %s", key, err.Error()))
+		return 0, err
+	}
+
+	e.Logger.Debug("rpush query is done")
+
+	return length, nil
+}
+
+func (e *InMemoryEngine) LPOP(key string) (string, bool, error) {
+	e.Logger.Debug(fmt.Sprintf("started lpop query for key: %s", key))
+
+	element, found, err := e.pop(key, func(list []string) (string, []string) {
+		return list[0], list[1:]
+	})
+
+	e.Logger.Debug("lpop query is done")
+
+	return element, found, err
+}
+
+func (e *InMemoryEngine) RPOP(key string) (string, bool, error) {
+	e.Logger.Debug(fmt.Sprintf("started rpop query for key: %s", key))
+
+	element, found, err := e.pop(key, func(list []string) (string, []string) {
+		return list[len(list)-1], list[:len(list)-1]
+	})
+
+	e.Logger.Debug("rpop query is done")
+
+	return element, found, err
+}
+
+func (e *InMemoryEngine) LRANGE(key string, start int, stop int) ([]string, error) {
+	e.Logger.Debug(fmt.Sprintf("started lrange query for key: %s; start: %d; stop: %d", key, start, stop))
+
+	elements := make([]string, 0)
+
+	err := e.partitions[e.makeTxId(key)].readTyped(key, listType, func(value *typedValue) {
+		from, to := listBounds(len(value.list), start, stop)
+
+		if from <= to {
+			elements = append(elements, value.list[from:to+1]...)
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.Logger.Debug("lrange query is done")
+
+	return elements, nil
+}
+
+func (e *InMemoryEngine) push(key string, elements []string, commit func() func() error, action func(list []string) []string) (int, error) {
+	var length int
+
+	needed := 0
+
+	for _, element := range elements {
+		needed += len(element)
+	}
+
+	err := e.partitions[e.makeTxId(key)].modifyTyped(key, listType, needed, true, commit, func(value *typedValue) error {
+		value.list = action(value.list)
+		length = len(value.list)
+
+		return nil
+	})
+
+	return length, err
+}
+
+func (e *InMemoryEngine) pop(key string, action func(list []string) (string, []string)) (string, bool, error) {
+	var element string
+	var found bool
+
+	err := e.partitions[e.makeTxId(key)].modifyTyped(key, listType, 0, false, nil, func(value *typedValue) error {
+		element, value.list = action(value.list)
+		found = true
+
+		return nil
+	})
+
+	return element, found, err
+}
+
+func listBounds(length int, start int, stop int) (int, int) {
+	if start < 0 {
+		start += length
+	}
+
+	if stop < 0 {
+		stop += length
+	}
+
+	return max(start, 0), min(stop, length-1)
+}
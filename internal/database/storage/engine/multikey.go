@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+func (e *InMemoryEngine) MGET(keys []string) ([]string, []bool) {
+	e.Logger.Debug(fmt.Sprintf("started mget query for %d keys", len(keys)))
+
+	values := make([]string, len(keys))
+	found := make([]bool, len(keys))
+
+	for i, key := range keys {
+		values[i], found[i] = e.partitions[e.makeTxId(key)].get(key)
+	}
+
+	e.Logger.Debug("mget query is done")
+
+	return values, found
+}
+
+// MSET reserves the memory of every partition before the commit logs the keys,
+// the keys stay pending for the other commits until they are stored.
+func (e *InMemoryEngine) MSET(keys []string, values []string, commit func() func() error) error {
+	e.Logger.Debug(fmt.Sprintf("started mset query for %d keys", len(keys)))
+
+	groups := e.groupByPartition(keys)
+
+	for {
+		pending, wait, err := e.prepareMSET(groups, keys, values, commit)
+
+		if pending != nil {
+			<-pending
+			continue
+		}
+
+		if err == nil && wait != nil {
+			err = wait()
+
+			unlock := e.lockPartitions(groups)
+
+			if err == nil {
+				e.storeMSET(groups, keys, values)
+			}
+
+			e.releaseMSET(groups, keys)
+			unlock()
+		}
+
+		if err != nil {
+			e.Logger.Error(fmt.Sprintf("mset query failed: %s", err.Error()))
+			return err
+		}
+
+		e.Logger.Debug("mset query is done")
+
+		return nil
+	}
+}
+
+// prepareMSET returns the pending commit of a key to wait for or stores the keys at once
+// without a commit, otherwise the keys are marked pending until the wait.
+func (e *InMemoryEngine) prepareMSET(groups map[int][]int, keys []string, values []string,
+	commit func() func() error) (chan struct{}, func() error, error) {
+	unlock := e.lockPartitions(groups)
+	defer unlock()
+
+	for id, indexes := range groups {
+		for _, i := range indexes {
+			if pending := e.partitions[id].pending[keys[i]]; pending != nil {
+				return pending, nil, nil
+			}
+		}
+	}
+
+	for id, indexes := range groups {
+		err := e.partitions[id].reserve(pick(keys, indexes), pick(values, indexes))
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var wait func() error
+
+	if commit != nil {
+		wait = commit()
+	}
+
+	if wait == nil {
+		e.storeMSET(groups, keys, values)
+		return nil, nil, nil
+	}
+
+	pending := make(chan struct{})
+
+	for id, indexes := range groups {
+		for _, i := range indexes {
+			e.partitions[id].pending[keys[i]] = pending
+		}
+	}
+
+	return nil, wait, nil
+}
+
+func (e *InMemoryEngine) storeMSET(groups map[int][]int, keys []string, values []string) {
+	for id, indexes := range groups {
+		partition := e.partitions[id]
+
+		for _, i := range indexes {
+			partition.store(keys[i], values[i])
+			delete(partition.expires, keys[i])
+		}
+	}
+}
+
+func (e *InMemoryEngine) releaseMSET(groups map[int][]int, keys []string) {
+	var pending chan struct{}
+
+	for id, indexes := range groups {
+		for _, i := range indexes {
+			if pending == nil {
+				pending = e.partitions[id].pending[keys[i]]
+			}
+
+			delete(e.partitions[id].pending, keys[i])
+		}
+	}
+
+	if pending != nil {
+		close(pending)
+	}
+}
+
+func (e *InMemoryEngine) MDEL(keys []string) int {
+	e.Logger.Debug(fmt.Sprintf("started mdel query for %d keys", len(keys)))
+
+	groups := e.groupByPartition(keys)
+
+	unlock := e.lockPartitions(groups)
+	defer unlock()
+
+	now := time.Now()
+	deleted := 0
+
+	for id, indexes := range groups {
+		partition := e.partitions[id]
+
+		for _, i := range indexes {
+			if partition.exists(keys[i], now) {
+				deleted++
+			}
+
+			partition.remove(keys[i])
+		}
+	}
+
+	e.Logger.Debug("mdel query is done")
+
+	return deleted
+}
+
+func (e *InMemoryEngine) groupByPartition(keys []string) map[int][]int {
+	groups := make(map[int][]int)
+
+	for i, key := range keys {
+		id := e.makeTxId(key)
+		groups[id] = append(groups[id], i)
+	}
+
+	return groups
+}
+
+func (e *InMemoryEngine) lockPartitions(groups map[int][]int) func() {
+	ids := make([]int, 0, len(groups))
+
+	for id := range groups {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		e.partitions[id].mutex.Lock()
+	}
+
+	return func() {
+		for i := len(ids) - 1; i >= 0; i-- {
+			e.partitions[ids[i]].mutex.Unlock()
+		}
+	}
+}
+
+func pick(elements []string, indexes []int) []string {
+	picked := make([]string, len(indexes))
+
+	for i, index := range indexes {
+		picked[i] = elements[index]
+	}
+
+	return picked
+}
@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_MultiKeyEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithPartitions(4, 10))
+
+	err := engine.MSET([]string{"biba", "boba", "aboba"}, []string{"1", "2", "3"}, nil)
+
+	assert.NoError(t, err)
+
+	values, found := engine.MGET([]string{"biba", "missing", "aboba"})
+
+	assert.Equal(t, []string{"1", "", "3"}, values)
+	assert.Equal(t, []bool{true, false, true}, found)
+
+	assert.Equal(t, 2, engine.MDEL([]string{"biba", "boba", "missing"}))
+
+	values, found = engine.MGET([]string{"biba", "boba", "aboba"})
+
+	assert.Equal(t, []string{"", "", "3"}, values)
+	assert.Equal(t, []bool{false, false, true}, found)
+}
+
+func Test_MultiKeyEngineAtomicSet(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithPartitions(4, 10), WithMaxMemory(4*(entryOverhead+8), NoEviction))
+
+	err := engine.MSET([]string{"a", "b", "c", "d", "e", "f", "g", "h"}, []string{"1", "2", "3", "4", "5", "6", "7", "8"}, nil)
+
+	assert.Equal(t, errOutOfMemory, err)
+	assert.Equal(t, 0, engine.MemoryUsage())
+
+	_, found := engine.MGET([]string{"a", "b", "c", "d", "e", "f", "g", "h"})
+
+	assert.NotContains(t, found, true)
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+type OrderedEngine struct {
+	*InMemoryEngine
+}
+
+func NewOrderedEngine(logger *zap.Logger, options ...EngineOption) (*OrderedEngine, error) {
+	engine, err := NewInMemoryEngine(logger, options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, partition := range engine.partitions {
+		partition.enableIndex()
+	}
+
+	return &OrderedEngine{InMemoryEngine: engine}, nil
+}
+
+func (e *OrderedEngine) RANGE(start, end string, limit int) ([]string, []string) {
+	e.Logger.Debug(fmt.Sprintf("started range query from key: %s; to key: %s", start, end))
+
+	keys, values := e.collect(limit, func(partition *hashTable) ([]string, []string) {
+		return partition.rangeKeys(start, end, limit)
+	})
+
+	e.Logger.Debug("range query is done")
+
+	return keys, values
+}
+
+func (e *OrderedEngine) PREFIX(prefix string, limit int) ([]string, []string) {
+	e.Logger.Debug(fmt.Sprintf("started prefix query for prefix: %s", prefix))
+
+	keys, values := e.collect(limit, func(partition *hashTable) ([]string, []string) {
+		return partition.prefixKeys(prefix, limit)
+	})
+
+	e.Logger.Debug("prefix query is done")
+
+	return keys, values
+}
+
+func (e *OrderedEngine) collect(limit int, query func(partition *hashTable) ([]string, []string)) ([]string, []string) {
+	partitionKeys := make([][]string, len(e.partitions))
+	partitionValues := make([][]string, len(e.partitions))
+
+	for i, partition := range e.partitions {
+		partitionKeys[i], partitionValues[i] = query(partition)
+	}
+
+	return mergeSorted(partitionKeys, partitionValues, limit)
+}
+
+func mergeSorted(partitionKeys [][]string, partitionValues [][]string, limit int) ([]string, []string) {
+	keys := make([]string, 0)
+	values := make([]string, 0)
+
+	positions := make([]int, len(partitionKeys))
+
+	for limit == 0 || len(keys) < limit {
+		smallest := -1
+
+		for i, position := range positions {
+			if position == len(partitionKeys[i]) {
+				continue
+			}
+
+			if smallest == -1 || partitionKeys[i][position] < partitionKeys[smallest][positions[smallest]] {
+				smallest = i
+			}
+		}
+
+		if smallest == -1 {
+			break
+		}
+
+		keys = append(keys, partitionKeys[smallest][positions[smallest]])
+		values = append(values, partitionValues[smallest][positions[smallest]])
+		positions[smallest]++
+	}
+
+	return keys, values
+}
@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_NewOrderedEngine(t *testing.T) {
+
+	engine, err := NewOrderedEngine(zap.NewNop(), WithPartitions(4, 10))
+
+	assert.NoError(t, err)
+
+	for _, partition := range engine.partitions {
+		assert.NotNil(t, partition.index)
+	}
+
+	engine, err = NewOrderedEngine(nil)
+
+	assert.Nil(t, engine)
+	assert.Equal(t, errors.New("engine without logger"), err)
+}
+
+func Test_RangeEngine(t *testing.T) {
+
+	engine, _ := NewOrderedEngine(zap.NewNop(), WithPartitions(4, 10))
+
+	for _, key := range []string{"user:3", "user:1", "order:1", "user:2", "user:4", "zebra"} {
+		engine.SET(key, "v"+key, nil)
+	}
+
+	engine.SETEX("user:25", "expired", time.Now().Add(-time.Second), nil)
+	engine.DEL("user:4")
+
+	tests := map[string]struct {
+		start string
+		end   string
+		limit int
+
+		expectedKeys   []string
+		expectedValues []string
+	}{
+		"range without limit": {
+			start: "user:1",
+			end:   "user:9",
+
+			expectedKeys:   []string{"user:1", "user:2", "user:3"},
+			expectedValues: []string{"vuser:1", "vuser:2", "vuser:3"},
+		},
+
+		"range with limit": {
+			start: "a",
+			end:   "z",
+			limit: 2,
+
+			expectedKeys:   []string{"order:1", "user:1"},
+			expectedValues: []string{"vorder:1", "vuser:1"},
+		},
+
+		"empty range": {
+			start: "b",
+			end:   "c",
+
+			expectedKeys:   []string{},
+			expectedValues: []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			keys, values := engine.RANGE(test.start, test.end, test.limit)
+
+			assert.Equal(t, test.expectedKeys, keys)
+			assert.Equal(t, test.expectedValues, values)
+		})
+	}
+}
+
+func Test_PrefixEngine(t *testing.T) {
+
+	engine, _ := NewOrderedEngine(zap.NewNop(), WithPartitions(3, 10))
+
+	for _, key := range []string{"user:3", "user:1", "order:1", "user:2", "users"} {
+		engine.SET(key, "v"+key, nil)
+	}
+
+	keys, values := engine.PREFIX("user:", 0)
+
+	assert.Equal(t, []string{"user:1", "user:2", "user:3"}, keys)
+	assert.Equal(t, []string{"vuser:1", "vuser:2", "vuser:3"}, values)
+
+	keys, _ = engine.PREFIX("user", 2)
+
+	assert.Equal(t, []string{"user:1", "user:2"}, keys)
+
+	keys, _ = engine.PREFIX("admin", 0)
+
+	assert.Empty(t, keys)
+}
+
+func Test_mergeSorted(t *testing.T) {
+	keys, values := mergeSorted(
+		[][]string{{"a", "d"}, {}, {"b", "c", "e"}},
+		[][]string{{"1", "4"}, {}, {"2", "3", "5"}},
+		4,
+	)
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, keys)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, values)
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"inmemorykvdb/pkg/concurrency"
+	"time"
+)
+
+const (
+	positionBits = 32
+	positionMask = 1<<positionBits - 1
+)
+
+type scanSlot struct {
+	key  string
+	used bool
+}
+
+func (e *InMemoryEngine) SCAN(cursor uint64, match string, count int) (uint64, []string, error) {
+	e.Logger.Debug(fmt.Sprintf("started scan query from cursor: %d", cursor))
+
+	partition, position := decodeCursor(cursor)
+
+	if partition >= len(e.partitions) {
+		e.Logger.Error("scan query has incorrect cursor")
+		return 0, nil, errors.New("incorrect cursor")
+	}
+
+	keys := make([]string, 0)
+	examined := 0
+
+	for partition < len(e.partitions) && examined < count {
+		found, next, done := e.partitions[partition].scan(position, count-examined, match)
+
+		keys = append(keys, found...)
+		examined += next - position
+
+		if !done {
+			position = next
+			break
+		}
+
+		partition++
+		position = 0
+	}
+
+	e.Logger.Debug("scan query is done")
+
+	if partition == len(e.partitions) {
+		return 0, keys, nil
+	}
+
+	return encodeCursor(partition, position), keys, nil
+}
+
+func encodeCursor(partition, position int) uint64 {
+	return uint64(partition)<<positionBits | uint64(position)
+}
+
+func decodeCursor(cursor uint64) (int, int) {
+	return int(cursor >> positionBits), int(cursor & positionMask)
+}
+
+func (h *hashTable) scan(position int, count int, match string) ([]string, int, bool) {
+	keys := make([]string, 0)
+
+	concurrency.WithRLock(h.mutex, func() {
+		now := time.Now()
+
+		for ; position < len(h.slots) && count != 0; position++ {
+			count--
+
+			slot := h.slots[position]
+
+			if !slot.used || h.isExpired(slot.key, now) {
+				continue
+			}
+
+			if match == "" || matchGlob(match, slot.key) {
+				keys = append(keys, slot.key)
+			}
+		}
+	})
+
+	return keys, position, position >= len(h.slots)
+}
+
+func (h *hashTable) takeSlot(key string) {
+	if len(h.freeSlots) == 0 {
+		h.slotOf[key] = len(h.slots)
+		h.slots = append(h.slots, scanSlot{key: key, used: true})
+		return
+	}
+
+	position := h.freeSlots[len(h.freeSlots)-1]
+	h.freeSlots = h.freeSlots[:len(h.freeSlots)-1]
+
+	h.slotOf[key] = position
+	h.slots[position] = scanSlot{key: key, used: true}
+}
+
+func (h *hashTable) releaseSlot(key string) {
+	position, found := h.slotOf[key]
+
+	if !found {
+		return
+	}
+
+	delete(h.slotOf, key)
+	h.slots[position] = scanSlot{}
+	h.freeSlots = append(h.freeSlots, position)
+}
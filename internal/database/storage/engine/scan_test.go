@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func scanAll(engine *InMemoryEngine, match string, count int) []string {
+	keys := make([]string, 0)
+	cursor := uint64(0)
+
+	for {
+		next, found, _ := engine.SCAN(cursor, match, count)
+		keys = append(keys, found...)
+
+		if next == 0 {
+			return keys
+		}
+
+		cursor = next
+	}
+}
+
+func Test_ScanEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithPartitions(4, 10))
+
+	expected := make([]string, 0)
+
+	for i := range 50 {
+		key := fmt.Sprintf("user:%d", i)
+		engine.SET(key, "value", nil)
+		expected = append(expected, key)
+	}
+
+	engine.SET("order:1", "value", nil)
+
+	assert.ElementsMatch(t, expected, scanAll(engine, "user:*", 7))
+	assert.ElementsMatch(t, append(expected, "order:1"), scanAll(engine, "", 100))
+}
+
+func Test_ScanEngineIncorrectCursor(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithPartitions(2, 10))
+
+	_, keys, err := engine.SCAN(encodeCursor(2, 0), "", 10)
+
+	assert.Nil(t, keys)
+	assert.Equal(t, errors.New("incorrect cursor"), err)
+}
+
+func Test_ScanEngineWithConcurrentWrites(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop(), WithPartitions(4, 10))
+
+	stable := make([]string, 0)
+
+	for i := range 200 {
+		key := fmt.Sprintf("stable:%d", i)
+		engine.SET(key, "value", nil)
+		stable = append(stable, key)
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			key := fmt.Sprintf("volatile:%d", i%50)
+			engine.SET(key, "value", nil)
+			engine.DEL(fmt.Sprintf("volatile:%d", (i+25)%50))
+		}
+	}()
+
+	keys := scanAll(engine, "stable:*", 3)
+
+	close(stop)
+	wg.Wait()
+
+	assert.Subset(t, keys, stable)
+}
+
+func Test_scanSlots(t *testing.T) {
+	ht := NewHashTable(10)
+
+	ht.set("key1", "val1", nil)
+	ht.set("key2", "val2", nil)
+	ht.set("key3", "val3", nil)
+
+	ht.del("key2")
+
+	assert.Equal(t, []int{1}, ht.freeSlots)
+
+	ht.set("key4", "val4", nil)
+
+	assert.Equal(t, 1, ht.slotOf["key4"])
+	assert.Empty(t, ht.freeSlots)
+
+	keys, next, done := ht.scan(0, 2, "")
+
+	assert.Equal(t, []string{"key1", "key4"}, keys)
+	assert.Equal(t, 2, next)
+	assert.False(t, done)
+
+	keys, next, done = ht.scan(next, 2, "")
+
+	assert.Equal(t, []string{"key3"}, keys)
+	assert.Equal(t, 3, next)
+	assert.True(t, done)
+}
+
+func Test_cursor(t *testing.T) {
+	partition, position := decodeCursor(encodeCursor(3, 12345))
+
+	assert.Equal(t, 3, partition)
+	assert.Equal(t, 12345, position)
+	assert.Equal(t, uint64(0), encodeCursor(0, 0))
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+)
+
+func (e *InMemoryEngine) SADD(key string, members []string, commit func() func() error) (int, error) {
+	e.Logger.Debug(fmt.Sprintf("started sadd query for key: %s", key))
+
+	var added int
+
+	needed := 0
+
+	for _, member := range members {
+		needed += len(member)
+	}
+
+	err := e.partitions[e.makeTxId(key)].modifyTyped(key, setType, needed, true, commit, func(value *typedValue) error {
+		for _, member := range members {
+			if _, found := value.set[member]; !found {
+				value.set[member] = struct{}{}
+				added++
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("sadd query for key %s failed: %s", key, err.Error()))
+		return 0, err
+	}
+
+	e.Logger.Debug("sadd query is done")
+
+	return added, nil
+}
+
+func (e *InMemoryEngine) SREM(key string, members []string) (int, error) {
+	e.Logger.Debug(fmt.Sprintf("started srem query for key: %s", key))
+
+	var removed int
+
+	err := e.partitions[e.makeTxId(key)].modifyTyped(key, setType, 0, false, nil, func(value *typedValue) error {
+		for _, member := range members {
+			if _, found := value.set[member]; found {
+				delete(value.set, member)
+				removed++
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	e.Logger.Debug("srem query is done")
+
+	return removed, nil
+}
+
+func (e *InMemoryEngine) SMEMBERS(key string) ([]string, error) {
+	e.Logger.Debug(fmt.Sprintf("started smembers query for key: %s", key))
+
+	members := make([]string, 0)
+
+	err := e.partitions[e.makeTxId(key)].readTyped(key, setType, func(value *typedValue) {
+		for member := range value.set {
+			members = append(members, member)
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(members)
+
+	e.Logger.Debug("smembers query is done")
+
+	return members, nil
+}
+
+func (e *InMemoryEngine) SISMEMBER(key string, member string) (bool, error) {
+	e.Logger.Debug(fmt.Sprintf("started sismember query for key: %s; member: %s", key, member))
+
+	var found bool
+
+	err := e.partitions[e.makeTxId(key)].readTyped(key, setType, func(value *typedValue) {
+		_, found = value.set[member]
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	e.Logger.Debug("sismember query is done")
+
+	return found, nil
+}
@@ -0,0 +1,103 @@
+package engine
+
+import "math/rand/v2"
+
+const (
+	maxSkipListLevel  = 16
+	skipListLevelStep = 4
+)
+
+type skipListNode struct {
+	key  string
+	next []*skipListNode
+}
+
+type skipList struct {
+	head   *skipListNode
+	level  int
+	length int
+}
+
+func newSkipList() *skipList {
+	return &skipList{head: &skipListNode{next: make([]*skipListNode, maxSkipListLevel)}, level: 1}
+}
+
+func (s *skipList) insert(key string) {
+	update := s.findPredecessors(key)
+
+	if next := update[0].next[0]; next != nil && next.key == key {
+		return
+	}
+
+	level := randomLevel()
+
+	if level > s.level {
+		for i := s.level; i < level; i++ {
+			update[i] = s.head
+		}
+
+		s.level = level
+	}
+
+	node := &skipListNode{key: key, next: make([]*skipListNode, level)}
+
+	for i := range level {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+
+	s.length++
+}
+
+func (s *skipList) delete(key string) {
+	update := s.findPredecessors(key)
+
+	node := update[0].next[0]
+
+	if node == nil || node.key != key {
+		return
+	}
+
+	for i := range len(node.next) {
+		update[i].next[i] = node.next[i]
+	}
+
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+
+	s.length--
+}
+
+func (s *skipList) ascend(from string, action func(key string) bool) {
+	for node := s.findPredecessors(from)[0].next[0]; node != nil; node = node.next[0] {
+		if !action(node.key) {
+			return
+		}
+	}
+}
+
+func (s *skipList) findPredecessors(key string) []*skipListNode {
+	update := make([]*skipListNode, maxSkipListLevel)
+	node := s.head
+
+	for i := s.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && node.next[i].key < key {
+			node = node.next[i]
+		}
+
+		update[i] = node
+	}
+
+	return update
+}
+
+func randomLevel() int {
+	level := 1
+
+	for level < maxSkipListLevel && rand.IntN(skipListLevelStep) == 0 {
+		level++
+	}
+
+	return level
+}
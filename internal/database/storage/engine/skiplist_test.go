@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_skipList(t *testing.T) {
+	list := newSkipList()
+
+	for _, key := range []string{"d", "b", "a", "c", "e", "b"} {
+		list.insert(key)
+	}
+
+	assert.Equal(t, 5, list.length)
+
+	list.delete("c")
+	list.delete("z")
+
+	assert.Equal(t, 4, list.length)
+
+	tests := map[string]struct {
+		from string
+		stop string
+
+		expectedKeys []string
+	}{
+		"ascend from start": {
+			from: "",
+
+			expectedKeys: []string{"a", "b", "d", "e"},
+		},
+
+		"ascend from missing key": {
+			from: "c",
+
+			expectedKeys: []string{"d", "e"},
+		},
+
+		"ascend with stop": {
+			from: "b",
+			stop: "d",
+
+			expectedKeys: []string{"b", "d"},
+		},
+
+		"ascend after last key": {
+			from: "f",
+
+			expectedKeys: []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			keys := make([]string, 0)
+
+			list.ascend(test.from, func(key string) bool {
+				keys = append(keys, key)
+				return key != test.stop
+			})
+
+			assert.Equal(t, test.expectedKeys, keys)
+		})
+	}
+}
+
+func Test_skipListOrder(t *testing.T) {
+	list := newSkipList()
+
+	for i := 999; i >= 0; i-- {
+		list.insert(string(rune('a'+i%26)) + string(rune('a'+i/26%26)))
+	}
+
+	var previous string
+
+	list.ascend("", func(key string) bool {
+		assert.Less(t, previous, key)
+		previous = key
+		return true
+	})
+}
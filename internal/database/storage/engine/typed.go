@@ -0,0 +1,214 @@
+package engine
+
+import (
+	"errors"
+	"inmemorykvdb/pkg/concurrency"
+	"time"
+)
+
+const (
+	noneType   = "none"
+	stringType = "string"
+	hashType   = "hash"
+	listType   = "list"
+	setType    = "set"
+	zsetType   = "zset"
+
+	scoreSize = 8
+)
+
+var errWrongType = errors.New("WRONGTYPE operation against a key holding the wrong kind of value")
+
+type typedValue struct {
+	kind string
+
+	hash map[string]string
+	list []string
+	set  map[string]struct{}
+	zset *sortedSet
+}
+
+type sortedSet struct {
+	scores map[string]float64
+	order  *zsetList
+}
+
+func newTypedValue(kind string) *typedValue {
+	value := &typedValue{kind: kind}
+
+	switch kind {
+	case hashType:
+		value.hash = make(map[string]string)
+	case setType:
+		value.set = make(map[string]struct{})
+	case zsetType:
+		value.zset = &sortedSet{scores: make(map[string]float64), order: newZsetList()}
+	}
+
+	return value
+}
+
+func (v *typedValue) size() int {
+	var size int
+
+	switch v.kind {
+	case hashType:
+		for field, value := range v.hash {
+			size += len(field) + len(value)
+		}
+	case listType:
+		for _, element := range v.list {
+			size += len(element)
+		}
+	case setType:
+		for member := range v.set {
+			size += len(member)
+		}
+	case zsetType:
+		for member := range v.zset.scores {
+			size += len(member) + scoreSize
+		}
+	}
+
+	return size
+}
+
+func (v *typedValue) empty() bool {
+	return len(v.hash) == 0 && len(v.list) == 0 && len(v.set) == 0 && (v.zset == nil || len(v.zset.scores) == 0)
+}
+
+// modifyTyped reserves the memory before the commit logs the change, the action runs once it is logged.
+func (h *hashTable) modifyTyped(key, kind string, needed int, create bool, commit func() func() error,
+	action func(value *typedValue) error) error {
+	var actionErr error
+
+	err := h.commit(key, func() (func(), func() error, error) {
+		proceed, err := h.reserveTyped(key, kind, needed, create)
+
+		if err != nil || !proceed {
+			return nil, nil, err
+		}
+
+		var wait func() error
+
+		if commit != nil {
+			wait = commit()
+		}
+
+		return func() {
+			actionErr = h.updateTyped(key, kind, action)
+		}, wait, nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return actionErr
+}
+
+// reserveTyped reports whether the key holds a value of the kind or could be created with it.
+func (h *hashTable) reserveTyped(key, kind string, needed int, create bool) (bool, error) {
+	if h.isExpired(key, time.Now()) {
+		h.remove(key)
+	}
+
+	if _, isString := h.pairs[key]; isString {
+		return false, errWrongType
+	}
+
+	value, found := h.typed[key]
+
+	if found && value.kind != kind {
+		return false, errWrongType
+	}
+
+	if !found && !create {
+		return false, nil
+	}
+
+	if !found {
+		needed += len(key) + entryOverhead
+	}
+
+	return true, h.reserveBytes(needed, []string{key})
+}
+
+func (h *hashTable) updateTyped(key, kind string, action func(value *typedValue) error) error {
+	value, found := h.typed[key]
+
+	if !found {
+		value = newTypedValue(kind)
+		h.typed[key] = value
+		h.usedMemory += len(key) + entryOverhead
+		h.takeSlot(key)
+
+		if h.index != nil {
+			h.index.insert(key)
+		}
+	}
+
+	oldSize := value.size()
+
+	err := action(value)
+
+	h.usedMemory += value.size() - oldSize
+
+	if value.empty() {
+		h.remove(key)
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	h.bump(key)
+	h.track(key)
+
+	return nil
+}
+
+func (h *hashTable) readTyped(key, kind string, action func(value *typedValue)) error {
+	var err error
+
+	concurrency.WithRLock(h.mutex, func() {
+		if !h.exists(key, time.Now()) {
+			return
+		}
+
+		value, found := h.typed[key]
+
+		if !found || value.kind != kind {
+			err = errWrongType
+			return
+		}
+
+		action(value)
+		h.touch(key)
+	})
+
+	return err
+}
+
+func (h *hashTable) kind(key string) string {
+	kind := noneType
+
+	concurrency.WithRLock(h.mutex, func() {
+		if !h.exists(key, time.Now()) {
+			return
+		}
+
+		kind = stringType
+
+		if value, found := h.typed[key]; found {
+			kind = value.kind
+		}
+	})
+
+	return kind
+}
+
+func (e *InMemoryEngine) TYPE(key string) string {
+	return e.partitions[e.makeTxId(key)].kind(key)
+}
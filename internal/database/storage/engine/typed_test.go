@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_HashEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	added, err := engine.HSET("user", []string{"name", "age"}, []string{"biba", "20"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	added, _ = engine.HSET("user", []string{"age"}, []string{"21"}, nil)
+
+	assert.Equal(t, 0, added)
+
+	value, found, err := engine.HGET("user", "age")
+
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "21", value)
+
+	fields, values, _ := engine.HGETALL("user")
+
+	assert.Equal(t, []string{"age", "name"}, fields)
+	assert.Equal(t, []string{"21", "biba"}, values)
+
+	deleted, _ := engine.HDEL("user", []string{"age", "name", "missing"})
+
+	assert.Equal(t, 2, deleted)
+	assert.Equal(t, noneType, engine.TYPE("user"))
+}
+
+func Test_ListEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	length, err := engine.RPUSH("queue", []string{"b", "c"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, length)
+
+	length, _ = engine.LPUSH("queue", []string{"a", "z"}, nil)
+
+	assert.Equal(t, 4, length)
+
+	elements, _ := engine.LRANGE("queue", 0, -1)
+
+	assert.Equal(t, []string{"z", "a", "b", "c"}, elements)
+
+	elements, _ = engine.LRANGE("queue", -2, 10)
+
+	assert.Equal(t, []string{"b", "c"}, elements)
+
+	element, found, _ := engine.LPOP("queue")
+
+	assert.True(t, found)
+	assert.Equal(t, "z", element)
+
+	element, _, _ = engine.RPOP("queue")
+
+	assert.Equal(t, "c", element)
+
+	engine.LPOP("queue")
+	engine.LPOP("queue")
+
+	_, found, err = engine.LPOP("queue")
+
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, noneType, engine.TYPE("queue"))
+}
+
+func Test_SetTypeEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	added, err := engine.SADD("tags", []string{"b", "a", "b"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	members, _ := engine.SMEMBERS("tags")
+
+	assert.Equal(t, []string{"a", "b"}, members)
+
+	isMember, _ := engine.SISMEMBER("tags", "a")
+
+	assert.True(t, isMember)
+
+	removed, _ := engine.SREM("tags", []string{"a", "c"})
+
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, setType, engine.TYPE("tags"))
+}
+
+func Test_WrongTypeEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	engine.SET("biba", "boba", nil)
+	engine.SADD("tags", []string{"a"}, nil)
+
+	_, err := engine.HSET("biba", []string{"f"}, []string{"v"}, nil)
+	assert.Equal(t, errWrongType, err)
+
+	_, err = engine.LPUSH("tags", []string{"a"}, nil)
+	assert.Equal(t, errWrongType, err)
+
+	_, err = engine.SMEMBERS("biba")
+	assert.Equal(t, errWrongType, err)
+
+	_, err = engine.INCRBY("tags", 1, nil)
+	assert.Equal(t, errWrongType, err)
+
+	_, found := engine.GET("tags")
+	assert.False(t, found)
+
+	engine.SET("tags", "boba", nil)
+
+	assert.Equal(t, stringType, engine.TYPE("tags"))
+}
+
+func Test_TypedEngineMemoryAndExpiration(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	engine.RPUSH("queue", []string{"ab", "cd"}, nil)
+
+	assert.Equal(t, len("queue")+4+entryOverhead, engine.MemoryUsage())
+
+	engine.LPOP("queue")
+
+	assert.Equal(t, len("queue")+2+entryOverhead, engine.MemoryUsage())
+
+	engine.EXPIREAT("queue", time.Now().Add(-time.Second))
+
+	assert.Equal(t, noneType, engine.TYPE("queue"))
+
+	elements, err := engine.LRANGE("queue", 0, -1)
+
+	assert.NoError(t, err)
+	assert.Empty(t, elements)
+
+	engine.DEL("queue")
+
+	assert.Equal(t, 0, engine.MemoryUsage())
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"fmt"
+	"inmemorykvdb/pkg/concurrency"
+	"time"
+)
+
+func (e *InMemoryEngine) VERSION(key string) (uint64, bool) {
+	e.Logger.Debug(fmt.Sprintf("started version query for key: %s", key))
+
+	version, found := e.partitions[e.makeTxId(key)].keyVersion(key)
+
+	e.Logger.Debug("version query is done")
+
+	return version, found
+}
+
+func (h *hashTable) keyVersion(key string) (uint64, bool) {
+	var version uint64
+	var found bool
+
+	concurrency.WithRLock(h.mutex, func() {
+		found = h.exists(key, time.Now())
+
+		if found {
+			version = h.versions[key]
+			return
+		}
+
+		version = h.removed
+	})
+
+	return version, found
+}
+
+func (h *hashTable) bump(key string) {
+	h.version++
+	h.versions[key] = h.version
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_VersionEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	_, found := engine.VERSION("biba")
+
+	assert.False(t, found)
+
+	engine.SET("biba", "boba", nil)
+
+	first, found := engine.VERSION("biba")
+
+	assert.True(t, found)
+
+	engine.SET("biba", "boba", nil)
+
+	second, _ := engine.VERSION("biba")
+
+	assert.Greater(t, second, first)
+
+	engine.EXPIREAT("biba", time.Now().Add(time.Minute))
+
+	third, _ := engine.VERSION("biba")
+
+	assert.Greater(t, third, second)
+
+	engine.DEL("biba")
+	engine.SET("biba", "boba", nil)
+
+	fourth, _ := engine.VERSION("biba")
+
+	assert.Greater(t, fourth, third)
+}
+
+func Test_VersionEngineOfDeletedKey(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	missing, found := engine.VERSION("biba")
+
+	assert.False(t, found)
+
+	engine.SET("biba", "boba", nil)
+	engine.DEL("biba")
+
+	deleted, found := engine.VERSION("biba")
+
+	assert.False(t, found)
+	assert.Greater(t, deleted, missing)
+
+	engine.DEL("biba")
+
+	again, _ := engine.VERSION("biba")
+
+	assert.Equal(t, deleted, again)
+}
+
+func Test_VersionEngineIfVersion(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	engine.SET("biba", "boba", nil)
+
+	version, _ := engine.VERSION("biba")
+
+	ifVersion := func(_ string, found bool, current uint64) bool {
+		return found && current == version
+	}
+
+	applied, _ := engine.SETIF("biba", "aboba", time.Time{}, ifVersion, nil)
+
+	assert.True(t, applied)
+
+	applied, _ = engine.SETIF("biba", "biba", time.Time{}, ifVersion, nil)
+
+	assert.False(t, applied)
+}
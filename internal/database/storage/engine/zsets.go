@@ -0,0 +1,255 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var errNotScore = errors.New("resulting score is not a number")
+
+func (e *InMemoryEngine) ZADD(key string, scores []float64, members []string, commit func() func() error) (int, error) {
+	e.Logger.Debug(fmt.Sprintf("started zadd query for key: %s", key))
+
+	var added int
+
+	needed := 0
+
+	for _, member := range members {
+		needed += len(member) + scoreSize
+	}
+
+	err := e.partitions[e.makeTxId(key)].modifyTyped(key, zsetType, needed, true, commit, func(value *typedValue) error {
+		for i, member := range members {
+			if _, found := value.zset.scores[member]; !found {
+				added++
+			}
+
+			value.zset.add(member, scores[i])
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("zadd query for key %s failed: %s", key, err.Error()))
+		return 0, err
+	}
+
+	e.Logger.Debug("zadd query is done")
+
+	return added, nil
+}
+
+func (e *InMemoryEngine) ZREM(key string, members []string) (int, error) {
+	e.Logger.Debug(fmt.Sprintf("started zrem query for key: %s", key))
+
+	var removed int
+
+	err := e.partitions[e.makeTxId(key)].modifyTyped(key, zsetType, 0, false, nil, func(value *typedValue) error {
+		for _, member := range members {
+			if value.zset.remove(member) {
+				removed++
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	e.Logger.Debug("zrem query is done")
+
+	return removed, nil
+}
+
+func (e *InMemoryEngine) ZINCRBY(key string, delta float64, member string, commit func(score float64) func() error) (float64, error) {
+	e.Logger.Debug(fmt.Sprintf("started zincrby query for key: %s; member: %s; delta: %g", key, member, delta))
+
+	score, err := e.partitions[e.makeTxId(key)].incrScore(key, member, delta, commit)
+
+	if err != nil {
+		e.Logger.Error(fmt.Sprintf("zincrby query for key %s failed: %s", key, err.Error()))
+		return 0, err
+	}
+
+	e.Logger.Debug("zincrby query is done")
+
+	return score, nil
+}
+
+func (e *InMemoryEngine) ZSCORE(key string, member string) (float64, bool, error) {
+	e.Logger.Debug(fmt.Sprintf("started zscore query for key: %s; member: %s", key, member))
+
+	var score float64
+	var found bool
+
+	err := e.partitions[e.makeTxId(key)].readTyped(key, zsetType, func(value *typedValue) {
+		score, found = value.zset.scores[member]
+	})
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	e.Logger.Debug("zscore query is done")
+
+	return score, found, nil
+}
+
+func (e *InMemoryEngine) ZRANK(key string, member string) (int, bool, error) {
+	e.Logger.Debug(fmt.Sprintf("started zrank query for key: %s; member: %s", key, member))
+
+	var rank int
+	var found bool
+
+	err := e.partitions[e.makeTxId(key)].readTyped(key, zsetType, func(value *typedValue) {
+		var score float64
+
+		score, found = value.zset.scores[member]
+
+		if found {
+			rank = value.zset.order.rank(member, score)
+		}
+	})
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	e.Logger.Debug("zrank query is done")
+
+	return rank, found, nil
+}
+
+func (e *InMemoryEngine) ZRANGE(key string, start int, stop int) ([]string, []float64, error) {
+	e.Logger.Debug(fmt.Sprintf("started zrange query for key: %s; start: %d; stop: %d", key, start, stop))
+
+	members := make([]string, 0)
+	scores := make([]float64, 0)
+
+	err := e.partitions[e.makeTxId(key)].readTyped(key, zsetType, func(value *typedValue) {
+		from, to := listBounds(value.zset.order.length, start, stop)
+
+		if from > to {
+			return
+		}
+
+		node := value.zset.order.byRank(from)
+
+		for rank := from; rank <= to && node != nil; rank++ {
+			members = append(members, node.member)
+			scores = append(scores, node.score)
+			node = node.levels[0].next
+		}
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e.Logger.Debug("zrange query is done")
+
+	return members, scores, nil
+}
+
+func (e *InMemoryEngine) ZRANGEBYSCORE(key string, min float64, max float64) ([]string, []float64, error) {
+	e.Logger.Debug(fmt.Sprintf("started zrangebyscore query for key: %s; min: %g; max: %g", key, min, max))
+
+	members := make([]string, 0)
+	scores := make([]float64, 0)
+
+	err := e.partitions[e.makeTxId(key)].readTyped(key, zsetType, func(value *typedValue) {
+		for node := value.zset.order.firstFrom(min); node != nil && node.score <= max; node = node.levels[0].next {
+			members = append(members, node.member)
+			scores = append(scores, node.score)
+		}
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e.Logger.Debug("zrangebyscore query is done")
+
+	return members, scores, nil
+}
+
+func (s *sortedSet) add(member string, score float64) {
+	if oldScore, found := s.scores[member]; found {
+		s.order.delete(member, oldScore)
+	}
+
+	s.scores[member] = score
+	s.order.insert(member, score)
+}
+
+func (s *sortedSet) remove(member string) bool {
+	score, found := s.scores[member]
+
+	if !found {
+		return false
+	}
+
+	delete(s.scores, member)
+	s.order.delete(member, score)
+
+	return true
+}
+
+func (h *hashTable) incrScore(key, member string, delta float64, commit func(score float64) func() error) (float64, error) {
+	var score float64
+
+	err := h.commit(key, func() (func(), func() error, error) {
+		if h.isExpired(key, time.Now()) {
+			h.remove(key)
+		}
+
+		if _, isString := h.pairs[key]; isString {
+			return nil, nil, errWrongType
+		}
+
+		value, found := h.typed[key]
+
+		if found && value.kind != zsetType {
+			return nil, nil, errWrongType
+		}
+
+		needed := len(member) + scoreSize
+		score = delta
+
+		if found {
+			score += value.zset.scores[member]
+		} else {
+			needed += len(key) + entryOverhead
+		}
+
+		if math.IsNaN(score) {
+			return nil, nil, errNotScore
+		}
+
+		err := h.reserveBytes(needed, []string{key})
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var wait func() error
+
+		if commit != nil {
+			wait = commit(score)
+		}
+
+		return func() {
+			h.updateTyped(key, zsetType, func(value *typedValue) error {
+				value.zset.add(member, score)
+				return nil
+			})
+		}, wait, nil
+	})
+
+	return score, err
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_SortedSetEngine(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	added, err := engine.ZADD("board", []float64{30, 10, 20}, []string{"carol", "alice", "bob"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, added)
+
+	added, _ = engine.ZADD("board", []float64{40}, []string{"alice"}, nil)
+
+	assert.Equal(t, 0, added)
+
+	members, scores, _ := engine.ZRANGE("board", 0, -1)
+
+	assert.Equal(t, []string{"bob", "carol", "alice"}, members)
+	assert.Equal(t, []float64{20, 30, 40}, scores)
+
+	rank, found, _ := engine.ZRANK("board", "alice")
+
+	assert.True(t, found)
+	assert.Equal(t, 2, rank)
+
+	score, _ := engine.ZINCRBY("board", -25, "alice", nil)
+
+	assert.Equal(t, float64(15), score)
+
+	members, _, _ = engine.ZRANGEBYSCORE("board", 15, 25)
+
+	assert.Equal(t, []string{"alice", "bob"}, members)
+
+	score, found, _ = engine.ZSCORE("board", "carol")
+
+	assert.True(t, found)
+	assert.Equal(t, float64(30), score)
+
+	removed, _ := engine.ZREM("board", []string{"carol", "dave"})
+
+	assert.Equal(t, 1, removed)
+
+	_, found, _ = engine.ZRANK("board", "carol")
+
+	assert.False(t, found)
+}
+
+func Test_SortedSetEngineWrongType(t *testing.T) {
+
+	engine, _ := NewInMemoryEngine(zap.NewNop())
+
+	engine.SADD("tags", []string{"a"}, nil)
+
+	_, err := engine.ZADD("tags", []float64{1}, []string{"a"}, nil)
+
+	assert.Equal(t, errWrongType, err)
+
+	_, err = engine.ZINCRBY("tags", 1, "a", nil)
+
+	assert.Equal(t, errWrongType, err)
+}
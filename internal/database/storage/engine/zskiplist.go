@@ -0,0 +1,158 @@
+package engine
+
+type zsetLevel struct {
+	next *zsetNode
+	span int
+}
+
+type zsetNode struct {
+	member string
+	score  float64
+	levels []zsetLevel
+}
+
+type zsetList struct {
+	head   *zsetNode
+	level  int
+	length int
+}
+
+func newZsetList() *zsetList {
+	return &zsetList{head: &zsetNode{levels: make([]zsetLevel, maxSkipListLevel)}, level: 1}
+}
+
+func (n *zsetNode) before(score float64, member string) bool {
+	return n.score < score || (n.score == score && n.member < member)
+}
+
+func (n *zsetNode) is(score float64, member string) bool {
+	return n.score == score && n.member == member
+}
+
+func (z *zsetList) insert(member string, score float64) {
+	update := make([]*zsetNode, maxSkipListLevel)
+	rank := make([]int, maxSkipListLevel)
+	node := z.head
+
+	for i := z.level - 1; i >= 0; i-- {
+		if i != z.level-1 {
+			rank[i] = rank[i+1]
+		}
+
+		for node.levels[i].next != nil && node.levels[i].next.before(score, member) {
+			rank[i] += node.levels[i].span
+			node = node.levels[i].next
+		}
+
+		update[i] = node
+	}
+
+	level := randomLevel()
+
+	if level > z.level {
+		for i := z.level; i < level; i++ {
+			update[i] = z.head
+			update[i].levels[i].span = z.length
+		}
+
+		z.level = level
+	}
+
+	inserted := &zsetNode{member: member, score: score, levels: make([]zsetLevel, level)}
+
+	for i := range level {
+		inserted.levels[i].next = update[i].levels[i].next
+		update[i].levels[i].next = inserted
+
+		inserted.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+
+	for i := level; i < z.level; i++ {
+		update[i].levels[i].span++
+	}
+
+	z.length++
+}
+
+func (z *zsetList) delete(member string, score float64) {
+	update := make([]*zsetNode, maxSkipListLevel)
+	node := z.head
+
+	for i := z.level - 1; i >= 0; i-- {
+		for node.levels[i].next != nil && node.levels[i].next.before(score, member) {
+			node = node.levels[i].next
+		}
+
+		update[i] = node
+	}
+
+	deleted := node.levels[0].next
+
+	if deleted == nil || !deleted.is(score, member) {
+		return
+	}
+
+	for i := range z.level {
+		if update[i].levels[i].next == deleted {
+			update[i].levels[i].span += deleted.levels[i].span - 1
+			update[i].levels[i].next = deleted.levels[i].next
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+
+	for z.level > 1 && z.head.levels[z.level-1].next == nil {
+		z.level--
+	}
+
+	z.length--
+}
+
+func (z *zsetList) rank(member string, score float64) int {
+	node := z.head
+	rank := 0
+
+	for i := z.level - 1; i >= 0; i-- {
+		for next := node.levels[i].next; next != nil && (next.before(score, member) || next.is(score, member)); next = node.levels[i].next {
+			rank += node.levels[i].span
+			node = next
+		}
+
+		if node != z.head && node.is(score, member) {
+			return rank - 1
+		}
+	}
+
+	return -1
+}
+
+func (z *zsetList) byRank(rank int) *zsetNode {
+	node := z.head
+	traversed := 0
+
+	for i := z.level - 1; i >= 0; i-- {
+		for node.levels[i].next != nil && traversed+node.levels[i].span <= rank+1 {
+			traversed += node.levels[i].span
+			node = node.levels[i].next
+		}
+
+		if traversed == rank+1 {
+			return node
+		}
+	}
+
+	return nil
+}
+
+func (z *zsetList) firstFrom(score float64) *zsetNode {
+	node := z.head
+
+	for i := z.level - 1; i >= 0; i-- {
+		for node.levels[i].next != nil && node.levels[i].next.score < score {
+			node = node.levels[i].next
+		}
+	}
+
+	return node.levels[0].next
+}
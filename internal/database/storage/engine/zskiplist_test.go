@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ZsetListOrderAndRank(t *testing.T) {
+
+	list := newZsetList()
+
+	type entry struct {
+		member string
+		score  float64
+	}
+
+	entries := make([]entry, 0)
+
+	for i := range 200 {
+		entries = append(entries, entry{member: fmt.Sprintf("m%03d", i), score: float64(rand.IntN(50))})
+	}
+
+	for _, e := range entries {
+		list.insert(e.member, e.score)
+	}
+
+	for _, e := range entries[:50] {
+		list.delete(e.member, e.score)
+	}
+
+	entries = entries[50:]
+
+	slices.SortFunc(entries, func(a, b entry) int {
+		if a.score != b.score {
+			if a.score < b.score {
+				return -1
+			}
+
+			return 1
+		}
+
+		if a.member < b.member {
+			return -1
+		}
+
+		return 1
+	})
+
+	assert.Equal(t, len(entries), list.length)
+
+	for rank, e := range entries {
+		assert.Equal(t, rank, list.rank(e.member, e.score))
+
+		node := list.byRank(rank)
+
+		assert.Equal(t, e.member, node.member)
+	}
+
+	assert.Equal(t, -1, list.rank("missing", 1))
+	assert.Nil(t, list.byRank(len(entries)))
+}
+
+func Test_ZsetListFirstFrom(t *testing.T) {
+
+	list := newZsetList()
+
+	list.insert("a", 1)
+	list.insert("b", 2)
+	list.insert("c", 3)
+
+	assert.Equal(t, "b", list.firstFrom(1.5).member)
+	assert.Equal(t, "a", list.firstFrom(-1).member)
+	assert.Nil(t, list.firstFrom(4))
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"strconv"
+	"time"
+)
+
+const (
+	keyIndex      = 0
+	valueIndex    = 1
+	ttlIndex      = 1
+	modifierIndex = 2
+	durationIndex = 3
+)
+
+func resolveExpiration(req request.Request, now time.Time) (request.Request, error) {
+	switch req.RequestType {
+
+	case commands.SetCommand:
+		if !hasExpiration(req.Args) || req.Args[modifierIndex] == commands.ExpireAtMilliseconds {
+			return req, nil
+		}
+
+		deadline, err := relativeDeadline(req.Args[modifierIndex], req.Args[durationIndex], now)
+
+		if err != nil {
+			return req, err
+		}
+
+		args := []string{req.Args[keyIndex], req.Args[valueIndex], commands.ExpireAtMilliseconds, formatUnixMilli(deadline)}
+		args = append(args, req.Args[durationIndex+1:]...)
+
+		return request.Request{RequestType: commands.SetCommand, Args: args}, nil
+
+	case commands.ExpireCommand:
+		deadline, err := relativeDeadline(commands.ExpireSeconds, req.Args[ttlIndex], now)
+
+		if err != nil {
+			return req, err
+		}
+
+		args := []string{req.Args[keyIndex], formatUnixMilli(deadline)}
+
+		return request.Request{RequestType: commands.ExpireAtCommand, Args: args}, nil
+	}
+
+	return req, nil
+}
+
+func relativeDeadline(modifier string, duration string, now time.Time) (time.Time, error) {
+	count, err := strconv.ParseInt(duration, 10, 64)
+
+	if err != nil {
+		return time.Time{}, errors.New("incorrect expiration time")
+	}
+
+	switch modifier {
+	case commands.ExpireSeconds:
+		return now.Add(time.Duration(count) * time.Second), nil
+	case commands.ExpireMilliseconds:
+		return now.Add(time.Duration(count) * time.Millisecond), nil
+	}
+
+	return time.Time{}, errors.New("incorrect expiration modifier")
+}
+
+func hasExpiration(args []string) bool {
+	if len(args) <= durationIndex {
+		return false
+	}
+
+	switch args[modifierIndex] {
+	case commands.ExpireSeconds, commands.ExpireMilliseconds, commands.ExpireAtMilliseconds:
+		return true
+	}
+
+	return false
+}
+
+func parseDeadline(args []string) (time.Time, bool, error) {
+	if !hasExpiration(args) {
+		return time.Time{}, false, nil
+	}
+
+	if args[modifierIndex] != commands.ExpireAtMilliseconds {
+		return time.Time{}, false, errors.New("incorrect expiration modifier")
+	}
+
+	deadline, err := parseUnixMilli(args[durationIndex])
+
+	return deadline, true, err
+}
+
+func parseUnixMilli(unparsed string) (time.Time, error) {
+	milli, err := strconv.ParseInt(unparsed, 10, 64)
+
+	if err != nil {
+		return time.Time{}, errors.New("incorrect expiration deadline")
+	}
+
+	return time.UnixMilli(milli), nil
+}
+
+func formatUnixMilli(deadline time.Time) string {
+	return strconv.FormatInt(deadline.UnixMilli(), 10)
+}
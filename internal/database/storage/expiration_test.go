@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_resolveExpiration(t *testing.T) {
+	t.Parallel()
+
+	now := time.UnixMilli(1700000000000)
+
+	type testCase struct {
+		name string
+
+		request request.Request
+
+		expectedRequest request.Request
+		expectedErr     error
+	}
+
+	testCases := []testCase{
+		{
+			name: "set without expiration",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+			expectedErr:     nil,
+		},
+		{
+			name: "set with expiration in seconds",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "EX", "30"}},
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "PXAT", "1700000030000"}},
+			expectedErr:     nil,
+		},
+		{
+			name: "set with expiration in milliseconds",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "PX", "1500"}},
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "PXAT", "1700000001500"}},
+			expectedErr:     nil,
+		},
+		{
+			name: "set with absolute deadline",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "PXAT", "1700000001500"}},
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "PXAT", "1700000001500"}},
+			expectedErr:     nil,
+		},
+		{
+			name: "expire",
+
+			request: request.Request{RequestType: commands.ExpireCommand, Args: []string{"biba", "10"}},
+
+			expectedRequest: request.Request{RequestType: commands.ExpireAtCommand, Args: []string{"biba", "1700000010000"}},
+			expectedErr:     nil,
+		},
+		{
+			name: "expire with incorrect time",
+
+			request: request.Request{RequestType: commands.ExpireCommand, Args: []string{"biba", "boba"}},
+
+			expectedRequest: request.Request{RequestType: commands.ExpireCommand, Args: []string{"biba", "boba"}},
+			expectedErr:     errors.New("incorrect expiration time"),
+		},
+		{
+			name: "set with condition",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", commands.IfEqualModifier, "10"}},
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", commands.IfEqualModifier, "10"}},
+			expectedErr:     nil,
+		},
+		{
+			name: "set with expiration and condition",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", commands.ExpireMilliseconds, "100", commands.NotExistsModifier}},
+
+			expectedRequest: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", commands.ExpireAtMilliseconds, "1700000000100", commands.NotExistsModifier}},
+			expectedErr:     nil,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := resolveExpiration(test.request, now)
+
+			assert.Equal(t, test.expectedRequest, req)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -87,6 +90,71 @@ func ForEach(directory string, fileNames []string, action func(file *os.File) er
 	return nil
 }
 
+// AppendFiles appends the data to the files, a failed append truncates every file
+// back to its previous size, so the records are either appended to all of them or to none.
+func AppendFiles(dir string, fileNames []string, fileData [][]byte) error {
+	if len(fileNames) != len(fileData) {
+		return fmt.Errorf("could not append %d file names with %d file data", len(fileNames), len(fileData))
+	}
+
+	sizes := make([]int64, 0, len(fileNames))
+
+	for i, name := range fileNames {
+		size, err := appendFile(dir, name, fileData[i])
+		sizes = append(sizes, size)
+
+		if err != nil {
+			return rollback(dir, fileNames[:len(sizes)], sizes, err)
+		}
+	}
+
+	return nil
+}
+
+// appendFile returns the size of the file before the append or -1 when it could not be opened.
+func appendFile(dir string, name string, data []byte) (int64, error) {
+	file, err := os.OpenFile(dir+name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+
+	if err != nil {
+		return -1, fmt.Errorf("could not open file %s", name)
+	}
+
+	defer file.Close()
+
+	stats, err := file.Stat()
+
+	if err != nil {
+		return -1, fmt.Errorf("could not get stats of file %s", name)
+	}
+
+	n, err := file.Write(data)
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if err != nil {
+		return stats.Size(), fmt.Errorf("appended only %d bytes to file %s", n, name)
+	}
+
+	return stats.Size(), nil
+}
+
+// rollback goes from the last file to the first, a file appended twice ends at its first size.
+func rollback(dir string, fileNames []string, sizes []int64, err error) error {
+	for i := len(fileNames) - 1; i >= 0; i-- {
+		if sizes[i] < 0 {
+			continue
+		}
+
+		if truncateErr := os.Truncate(dir+fileNames[i], sizes[i]); truncateErr != nil {
+			err = fmt.Errorf("%w, could not truncate file %s back", err, fileNames[i])
+		}
+	}
+
+	return err
+}
+
 func MakeFileNames(directory string) ([]string, error) {
 	files, err := os.ReadDir(directory)
 
@@ -104,9 +172,58 @@ func MakeFileNames(directory string) ([]string, error) {
 		fileNames = append(fileNames, file.Name())
 	}
 
+	SortSegments(fileNames)
+
 	return fileNames, nil
 }
 
+func SortSegments(names []string) {
+	slices.SortFunc(names, func(a, b string) int {
+		if SegmentLess(a, b) {
+			return -1
+		}
+
+		if SegmentLess(b, a) {
+			return 1
+		}
+
+		return 0
+	})
+}
+
+func SegmentLess(a, b string) bool {
+	prefixA, indexA, suffixA, numberedA := splitSegment(a)
+	prefixB, indexB, suffixB, numberedB := splitSegment(b)
+
+	if !numberedA || !numberedB || prefixA != prefixB || suffixA != suffixB || indexA == indexB {
+		return a < b
+	}
+
+	return indexA < indexB
+}
+
+func splitSegment(name string) (string, uint64, string, bool) {
+	end := strings.LastIndexAny(name, "0123456789") + 1
+
+	if end == 0 {
+		return name, 0, "", false
+	}
+
+	start := end
+
+	for start > 0 && name[start-1] >= '0' && name[start-1] <= '9' {
+		start--
+	}
+
+	index, err := strconv.ParseUint(name[start:end], 10, 64)
+
+	if err != nil {
+		return name, 0, "", false
+	}
+
+	return name[:start], index, name[end:], true
+}
+
 func FindLastFile(directory string) (string, error) {
 	names, err := MakeFileNames(directory)
 
@@ -125,10 +242,14 @@ func FindFile(names []string, target string) int {
 	left := 0
 	right := len(names) - 1
 
+	if len(names) == 0 {
+		return NotFound
+	}
+
 	for left < right {
 		mid := (left + right) / 2
 
-		if names[mid] < target {
+		if SegmentLess(names[mid], target) {
 			left = mid + 1
 		} else {
 			right = mid
@@ -142,6 +263,16 @@ func FindFile(names []string, target string) int {
 	return left
 }
 
+func FindNextFile(names []string, target string) int {
+	for i, name := range names {
+		if SegmentLess(target, name) {
+			return i
+		}
+	}
+
+	return NotFound
+}
+
 func WriteFiles(dir string, fileNames []string, fileData [][]byte) error {
 	if len(fileNames) != len(fileData) {
 		return fmt.Errorf("could not write %d file names with %d file data", len(fileNames), len(fileData))
@@ -192,6 +192,14 @@ func Test_FindFile(t *testing.T) {
 
 			expectedIndex: NotFound,
 		},
+		{
+			name: "file in numerically ordered list",
+
+			names:  []string{"wal1.log", "wal2.log", "wal9.log", "wal10.log", "wal11.log"},
+			target: "wal10.log",
+
+			expectedIndex: 3,
+		},
 	}
 
 	for _, test := range testCases {
@@ -202,6 +210,61 @@ func Test_FindFile(t *testing.T) {
 	}
 }
 
+func Test_FindNextFile(t *testing.T) {
+	type testCase struct {
+		name string
+
+		names  []string
+		target string
+
+		expectedIndex int
+	}
+
+	names := []string{"wal2.log", "wal9.log", "wal10.log", "wal11.log"}
+
+	testCases := []testCase{
+		{
+			name: "next after existing file",
+
+			names:  names,
+			target: "wal9.log",
+
+			expectedIndex: 2,
+		},
+		{
+			name: "next after removed file",
+
+			names:  names,
+			target: "wal5.log",
+
+			expectedIndex: 1,
+		},
+		{
+			name: "last file",
+
+			names:  names,
+			target: "wal11.log",
+
+			expectedIndex: NotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			index := FindNextFile(test.names, test.target)
+			assert.Equal(t, test.expectedIndex, index)
+		})
+	}
+}
+
+func Test_SortSegments(t *testing.T) {
+	names := []string{"wal10.log", "wal2.log", "biba", "wal1.log", "wal.log"}
+
+	SortSegments(names)
+
+	assert.Equal(t, []string{"biba", "wal.log", "wal1.log", "wal2.log", "wal10.log"}, names)
+}
+
 func Test_FindLastFile(t *testing.T) {
 
 	type testCase struct {
@@ -316,3 +379,47 @@ func Test_WriteFiles(t *testing.T) {
 		})
 	}
 }
+
+func Test_AppendFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	err := AppendFiles(dir, []string{"wal1.log", "wal2.log"}, [][]byte{[]byte("biba"), []byte("boba")})
+
+	assert.NoError(t, err)
+
+	err = AppendFiles(dir, []string{"wal2.log"}, [][]byte{[]byte("biba")})
+
+	assert.NoError(t, err)
+
+	first, _ := os.ReadFile(dir + "wal1.log")
+	second, _ := os.ReadFile(dir + "wal2.log")
+
+	assert.Equal(t, []byte("biba"), first)
+	assert.Equal(t, []byte("bobabiba"), second)
+
+	err = AppendFiles(dir, []string{"wal1.log"}, nil)
+
+	assert.Equal(t, errors.New("could not append 1 file names with 0 file data"), err)
+}
+
+func Test_AppendFilesRollsBack(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	os.WriteFile(dir+"wal1.log", []byte("biba"), 0644)
+	os.Mkdir(dir+"wal3.log", 0755)
+
+	err := AppendFiles(dir, []string{"wal1.log", "wal2.log", "wal1.log", "wal3.log"},
+		[][]byte{[]byte("boba"), []byte("boba"), []byte("biba"), []byte("boba")})
+
+	assert.Equal(t, errors.New("could not open file wal3.log"), err)
+
+	first, _ := os.ReadFile(dir + "wal1.log")
+	second, _ := os.ReadFile(dir + "wal2.log")
+
+	assert.Equal(t, []byte("biba"), first)
+	assert.Empty(t, second)
+}
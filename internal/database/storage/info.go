@@ -0,0 +1,70 @@
+package storage
+
+import "fmt"
+
+const (
+	masterRole = "master"
+	slaveRole  = "slave"
+
+	walDisabled = "disabled"
+	walHealthy  = "ok"
+	walDegraded = "degraded"
+)
+
+type sequencedWal interface {
+	LSN() uint64
+	AdvanceLSN(lsn uint64)
+}
+
+type degradableWal interface {
+	Failure() error
+}
+
+func (s *Storage) info() string {
+	role := masterRole
+
+	if s.replica != nil && !s.replica.IsMaster() {
+		role = slaveRole
+	}
+
+	info := fmt.Sprintf("role:%s\nlsn:%d\nwal:%s", role, s.lsn(), s.walStatus())
+
+	if err := s.walFailure(); err != nil {
+		info += fmt.Sprintf("\nwal_error:%s", err.Error())
+	}
+
+	return info
+}
+
+func (s *Storage) walStatus() string {
+	switch {
+	case s.wal == nil:
+		return walDisabled
+	case s.walFailure() != nil:
+		return walDegraded
+	}
+
+	return walHealthy
+}
+
+func (s *Storage) walFailure() error {
+	if degradable, ok := s.wal.(degradableWal); ok {
+		return degradable.Failure()
+	}
+
+	return nil
+}
+
+func (s *Storage) lsn() uint64 {
+	if sequenced, ok := s.wal.(sequencedWal); ok {
+		return sequenced.LSN()
+	}
+
+	return 0
+}
+
+func (s *Storage) advanceLSN(lsn uint64) {
+	if sequenced, ok := s.wal.(sequencedWal); ok {
+		sequenced.AdvanceLSN(lsn)
+	}
+}
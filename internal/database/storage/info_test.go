@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_InfoReportsLSN(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	stor, _ := newPersistentStorage(t, dir)
+
+	answer, err := stor.HandleRequest(request.Request{RequestType: commands.InfoCommand})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "role:master\nlsn:0\nwal:ok", answer)
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+	stor.HandleRequest(request.Request{RequestType: commands.DelCommand, Args: []string{"boba"}})
+	stor.wal.(segmentedWal).Checkpoint()
+
+	answer, _ = stor.HandleRequest(request.Request{RequestType: commands.InfoCommand})
+
+	assert.Equal(t, "role:master\nlsn:2\nwal:ok", answer)
+
+	_, err = stor.HandleRequest(request.Request{RequestType: commands.SaveCommand})
+
+	assert.NoError(t, err)
+
+	assert.Empty(t, writtenSegments(dir))
+
+	recovered, _ := newPersistentStorage(t, dir)
+
+	answer, _ = recovered.HandleRequest(request.Request{RequestType: commands.InfoCommand})
+
+	assert.Equal(t, "role:master\nlsn:2\nwal:ok", answer)
+
+	recovered.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"boba", "biba"}})
+	recovered.wal.(segmentedWal).Checkpoint()
+
+	answer, _ = recovered.HandleRequest(request.Request{RequestType: commands.InfoCommand})
+
+	assert.Equal(t, "role:master\nlsn:3\nwal:ok", answer)
+}
+
+type degradedWal struct {
+	recordingWal
+}
+
+func (w *degradedWal) Write(req request.Request) func() (uint64, error) {
+	return func() (uint64, error) { return 0, w.Failure() }
+}
+
+func (w *degradedWal) WriteGroup(reqs []request.Request) func() (uint64, error) {
+	return func() (uint64, error) { return 0, w.Failure() }
+}
+
+func (w *degradedWal) Failure() error {
+	return errors.New("node is read-only, wal is degraded: no space left on device")
+}
+
+func Test_DegradedWalRefusesMutations(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(&degradedWal{}))
+
+	_, err := stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+
+	assert.Equal(t, errors.New("node is read-only, wal is degraded: no space left on device"), err)
+
+	_, err = stor.HandleRequest(request.Request{RequestType: commands.IncrCommand, Args: []string{"boba"}})
+
+	assert.Error(t, err)
+
+	_, found := eng.GET("biba")
+
+	assert.False(t, found)
+
+	_, found = eng.GET("boba")
+
+	assert.False(t, found)
+
+	_, applied, err := stor.HandleTransaction([]request.Request{{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}}, nil)
+
+	assert.False(t, applied)
+	assert.Error(t, err)
+
+	answer, err := stor.HandleRequest(request.Request{RequestType: commands.InfoCommand})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "role:master\nlsn:0\nwal:degraded\nwal_error:node is read-only, wal is degraded: no space left on device", answer)
+}
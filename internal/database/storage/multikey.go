@@ -0,0 +1,30 @@
+package storage
+
+import "strings"
+
+func splitPairs(args []string) ([]string, []string) {
+	keys := make([]string, 0, len(args)/2)
+	values := make([]string, 0, len(args)/2)
+
+	for i := 0; i+1 < len(args); i += 2 {
+		keys = append(keys, args[i])
+		values = append(values, args[i+1])
+	}
+
+	return keys, values
+}
+
+func formatValues(values []string, found []bool) string {
+	formatted := make([]string, len(values))
+
+	for i, value := range values {
+		if !found[i] {
+			formatted[i] = notFound
+			continue
+		}
+
+		formatted[i] = value
+	}
+
+	return strings.Join(formatted, pairEndElement)
+}
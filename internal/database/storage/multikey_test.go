@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_HandleMultiKeyRequests(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	answer, err := stor.HandleRequest(request.Request{RequestType: commands.MSetCommand, Args: []string{"biba", "1", "boba", "2"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, okAnswer, answer)
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.MGetCommand, Args: []string{"biba", "aboba", "boba"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n"+notFound+"\n2", answer)
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.MDelCommand, Args: []string{"biba", "aboba"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1", answer)
+}
+
+func Test_splitPairs(t *testing.T) {
+	t.Parallel()
+
+	keys, values := splitPairs([]string{"biba", "1", "boba", "2"})
+
+	assert.Equal(t, []string{"biba", "boba"}, keys)
+	assert.Equal(t, []string{"1", "2"}, values)
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"strconv"
+	"strings"
+)
+
+const (
+	pairDelimElement = " "
+	pairEndElement   = "\n"
+)
+
+type orderedEngineLayer interface {
+	RANGE(start, end string, limit int) ([]string, []string)
+	PREFIX(prefix string, limit int) ([]string, []string)
+}
+
+func (s *Storage) orderedEngine() (orderedEngineLayer, error) {
+	ordered, ok := s.engine.(orderedEngineLayer)
+
+	if !ok {
+		s.logger.Error("engine does not support ordered queries")
+		return nil, errors.New("engine does not support ordered queries")
+	}
+
+	return ordered, nil
+}
+
+func parseLimit(modifiers []string) (int, error) {
+	if len(modifiers) < 2 || modifiers[0] != commands.LimitModifier {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(modifiers[1])
+
+	if err != nil || limit <= 0 {
+		return 0, errors.New("incorrect limit")
+	}
+
+	return limit, nil
+}
+
+func formatPairs(keys []string, values []string) string {
+	if len(keys) == 0 {
+		return notFound
+	}
+
+	builder := &strings.Builder{}
+
+	for i, key := range keys {
+		if i != 0 {
+			builder.WriteString(pairEndElement)
+		}
+
+		builder.WriteString(key)
+		builder.WriteString(pairDelimElement)
+		builder.WriteString(values[i])
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_HandleOrderedRequests(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewOrderedEngine(zap.NewNop(), engine.WithPartitions(4, 10))
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	for _, key := range []string{"user:2", "user:1", "order:1", "user:3"} {
+		stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{key, "v" + key}})
+	}
+
+	type testCase struct {
+		name string
+
+		request request.Request
+
+		expectStr   string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "range request",
+
+			request: request.Request{RequestType: commands.RangeCommand, Args: []string{"user:1", "user:2"}},
+
+			expectStr:   "user:1 vuser:1\nuser:2 vuser:2",
+			expectedErr: nil,
+		},
+		{
+			name: "range request with limit",
+
+			request: request.Request{RequestType: commands.RangeCommand, Args: []string{"a", "z", commands.LimitModifier, "1"}},
+
+			expectStr:   "order:1 vorder:1",
+			expectedErr: nil,
+		},
+		{
+			name: "empty range request",
+
+			request: request.Request{RequestType: commands.RangeCommand, Args: []string{"a", "b"}},
+
+			expectStr:   notFound,
+			expectedErr: nil,
+		},
+		{
+			name: "prefix request",
+
+			request: request.Request{RequestType: commands.PrefixCommand, Args: []string{"user:", commands.LimitModifier, "2"}},
+
+			expectStr:   "user:1 vuser:1\nuser:2 vuser:2",
+			expectedErr: nil,
+		},
+		{
+			name: "range request with incorrect limit",
+
+			request: request.Request{RequestType: commands.RangeCommand, Args: []string{"a", "z", commands.LimitModifier, "lol"}},
+
+			expectStr:   "",
+			expectedErr: errors.New("incorrect limit"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			actualValue, actualErr := stor.HandleRequest(test.request)
+
+			assert.Equal(t, test.expectStr, actualValue)
+			assert.Equal(t, test.expectedErr, actualErr)
+		})
+	}
+}
+
+func Test_HandleOrderedRequestsWithHashEngine(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	answer, err := stor.HandleRequest(request.Request{RequestType: commands.PrefixCommand, Args: []string{"user:"}})
+
+	assert.Equal(t, "", answer)
+	assert.Equal(t, errors.New("engine does not support ordered queries"), err)
+}
+
+func Test_formatPairs(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, notFound, formatPairs([]string{}, []string{}))
+	assert.Equal(t, "a 1\nb 2", formatPairs([]string{"a", "b"}, []string{"1", "2"}))
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"slices"
+)
+
+// holdKeys waits for the logged writes of the keys and holds the keys until the release,
+// so the writes of a key are applied to the engine in the order of the wal.
+func (s *Storage) holdKeys(keys []string) func() {
+	for {
+		s.pendingMutex.Lock()
+
+		pending := s.pendingOf(keys)
+
+		if pending == nil {
+			pending = make(chan struct{})
+
+			if s.pending == nil {
+				s.pending = make(map[string]chan struct{})
+			}
+
+			for _, key := range keys {
+				s.pending[key] = pending
+			}
+
+			s.pendingMutex.Unlock()
+
+			return func() {
+				s.pendingMutex.Lock()
+				defer s.pendingMutex.Unlock()
+
+				for _, key := range keys {
+					delete(s.pending, key)
+				}
+
+				close(pending)
+			}
+		}
+
+		s.pendingMutex.Unlock()
+
+		<-pending
+	}
+}
+
+func (s *Storage) pendingOf(keys []string) chan struct{} {
+	for _, key := range keys {
+		if pending, found := s.pending[key]; found {
+			return pending
+		}
+	}
+
+	return nil
+}
+
+// mutatedKeys returns the keys the request changes without repeats.
+func mutatedKeys(req request.Request) []string {
+	var keys []string
+
+	switch req.RequestType {
+	case commands.MSetCommand:
+		for i := 0; i < len(req.Args); i += 2 {
+			keys = append(keys, req.Args[i])
+		}
+	case commands.MDelCommand:
+		keys = req.Args
+	default:
+		if len(req.Args) != 0 {
+			keys = req.Args[:1]
+		}
+	}
+
+	unique := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if !slices.Contains(unique, key) {
+			unique = append(unique, key)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_holdKeys(t *testing.T) {
+	t.Parallel()
+
+	stor := &Storage{}
+
+	release := stor.holdKeys([]string{"biba", "boba"})
+
+	held := make(chan struct{})
+
+	go func() {
+		defer close(held)
+		stor.holdKeys([]string{"boba"})()
+	}()
+
+	select {
+	case <-held:
+		t.Fatal("key is held twice")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	stor.holdKeys([]string{"kuka"})()
+
+	release()
+
+	<-held
+
+	assert.Empty(t, stor.pending)
+}
+
+func Test_mutatedKeys(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		req request.Request
+
+		expectedKeys []string
+	}
+
+	testCases := []testCase{
+		{
+			name: "mset keys",
+
+			req: request.Request{RequestType: commands.MSetCommand, Args: []string{"biba", "1", "boba", "2", "biba", "3"}},
+
+			expectedKeys: []string{"biba", "boba"},
+		},
+		{
+			name: "mdel keys",
+
+			req: request.Request{RequestType: commands.MDelCommand, Args: []string{"biba", "boba"}},
+
+			expectedKeys: []string{"biba", "boba"},
+		},
+		{
+			name: "single key",
+
+			req: request.Request{RequestType: commands.HSetCommand, Args: []string{"biba", "name", "boba"}},
+
+			expectedKeys: []string{"biba"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expectedKeys, mutatedKeys(test.req))
+		})
+	}
+}
+
+func Test_ConcurrentWritesRecoverInOrder(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	stor, eng := newPersistentStorage(t, dir)
+
+	var wg sync.WaitGroup
+
+	for i := range 16 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", strconv.Itoa(i)}})
+			stor.HandleRequest(request.Request{RequestType: commands.RPushCommand, Args: []string{"queue", strconv.Itoa(i)}})
+		}()
+	}
+
+	wg.Wait()
+
+	live, _ := eng.GET("biba")
+	liveQueue, _ := eng.LRANGE("queue", 0, -1)
+
+	_, recovered := newPersistentStorage(t, dir)
+
+	value, _ := recovered.GET("biba")
+	queue, _ := recovered.LRANGE("queue", 0, -1)
+
+	assert.Equal(t, live, value)
+	assert.Equal(t, liveQueue, queue)
+}
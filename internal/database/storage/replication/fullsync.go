@@ -0,0 +1,204 @@
+package replication
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"inmemorykvdb/internal/database/storage/snapshot"
+	"math"
+)
+
+// fullDump is the point-in-time dump of the master which the slaves are reading by chunks,
+// the wal after its lsn is appended to the segment of the dump.
+type fullDump struct {
+	fileName string
+	lsn      uint64
+	data     []byte
+}
+
+// ServeFullSync lets the master answer the full sync requests with the dump of the storage.
+func (m *Master) ServeFullSync(dump func() (uint64, []request.Request)) {
+	m.syncMutex.Lock()
+	defer m.syncMutex.Unlock()
+
+	m.dump = dump
+}
+
+// fullSync makes a new dump for the first chunk, the next chunks are read from the same dump
+// while it is not replaced by the dump of another lsn or released after its last chunk.
+func (m *Master) fullSync(req *protocol.Request) (*protocol.Response, error) {
+	m.syncMutex.Lock()
+	defer m.syncMutex.Unlock()
+
+	if m.dump == nil {
+		return nil, errors.New("full sync is not enabled")
+	}
+
+	if req.Offset == 0 {
+		synced, err := m.newFullDump()
+
+		if err != nil || synced == nil {
+			return protocol.UnfoundResponse(), err
+		}
+
+		m.synced = synced
+	} else if m.synced == nil || m.synced.lsn != req.LSN || m.synced.fileName != req.FileName ||
+		req.Offset > int64(len(m.synced.data)) {
+		return nil, errors.New("full sync dump is outdated")
+	}
+
+	synced := m.synced
+
+	end := min(req.Offset+int64(m.maxChunkSize), int64(len(synced.data)))
+
+	resp := protocol.OkResponseOneFile(synced.fileName, synced.data[req.Offset:end])
+
+	if end < int64(len(synced.data)) {
+		resp.Token = protocol.NewToken(protocol.FullSyncChunkRequest(synced.fileName, end, synced.lsn))
+	} else {
+		m.synced = nil
+	}
+
+	return resp, nil
+}
+
+func (m *Master) newFullDump() (*fullDump, error) {
+	fileNames, err := m.fileNames()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fileNames) == 0 {
+		return nil, nil
+	}
+
+	lsn, reqs := m.dump()
+
+	if lsn == 0 {
+		return nil, nil
+	}
+
+	if m.synced != nil && m.synced.lsn == lsn {
+		return m.synced, nil
+	}
+
+	m.logger.Debug(fmt.Sprintf("started full sync dump with lsn %d", lsn))
+
+	return &fullDump{fileName: fileNames[len(fileNames)-1], lsn: lsn, data: snapshot.Encode(0, lsn, reqs)}, nil
+}
+
+// fullSync bootstraps an empty slave from the dump of the master, the slave
+// of a master without full sync reads the whole wal instead.
+func (s *Slave) fullSync() error {
+	s.logger.Debug("started full sync from master")
+
+	resp, err := s.exchange(protocol.FullSyncRequest())
+
+	if err != nil {
+		return err
+	}
+
+	switch resp.Status {
+	case protocol.ErrorStatus:
+		s.logger.Warn(fmt.Sprintf("master could not make full sync, reading the whole wal: %s", bytes.Join(resp.Data, nil)))
+		s.needsFullSync = false
+		return nil
+	case protocol.UnfoundStatus:
+		s.needsFullSync = false
+		return nil
+	}
+
+	if len(resp.FileNames) != 1 || len(resp.Data) != 1 {
+		return errors.New("full sync response is incorrect")
+	}
+
+	fileName := resp.FileNames[0]
+	dump := resp.Data[0]
+
+	for resp.Token != "" {
+		resp, err = s.exchange(protocol.ContinueRequest(resp.Token))
+
+		if err != nil {
+			return err
+		}
+
+		if resp.Status != protocol.OkStatus || len(resp.Data) != 1 {
+			return fmt.Errorf("full sync is interrupted: %s", bytes.Join(resp.Data, nil))
+		}
+
+		dump = append(dump, resp.Data[0]...)
+	}
+
+	_, batch, err := snapshot.Decode(dump)
+
+	if err != nil {
+		return err
+	}
+
+	records, err := dumpRecords(batch)
+
+	if err != nil {
+		return err
+	}
+
+	if s.lastLSN > 0 {
+		err = s.discard()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(records) == 0 {
+		s.lastLSN = batch.LastLSN
+		s.ackedLSN = batch.LastLSN
+		s.position = position{LSN: batch.LastLSN}
+	} else if err = s.apply(protocol.OkResponseOneFile(fileName, records)); err != nil {
+		return err
+	}
+
+	s.needsFullSync = false
+	s.logger.Info(fmt.Sprintf("full sync is done at lsn %d", batch.LastLSN))
+
+	return nil
+}
+
+// discard removes the local segments and clears the storage before the dump replaces them,
+// the records the slave has are older than the wal the master still keeps.
+func (s *Slave) discard() error {
+	s.logger.Info(fmt.Sprintf("discarding local wal at lsn %d for full sync", s.lastLSN))
+
+	err := s.manifest.Remove(math.MaxUint64)
+
+	if err != nil {
+		return err
+	}
+
+	s.position = position{}
+	s.storageChannel <- &request.Batch{Reset: true}
+
+	return nil
+}
+
+// dumpRecords writes the dump as wal records with its lsn, so a restarted
+// slave recovers the dump from its wal and continues after the lsn.
+func dumpRecords(batch *request.Batch) ([]byte, error) {
+	var records []byte
+
+	for _, req := range batch.Data {
+		req.LSN = batch.LastLSN
+
+		data, err := req.ParseToBytes()
+
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, data...)
+	}
+
+	return records, nil
+}
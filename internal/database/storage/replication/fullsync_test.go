@@ -0,0 +1,186 @@
+package replication
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"inmemorykvdb/internal/database/storage/snapshot"
+	"inmemorykvdb/internal/database/storage/wal/manifest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testDump() []request.Request {
+	return []request.Request{
+		{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+		{RequestType: commands.SAddCommand, Args: []string{"tags", "biba", "boba"}},
+		{RequestType: commands.HSetCommand, Args: []string{"user", "name", "biba"}},
+	}
+}
+
+func Test_fullSyncMaster(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir() + "/"
+
+	os.WriteFile(directory+"wal1.log", lsnRecord(1), 0644)
+	os.WriteFile(directory+"wal2.log", lsnRecord(2), 0644)
+
+	master := newTestMaster(directory)
+	master.maxChunkSize = 16
+
+	_, err := master.createResponse(protocol.FullSyncRequest())
+
+	assert.Equal(t, errors.New("full sync is not enabled"), err)
+
+	lsn := uint64(2)
+
+	master.ServeFullSync(func() (uint64, []request.Request) {
+		return lsn, testDump()
+	})
+
+	resp, err := master.createResponse(protocol.FullSyncRequest())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"wal2.log"}, resp.FileNames)
+
+	dump := resp.Data[0]
+
+	for resp.Token != "" {
+		resp, err = master.createResponse(protocol.ContinueRequest(resp.Token))
+
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(resp.Data[0]), master.maxChunkSize)
+
+		dump = append(dump, resp.Data[0]...)
+	}
+
+	_, batch, err := snapshot.Decode(dump)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), batch.LastLSN)
+	assert.Len(t, batch.Data, len(testDump()))
+
+	first, _ := master.createResponse(protocol.FullSyncRequest())
+
+	lsn = 3
+
+	master.createResponse(protocol.FullSyncRequest())
+
+	resp, err = master.createResponse(protocol.ContinueRequest(first.Token))
+
+	assert.Nil(t, resp)
+	assert.Equal(t, errors.New("full sync dump is outdated"), err)
+
+	lsn = 0
+
+	resp, err = master.createResponse(protocol.FullSyncRequest())
+
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.UnfoundResponse(), resp)
+}
+
+func Test_fullSyncSlave(t *testing.T) {
+	t.Parallel()
+
+	dump := snapshot.Encode(0, 7, testDump())
+
+	first := protocol.OkResponseOneFile("wal3.log", dump[:10])
+	first.Token = "biba"
+
+	type testCase struct {
+		name string
+
+		responses []*protocol.Response
+
+		expectedErr      error
+		expectedFullSync bool
+		expectedLSN      uint64
+		expectedRequest  *protocol.Request
+		expectedRecords  int
+	}
+
+	testCases := []testCase{
+		{
+			name: "dump by chunks",
+
+			responses: []*protocol.Response{first, protocol.OkResponseOneFile("wal3.log", dump[10:])},
+
+			expectedLSN:     7,
+			expectedRequest: protocol.ReadAfterLSNRequest(7),
+			expectedRecords: len(testDump()),
+		},
+		{
+			name: "master without full sync",
+
+			responses: []*protocol.Response{protocol.ErrorResponse(errors.New("full sync is not enabled"))},
+
+			expectedRequest: protocol.ReadAfterLSNRequest(0),
+		},
+		{
+			name: "outdated dump",
+
+			responses: []*protocol.Response{first, protocol.ErrorResponse(errors.New("full sync dump is outdated"))},
+
+			expectedErr:      errors.New("full sync is interrupted: full sync dump is outdated"),
+			expectedFullSync: true,
+			expectedRequest:  protocol.ReadAfterLSNRequest(0),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			slave := newTestSlave(t, &scriptedClient{responses: test.responses})
+			slave.needsFullSync = true
+
+			err := slave.fullSync()
+
+			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedFullSync, slave.needsFullSync)
+			assert.Equal(t, test.expectedLSN, slave.lastLSN)
+			assert.Equal(t, test.expectedRequest, slave.createRequest())
+
+			if test.expectedRecords == 0 {
+				assert.Empty(t, slave.storageChannel)
+				return
+			}
+
+			batch := <-slave.storageChannel
+
+			assert.FileExists(t, slave.directory+manifest.SegmentName("wal", 3))
+			assert.Len(t, batch.Data, test.expectedRecords)
+			assert.Equal(t, uint64(7), batch.LastLSN)
+		})
+	}
+}
+
+func Test_fullSyncAfterResync(t *testing.T) {
+	t.Parallel()
+
+	dump := snapshot.Encode(0, 7, testDump())
+
+	slave := newTestSlave(t, &scriptedClient{responses: []*protocol.Response{protocol.OkResponseOneFile("wal3.log", dump)}})
+
+	slave.apply(protocol.OkResponseUpToOffset([]string{"wal1.log"}, [][]byte{lsnRecord(2)}, int64(len(lsnRecord(2)))))
+	<-slave.storageChannel
+
+	err := slave.apply(protocol.ResyncResponse())
+
+	assert.Error(t, err)
+	assert.True(t, slave.needsFullSync)
+
+	err = slave.fullSync()
+
+	assert.NoError(t, err)
+	assert.False(t, slave.needsFullSync)
+	assert.True(t, (<-slave.storageChannel).Reset)
+	assert.Len(t, (<-slave.storageChannel).Data, len(testDump()))
+	assert.NoFileExists(t, slave.directory+manifest.SegmentName("wal", 1))
+	assert.FileExists(t, slave.directory+manifest.SegmentName("wal", 3))
+	assert.Equal(t, protocol.ReadAfterLSNRequest(7), slave.createRequest())
+}
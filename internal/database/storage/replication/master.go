@@ -2,10 +2,17 @@ package replication
 
 import (
 	"errors"
+	"fmt"
 	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/encryption"
 	"inmemorykvdb/internal/database/storage/filesystem"
 	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"inmemorykvdb/internal/database/storage/wal/manifest"
 	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -13,16 +20,44 @@ import (
 const (
 	extraBuffer      = 10
 	defaultDirectory = "C:/go/InMemoryKeyValueDB/test/"
+	defaultPattern   = "write_ahead"
+	defaultHeartbeat = 5 * time.Second
+	defaultChunkSize = 1 << 20
 )
 
 type server interface {
 	HandleConnections(func([]byte) []byte)
 }
 
+type flushNotifier interface {
+	Flushed() <-chan struct{}
+}
+
 type Master struct {
 	directory    string
+	pattern      string
+	keyring      *encryption.Keyring
+	manifest     *manifest.Manifest
 	masterServer server
 	logger       *zap.Logger
+
+	flushes   flushNotifier
+	heartbeat time.Duration
+
+	maxChunkSize int
+
+	syncMutex sync.Mutex
+	dump      func() (uint64, []request.Request)
+	synced    *fullDump
+
+	minReplicas int
+	ackTimeout  time.Duration
+	ackMutex    sync.Mutex
+	acks        map[string]uint64
+	acked       chan struct{}
+
+	// truncated is the lsn up to which the segments are removed after a snapshot
+	truncated atomic.Uint64
 }
 
 func (m *Master) DataChan() chan *request.Batch {
@@ -52,6 +87,30 @@ func NewMaster(listener server, logger *zap.Logger, options ...MasterOption) (*M
 		master.directory = defaultDirectory
 	}
 
+	if master.pattern == "" {
+		master.pattern = defaultPattern
+	}
+
+	if master.heartbeat == 0 {
+		master.heartbeat = defaultHeartbeat
+	}
+
+	if master.maxChunkSize == 0 {
+		master.maxChunkSize = defaultChunkSize
+	}
+
+	if master.ackTimeout == 0 {
+		master.ackTimeout = defaultAckTimeout
+	}
+
+	segments, err := manifest.NewManifest(logger, master.pattern, manifest.WithDirectory(master.directory))
+
+	if err != nil {
+		return nil, err
+	}
+
+	master.manifest = segments
+
 	master.start()
 
 	return master, nil
@@ -78,7 +137,7 @@ func (m *Master) start() {
 			return m.errorResp(err)
 		}
 
-		marshaledResp, err := protocol.Marshal(resp)
+		marshaledResp, err := protocol.Marshal(resp.Seal())
 
 		if err != nil {
 			m.logger.Error(err.Error())
@@ -90,16 +149,32 @@ func (m *Master) start() {
 }
 
 func (m *Master) errorResp(err error) []byte {
-	r, _ := protocol.Marshal(protocol.ErrorResponse(err))
+	r, _ := protocol.Marshal(protocol.ErrorResponse(err).Seal())
 	return r
 }
 
 func (m *Master) createResponse(req *protocol.Request) (*protocol.Response, error) {
-	fileNames, err := filesystem.MakeFileNames(m.directory)
+	switch req.Type {
+	case protocol.Subscribe:
+		return m.subscribe(req)
+	case protocol.FullSync:
+		return m.fullSync(req)
+	case protocol.Ack:
+		return m.acknowledge(req)
+	case protocol.Continue:
+		next, err := protocol.ParseToken(req.Token)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return m.createResponse(next)
+	}
+
+	fileNames, err := m.fileNames()
 
 	if err != nil {
-		m.logger.Error("could not read file names")
-		return nil, errors.New("could not read file names")
+		return nil, err
 	}
 
 	switch req.Type {
@@ -107,19 +182,73 @@ func (m *Master) createResponse(req *protocol.Request) (*protocol.Response, erro
 		return m.readLast(fileNames, req.LastFileName)
 	case protocol.ReadAll:
 		return m.readAll(fileNames)
+	case protocol.ReadAfterLSN:
+		return m.readAfterLSN(fileNames, req.LSN)
+	case protocol.ReadFromOffset:
+		return m.readFromOffset(fileNames, req)
 	}
 
 	return nil, errors.New("unexpected request type")
 }
 
+func (m *Master) fileNames() ([]string, error) {
+	segments, err := m.manifest.Segments()
+
+	if err != nil {
+		m.logger.Error(err.Error())
+		return nil, errors.New("could not read file names")
+	}
+
+	fileNames := make([]string, 0, len(segments))
+
+	for _, seg := range segments {
+		fileNames = append(fileNames, seg.Name)
+	}
+
+	return fileNames, nil
+}
+
+// subscribe holds the request of a streaming slave until a flushed batch brings
+// records after its position, an empty answer after the heartbeat keeps the stream alive.
+func (m *Master) subscribe(req *protocol.Request) (*protocol.Response, error) {
+	if m.flushes == nil {
+		return nil, errors.New("streaming is not enabled")
+	}
+
+	heartbeat := time.NewTimer(m.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		flushed := m.flushes.Flushed()
+
+		fileNames, err := m.fileNames()
+
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := m.readFromOffset(fileNames, req)
+
+		if err != nil || resp.Status != protocol.UnfoundStatus {
+			return resp, err
+		}
+
+		select {
+		case <-flushed:
+		case <-heartbeat.C:
+			return protocol.UnfoundResponse(), nil
+		}
+	}
+}
+
 func (m *Master) readLast(fileNames []string, lastFileName string) (*protocol.Response, error) {
-	index := filesystem.FindFile(fileNames, lastFileName)
+	index := filesystem.FindNextFile(fileNames, lastFileName)
 
-	if index == filesystem.NotFound || index == len(fileNames)-1 {
+	if index == filesystem.NotFound {
 		return protocol.UnfoundResponse(), nil
 	}
 
-	targetFileName := fileNames[index+1]
+	targetFileName := fileNames[index]
 
 	stats, err := os.Stat(m.directory + targetFileName)
 
@@ -136,11 +265,13 @@ func (m *Master) readLast(fileNames []string, lastFileName string) (*protocol.Re
 		return nil, errors.New("could not read last file")
 	}
 
-	data = data[:n]
+	data = m.decrypt(targetFileName, data[:n])
 
 	return protocol.OkResponseOneFile(targetFileName, data), nil
 }
 
+// readAll returns the records of every segment, a wal bigger than the chunk
+// continues as an offset read after the last record of the chunk.
 func (m *Master) readAll(fileNames []string) (*protocol.Response, error) {
 	files := make([][]byte, 0, len(fileNames))
 	files, err := filesystem.ReadAll(m.directory, fileNames, files)
@@ -149,5 +280,177 @@ func (m *Master) readAll(fileNames []string) (*protocol.Response, error) {
 		m.logger.Error("read all ended with problems files")
 	}
 
-	return protocol.OkResponseAllFiles(fileNames, files), err
+	d := m.newDelta()
+
+	for i, data := range files {
+		plain := m.decrypt(fileNames[i], data)
+		valid, _ := request.ValidLength(plain)
+
+		d.add(fileNames[i], plain[:valid], valid)
+	}
+
+	return d.response(), err
+}
+
+// Truncated records the lsn of the snapshot which replaced the removed segments,
+// a slave behind it could not catch up from the wal and is answered with a resync.
+func (m *Master) Truncated(lsn uint64) {
+	for {
+		current := m.truncated.Load()
+
+		if current >= lsn || m.truncated.CompareAndSwap(current, lsn) {
+			return
+		}
+	}
+}
+
+func (m *Master) readAfterLSN(fileNames []string, lsn uint64) (*protocol.Response, error) {
+	if truncated := m.truncated.Load(); lsn < truncated {
+		m.logger.Debug(fmt.Sprintf("lsn %d is before the truncated wal at lsn %d, slave needs a full sync", lsn, truncated))
+		return protocol.ResyncResponse(), nil
+	}
+
+	files := make([][]byte, 0, len(fileNames))
+	files, err := filesystem.ReadAll(m.directory, fileNames, files)
+
+	if err != nil {
+		m.logger.Error("read after lsn ended with problems files")
+	}
+
+	d := m.newDelta()
+
+	for i, data := range files {
+		plain := m.decrypt(fileNames[i], data)
+		valid, _ := request.ValidLength(plain)
+
+		d.add(fileNames[i], request.AfterLSN(plain, lsn), valid)
+	}
+
+	return d.response(), err
+}
+
+// readFromOffset returns the records after the byte offset of the segment, the position
+// is trusted only when the record ending at it has the lsn of the slave, otherwise
+// the segment is gone or rewritten by compaction and the lsn decides.
+func (m *Master) readFromOffset(fileNames []string, req *protocol.Request) (*protocol.Response, error) {
+	index := slices.Index(fileNames, req.FileName)
+
+	if index == -1 || req.Offset <= 0 {
+		return m.readAfterLSN(fileNames, req.LSN)
+	}
+
+	files := make([][]byte, 0, len(fileNames)-index)
+	files, err := filesystem.ReadAll(m.directory, fileNames[index:], files)
+
+	if err != nil {
+		m.logger.Error("read from offset ended with problems files")
+	}
+
+	if len(files) == 0 {
+		return m.readAfterLSN(fileNames, req.LSN)
+	}
+
+	plain := m.decrypt(req.FileName, files[0])
+	valid, _ := request.ValidLength(plain)
+
+	if req.Offset > int64(valid) {
+		m.logger.Debug(fmt.Sprintf("offset %d is out of segment %s, reading after lsn %d", req.Offset, req.FileName, req.LSN))
+		return m.readAfterLSN(fileNames, req.LSN)
+	}
+
+	last, lsnErr := request.LastLSN(plain[:req.Offset])
+
+	if lsnErr != nil || last != req.LSN {
+		m.logger.Debug(fmt.Sprintf("offset %d of segment %s is outdated, reading after lsn %d", req.Offset, req.FileName, req.LSN))
+		return m.readAfterLSN(fileNames, req.LSN)
+	}
+
+	d := m.newDelta()
+
+	d.add(req.FileName, plain[req.Offset:valid], valid)
+
+	for i, data := range files[1:] {
+		plain := m.decrypt(fileNames[index+1+i], data)
+		valid, _ := request.ValidLength(plain)
+
+		d.add(fileNames[index+1+i], plain[:valid], valid)
+	}
+
+	return d.response(), err
+}
+
+func (m *Master) newDelta() *delta {
+	return &delta{limit: m.maxChunkSize}
+}
+
+// delta collects the new records of the segments, it ends at the offset of the last one.
+// Records over the limit are cut between transactions and left to the next chunk,
+// an incomplete transaction at the end of a segment is left to the next request.
+type delta struct {
+	names   []string
+	records [][]byte
+	offset  int64
+	lsn     uint64
+
+	limit int
+	size  int
+	next  *protocol.Request
+}
+
+func (d *delta) add(fileName string, records []byte, end int) {
+	if len(records) == 0 || d.next != nil {
+		return
+	}
+
+	start := end - len(records)
+	records = records[:request.ChunkLength(records, len(records))]
+	cut := len(records)
+
+	if d.size+cut > d.limit {
+		cut = request.ChunkLength(records, d.limit-d.size)
+
+		if d.size > 0 && d.size+cut > d.limit {
+			cut = 0
+		}
+	}
+
+	if cut > 0 {
+		if lsn, _ := request.LastLSN(records[:cut]); lsn > 0 {
+			d.lsn = lsn
+		}
+
+		d.names = append(d.names, fileName)
+		d.records = append(d.records, records[:cut])
+		d.offset = int64(start + cut)
+		d.size += cut
+	}
+
+	if cut < len(records) {
+		d.next = protocol.ReadFromOffsetRequest(fileName, int64(start+cut), d.lsn)
+	}
+}
+
+func (d *delta) response() *protocol.Response {
+	if len(d.records) == 0 {
+		return protocol.UnfoundResponse()
+	}
+
+	resp := protocol.OkResponseUpToOffset(d.names, d.records, d.offset)
+
+	if d.next != nil {
+		resp.Token = protocol.NewToken(d.next)
+	}
+
+	return resp
+}
+
+// decrypt returns the records of a segment in plaintext, slaves encrypt them with their own key file.
+func (m *Master) decrypt(fileName string, data []byte) []byte {
+	plain, _, err := m.keyring.Decrypt(data)
+
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("could not decrypt segment %s: %s", fileName, err.Error()))
+	}
+
+	return plain
 }
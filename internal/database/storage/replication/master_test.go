@@ -2,15 +2,50 @@ package replication
 
 import (
 	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
 	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"inmemorykvdb/internal/database/storage/wal/manifest"
 	"inmemorykvdb/internal/network"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
 
+func newTestMaster(directory string) *Master {
+	segments, _ := manifest.NewManifest(zap.NewNop(), "wal", manifest.WithDirectory(directory))
+
+	return &Master{directory: directory, pattern: "wal", manifest: segments, logger: zap.NewNop(), maxChunkSize: defaultChunkSize}
+}
+
+type testFlushes struct {
+	mutex   sync.Mutex
+	flushed chan struct{}
+}
+
+func newTestFlushes() *testFlushes {
+	return &testFlushes{flushed: make(chan struct{})}
+}
+
+func (f *testFlushes) Flushed() <-chan struct{} {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.flushed
+}
+
+func (f *testFlushes) notify() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	close(f.flushed)
+	f.flushed = make(chan struct{})
+}
+
 func Test_NewMaster(t *testing.T) {
 	t.Parallel()
 
@@ -120,7 +155,7 @@ func Test_readLast(t *testing.T) {
 	directory := "C:/go/InMemoryKeyValueDB/test/master/readfile/"
 	serv, _ := network.NewServer(":8080", zap.NewNop())
 
-	master, _ := NewMaster(serv, zap.NewNop(), WithDirectoryMaster(directory))
+	master, _ := NewMaster(serv, zap.NewNop(), WithDirectoryMaster(directory), WithPatternMaster("wal"))
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
@@ -134,26 +169,189 @@ func Test_readLast(t *testing.T) {
 	}
 }
 
+func Test_readLastAfterCompaction(t *testing.T) {
+	directory := t.TempDir() + "/"
+
+	os.WriteFile(directory+"wal9.log", []byte("set biba boba"), 0644)
+	os.WriteFile(directory+"wal10.log", []byte("set boba biba"), 0644)
+
+	master := newTestMaster(directory)
+
+	resp, err := master.createResponse(protocol.ReadLastRequest("wal5.log"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.OkResponseOneFile("wal9.log", []byte("set biba boba")), resp)
+
+	resp, _ = master.createResponse(protocol.ReadLastRequest("wal9.log"))
+
+	assert.Equal(t, protocol.OkResponseOneFile("wal10.log", []byte("set boba biba")), resp)
+}
+
+func Test_readAfterLSN(t *testing.T) {
+	directory := t.TempDir() + "/"
+
+	lsnRecord := func(lsn uint64) []byte {
+		req := &request.Request{LSN: lsn, RequestType: commands.DelCommand, Args: []string{"biba"}}
+
+		data, _ := req.ParseToBytes()
+
+		return data
+	}
+
+	os.WriteFile(directory+"wal1.log", append(lsnRecord(1), lsnRecord(2)...), 0644)
+	os.WriteFile(directory+"wal2.log", lsnRecord(3), 0644)
+
+	master := newTestMaster(directory)
+
+	resp, err := master.createResponse(protocol.ReadAfterLSNRequest(1))
+
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.OkResponseUpToOffset([]string{"wal1.log", "wal2.log"}, [][]byte{lsnRecord(2), lsnRecord(3)},
+		int64(len(lsnRecord(3)))), resp)
+
+	resp, _ = master.createResponse(protocol.ReadAfterLSNRequest(2))
+
+	assert.Equal(t, protocol.OkResponseUpToOffset([]string{"wal2.log"}, [][]byte{lsnRecord(3)}, int64(len(lsnRecord(3)))), resp)
+
+	resp, _ = master.createResponse(protocol.ReadAfterLSNRequest(3))
+
+	assert.Equal(t, protocol.UnfoundResponse(), resp)
+}
+
+func Test_readAfterTruncatedLSN(t *testing.T) {
+	directory := t.TempDir() + "/"
+
+	os.WriteFile(directory+"wal3.log", lsnRecord(6), 0644)
+
+	master := newTestMaster(directory)
+	master.Truncated(5)
+	master.Truncated(4)
+
+	resp, err := master.createResponse(protocol.ReadAfterLSNRequest(3))
+
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.ResyncResponse(), resp)
+
+	resp, _ = master.createResponse(protocol.ReadFromOffsetRequest("wal1.log", 10, 3))
+
+	assert.Equal(t, protocol.ResyncResponse(), resp)
+
+	resp, _ = master.createResponse(protocol.ReadAfterLSNRequest(5))
+
+	assert.Equal(t, protocol.OkResponseUpToOffset([]string{"wal3.log"}, [][]byte{lsnRecord(6)}, int64(len(lsnRecord(6)))), resp)
+}
+
 func Test_readAll(t *testing.T) {
-	directory := "C:/go/InMemoryKeyValueDB/test/master/readall/"
-	serv, _ := network.NewServer(":8080", zap.NewNop())
+	directory := t.TempDir() + "/"
 
-	master, _ := NewMaster(serv, zap.NewNop(), WithDirectoryMaster(directory))
+	master := newTestMaster(directory)
 
-	data := [][]byte{[]byte("set biba boba"), []byte("get biba"), []byte("del biba"), []byte("set boba biba")}
+	data := [][]byte{lsnRecord(1), lsnRecord(2), lsnRecord(3), lsnRecord(4)}
 	fileNames := []string{"wal0.log", "wal1.log", "wal2.log", "wal3.log"}
 
 	for i, name := range fileNames {
 		os.WriteFile(directory+name, data[i], 0644)
 	}
 
-	expectedResp := protocol.OkResponseAllFiles(fileNames, data)
+	expectedResp := protocol.OkResponseUpToOffset(fileNames, data, int64(len(lsnRecord(4))))
 
 	resp, _ := master.readAll(fileNames)
 
 	assert.Equal(t, expectedResp, resp)
 }
 
+func Test_readChunks(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir() + "/"
+
+	os.WriteFile(directory+"wal1.log", append(append(lsnRecord(1), lsnRecord(2)...), lsnRecord(3)...), 0644)
+	os.WriteFile(directory+"wal2.log", append(lsnRecord(4), lsnRecord(5)...), 0644)
+
+	size := len(lsnRecord(1))
+
+	type testCase struct {
+		name string
+
+		req       *protocol.Request
+		chunkSize int
+
+		expectedNames   [][]string
+		expectedRecords [][][]byte
+	}
+
+	testCases := []testCase{
+		{
+			name: "read all by two records",
+
+			req:       protocol.ReadAllRequest(),
+			chunkSize: 2 * size,
+
+			expectedNames: [][]string{{"wal1.log"}, {"wal1.log", "wal2.log"}, {"wal2.log"}},
+			expectedRecords: [][][]byte{
+				{append(lsnRecord(1), lsnRecord(2)...)},
+				{lsnRecord(3), lsnRecord(4)},
+				{lsnRecord(5)},
+			},
+		},
+		{
+			name: "chunk smaller than record",
+
+			req:       protocol.ReadAfterLSNRequest(2),
+			chunkSize: 1,
+
+			expectedNames:   [][]string{{"wal1.log"}, {"wal2.log"}, {"wal2.log"}},
+			expectedRecords: [][][]byte{{lsnRecord(3)}, {lsnRecord(4)}, {lsnRecord(5)}},
+		},
+		{
+			name: "chunk fits whole delta",
+
+			req:       protocol.ReadFromOffsetRequest("wal1.log", int64(size), 1),
+			chunkSize: 10 * size,
+
+			expectedNames:   [][]string{{"wal1.log", "wal2.log"}},
+			expectedRecords: [][][]byte{{append(lsnRecord(2), lsnRecord(3)...), append(lsnRecord(4), lsnRecord(5)...)}},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			master := newTestMaster(directory)
+			master.maxChunkSize = test.chunkSize
+
+			req := test.req
+
+			for i := range test.expectedNames {
+				resp, err := master.createResponse(req)
+
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedNames[i], resp.FileNames)
+				assert.Equal(t, test.expectedRecords[i], resp.Data)
+
+				if i == len(test.expectedNames)-1 {
+					assert.Empty(t, resp.Token)
+					break
+				}
+
+				assert.NotEmpty(t, resp.Token)
+
+				req = protocol.ContinueRequest(resp.Token)
+			}
+		})
+	}
+}
+
+func Test_continueWithIncorrectToken(t *testing.T) {
+	t.Parallel()
+
+	master := newTestMaster(t.TempDir() + "/")
+
+	resp, err := master.createResponse(protocol.ContinueRequest("biba"))
+
+	assert.Nil(t, resp)
+	assert.Equal(t, protocol.ErrIncorrectToken, err)
+}
+
 func Test_createResponse(t *testing.T) {
 	type testCase struct {
 		name string
@@ -193,7 +391,7 @@ func Test_createResponse(t *testing.T) {
 
 	serv, _ := network.NewServer(":8080", zap.NewNop())
 
-	master, _ := NewMaster(serv, zap.NewNop(), WithDirectoryMaster("C:/go/InMemoryKeyValueDB/test/master/createresponse/"))
+	master, _ := NewMaster(serv, zap.NewNop(), WithDirectoryMaster("C:/go/InMemoryKeyValueDB/test/master/createresponse/"), WithPatternMaster("wal"))
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
@@ -268,7 +466,7 @@ func Test_Start(t *testing.T) {
 	}
 	serv, _ := network.NewServer(":8080", zap.NewNop())
 
-	master, _ := NewMaster(serv, zap.NewNop(), WithDirectoryMaster(directory))
+	master, _ := NewMaster(serv, zap.NewNop(), WithDirectoryMaster(directory), WithPatternMaster("wal"))
 	client, _ := network.NewClient(":8080")
 
 	master.start()
@@ -287,3 +485,198 @@ func Test_Start(t *testing.T) {
 		})
 	}
 }
+
+func Test_subscribe(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir() + "/"
+
+	os.WriteFile(directory+"wal1.log", lsnRecord(1), 0644)
+
+	master := newTestMaster(directory)
+
+	_, err := master.createResponse(protocol.SubscribeRequest("", 0, 0))
+
+	assert.Equal(t, errors.New("streaming is not enabled"), err)
+
+	flushes := newTestFlushes()
+
+	master.flushes = flushes
+	master.heartbeat = time.Hour
+
+	resp, err := master.createResponse(protocol.SubscribeRequest("", 0, 0))
+
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.OkResponseUpToOffset([]string{"wal1.log"}, [][]byte{lsnRecord(1)}, int64(len(lsnRecord(1)))), resp)
+
+	pushed := make(chan *protocol.Response)
+
+	go func() {
+		resp, _ := master.createResponse(protocol.SubscribeRequest("", 0, 1))
+		pushed <- resp
+	}()
+
+	select {
+	case <-pushed:
+		assert.Fail(t, "subscription is answered without new records")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	os.WriteFile(directory+"wal2.log", lsnRecord(2), 0644)
+	flushes.notify()
+
+	assert.Equal(t, protocol.OkResponseUpToOffset([]string{"wal2.log"}, [][]byte{lsnRecord(2)}, int64(len(lsnRecord(2)))), <-pushed)
+
+	master.heartbeat = 10 * time.Millisecond
+
+	resp, err = master.createResponse(protocol.SubscribeRequest("", 0, 2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.UnfoundResponse(), resp)
+}
+
+func Test_readFromOffset(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir() + "/"
+
+	os.WriteFile(directory+"wal1.log", append(lsnRecord(1), lsnRecord(2)...), 0644)
+	os.WriteFile(directory+"wal2.log", lsnRecord(3), 0644)
+
+	master := newTestMaster(directory)
+
+	size := int64(len(lsnRecord(1)))
+
+	type testCase struct {
+		name string
+
+		req *protocol.Request
+
+		expectedResponse *protocol.Response
+	}
+
+	testCases := []testCase{
+		{
+			name: "inside of segment",
+
+			req: protocol.ReadFromOffsetRequest("wal1.log", size, 1),
+
+			expectedResponse: protocol.OkResponseUpToOffset([]string{"wal1.log", "wal2.log"},
+				[][]byte{lsnRecord(2), lsnRecord(3)}, size),
+		},
+		{
+			name: "end of segment",
+
+			req: protocol.ReadFromOffsetRequest("wal1.log", 2*size, 2),
+
+			expectedResponse: protocol.OkResponseUpToOffset([]string{"wal2.log"}, [][]byte{lsnRecord(3)}, size),
+		},
+		{
+			name: "nothing new",
+
+			req: protocol.ReadFromOffsetRequest("wal2.log", size, 3),
+
+			expectedResponse: protocol.UnfoundResponse(),
+		},
+		{
+			name: "offset with other lsn",
+
+			req: protocol.ReadFromOffsetRequest("wal1.log", size, 2),
+
+			expectedResponse: protocol.OkResponseUpToOffset([]string{"wal2.log"}, [][]byte{lsnRecord(3)}, size),
+		},
+		{
+			name: "offset inside of record",
+
+			req: protocol.ReadFromOffsetRequest("wal1.log", 3, 1),
+
+			expectedResponse: protocol.OkResponseUpToOffset([]string{"wal1.log", "wal2.log"},
+				[][]byte{lsnRecord(2), lsnRecord(3)}, size),
+		},
+		{
+			name: "offset out of segment",
+
+			req: protocol.ReadFromOffsetRequest("wal2.log", 2*size, 1),
+
+			expectedResponse: protocol.OkResponseUpToOffset([]string{"wal1.log", "wal2.log"},
+				[][]byte{lsnRecord(2), lsnRecord(3)}, size),
+		},
+		{
+			name: "removed segment",
+
+			req: protocol.ReadFromOffsetRequest("wal0.log", size, 2),
+
+			expectedResponse: protocol.OkResponseUpToOffset([]string{"wal2.log"}, [][]byte{lsnRecord(3)}, size),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := master.createResponse(test.req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedResponse, resp)
+		})
+	}
+}
+
+func Test_readFromOffsetAfterAppend(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir() + "/"
+
+	os.WriteFile(directory+"wal1.log", lsnRecord(1), 0644)
+
+	master := newTestMaster(directory)
+
+	size := int64(len(lsnRecord(1)))
+
+	resp, _ := master.createResponse(protocol.ReadFromOffsetRequest("wal1.log", size, 1))
+
+	assert.Equal(t, protocol.UnfoundResponse(), resp)
+
+	file, _ := os.OpenFile(directory+"wal1.log", os.O_WRONLY|os.O_APPEND, 0644)
+	file.Write(append(lsnRecord(2), lsnRecord(3)[:5]...))
+	file.Close()
+
+	resp, _ = master.createResponse(protocol.ReadFromOffsetRequest("wal1.log", size, 1))
+
+	assert.Equal(t, protocol.OkResponseUpToOffset([]string{"wal1.log"}, [][]byte{lsnRecord(2)}, 2*size), resp)
+}
+
+func typedRecord(lsn uint64, requestType int) []byte {
+	req := &request.Request{LSN: lsn, RequestType: requestType}
+
+	if requestType == commands.SetCommand {
+		req.Args = []string{"biba", "boba"}
+	}
+
+	data, _ := req.ParseToBytes()
+
+	return data
+}
+
+func Test_readChunksKeepsTransactions(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir() + "/"
+
+	group := append(append(typedRecord(2, commands.MultiCommand), typedRecord(3, commands.SetCommand)...),
+		typedRecord(4, commands.ExecCommand)...)
+
+	os.WriteFile(directory+"wal1.log", append(append(lsnRecord(1), group...), typedRecord(5, commands.MultiCommand)...), 0644)
+
+	master := newTestMaster(directory)
+	master.maxChunkSize = 2 * len(lsnRecord(1))
+
+	resp, err := master.createResponse(protocol.ReadAllRequest())
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{lsnRecord(1)}, resp.Data)
+	assert.NotEmpty(t, resp.Token)
+
+	resp, err = master.createResponse(protocol.ContinueRequest(resp.Token))
+
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.OkResponseUpToOffset([]string{"wal1.log"}, [][]byte{group}, int64(len(lsnRecord(1))+len(group))), resp)
+}
@@ -0,0 +1,105 @@
+package replication
+
+import (
+	"encoding/json"
+	"fmt"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"os"
+	"path/filepath"
+)
+
+const (
+	positionExtension = ".pos"
+	tmpExtension      = ".tmp"
+)
+
+// position is the point of the master wal the slave has written up to:
+// the byte offset inside a segment of the master and the lsn of the last record there.
+type position struct {
+	FileName string `json:"file_name"`
+	Offset   int64  `json:"offset"`
+	LSN      uint64 `json:"lsn"`
+}
+
+// advance returns the position after the response, a master
+// which does not report offsets leaves only the lsn.
+func (p position) advance(resp *protocol.Response) position {
+	if len(resp.FileNames) == 0 || len(resp.FileNames) != len(resp.Data) {
+		return p
+	}
+
+	last, err := request.LastLSN(resp.Data[len(resp.Data)-1])
+
+	if err != nil {
+		return position{LSN: p.LSN}
+	}
+
+	if resp.Offset == 0 {
+		return position{LSN: max(p.LSN, last)}
+	}
+
+	return position{FileName: resp.FileNames[len(resp.FileNames)-1], Offset: resp.Offset, LSN: max(p.LSN, last)}
+}
+
+func loadPosition(path string) (position, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return position{}, err
+	}
+
+	var pos position
+
+	err = json.Unmarshal(data, &pos)
+
+	if err != nil {
+		return position{}, fmt.Errorf("replication position %s is corrupted", path)
+	}
+
+	return pos, nil
+}
+
+// savePosition replaces the position atomically through a synced temporary file.
+func savePosition(path string, pos position) error {
+	data, err := json.Marshal(pos)
+
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + tmpExtension
+
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+
+	if err != nil {
+		return fmt.Errorf("could not create replication position %s", tmpPath)
+	}
+
+	_, err = file.Write(data)
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	file.Close()
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("could not write replication position %s", tmpPath)
+	}
+
+	err = os.Rename(tmpPath, path)
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("could not replace replication position %s", path)
+	}
+
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package replication
+
+import (
+	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_advance(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		pos  position
+		resp *protocol.Response
+
+		expectedPosition position
+	}
+
+	testCases := []testCase{
+		{
+			name: "response with offset",
+
+			pos:  position{FileName: "wal1.log", Offset: 10, LSN: 1},
+			resp: protocol.OkResponseUpToOffset([]string{"wal1.log", "wal2.log"}, [][]byte{lsnRecord(2), lsnRecord(3)}, 19),
+
+			expectedPosition: position{FileName: "wal2.log", Offset: 19, LSN: 3},
+		},
+		{
+			name: "response without offset",
+
+			pos:  position{FileName: "wal1.log", Offset: 10, LSN: 1},
+			resp: protocol.OkResponseAllFiles([]string{"wal2.log"}, [][]byte{lsnRecord(3)}),
+
+			expectedPosition: position{LSN: 3},
+		},
+		{
+			name: "empty response",
+
+			pos:  position{FileName: "wal1.log", Offset: 10, LSN: 1},
+			resp: protocol.UnfoundResponse(),
+
+			expectedPosition: position{FileName: "wal1.log", Offset: 10, LSN: 1},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expectedPosition, test.pos.advance(test.resp))
+		})
+	}
+}
+
+func Test_saveAndLoadPosition(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir() + "/wal.pos"
+
+	_, err := loadPosition(path)
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	pos := position{FileName: "wal1.log", Offset: 19, LSN: 2}
+
+	err = savePosition(path, pos)
+
+	assert.NoError(t, err)
+
+	loaded, err := loadPosition(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, pos, loaded)
+
+	_, err = os.Stat(path + tmpExtension)
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	os.WriteFile(path, []byte("biba"), 0644)
+
+	_, err = loadPosition(path)
+
+	assert.EqualError(t, err, "replication position "+path+" is corrupted")
+}
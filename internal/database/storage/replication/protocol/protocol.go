@@ -1,25 +1,57 @@
 package protocol
 
-import "encoding/json"
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+)
 
 const (
 	OkStatus      = 0
 	ErrorStatus   = 1
 	UnfoundStatus = 2
+	ResyncStatus  = 3
+
+	ReadLast       = 0
+	ReadAll        = 1
+	ReadAfterLSN   = 2
+	Subscribe      = 3
+	ReadFromOffset = 4
+	Continue       = 5
+	FullSync       = 6
+	Ack            = 7
+)
 
-	ReadLast = 0
-	ReadAll  = 1
+var (
+	ErrChecksumMismatch = errors.New("chunk checksum mismatch")
+	ErrIncorrectToken   = errors.New("continuation token is incorrect")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
 )
 
+// Request of ReadFromOffset and Subscribe carries the position of the slave: the segment
+// of the master with the byte offset it is received up to and the lsn of the last record.
 type Request struct {
 	Type         int
 	LastFileName string `json:"last_file_name"`
+	LSN          uint64 `json:"lsn"`
+	FileName     string `json:"file_name"`
+	Offset       int64  `json:"offset"`
+	Token        string `json:"token"`
+	Replica      string `json:"replica"`
 }
 
+// Response of the lsn and offset requests ends at Offset of its last segment.
+// A response cut by the chunk size carries the Token of the request for the rest.
 type Response struct {
 	Status    int      `json:"status"`
 	FileNames []string `json:"file_name"`
 	Data      [][]byte `json:"data"`
+	Offset    int64    `json:"offset"`
+	Token     string   `json:"token"`
+	Checksum  uint32   `json:"checksum"`
 }
 
 func newRequest(reqType int, lastFileName string) *Request {
@@ -34,6 +66,87 @@ func ReadLastRequest(lastFileName string) *Request {
 	return newRequest(ReadLast, lastFileName)
 }
 
+func ReadAfterLSNRequest(lsn uint64) *Request {
+	req := newRequest(ReadAfterLSN, "")
+	req.LSN = lsn
+
+	return req
+}
+
+// ReadFromOffsetRequest asks for the records after the position, the master
+// falls back to the lsn when the segment is gone or rewritten by compaction.
+func ReadFromOffsetRequest(fileName string, offset int64, lsn uint64) *Request {
+	return newPositionRequest(ReadFromOffset, fileName, offset, lsn)
+}
+
+// SubscribeRequest asks the master to answer once it has records after the position.
+func SubscribeRequest(fileName string, offset int64, lsn uint64) *Request {
+	return newPositionRequest(Subscribe, fileName, offset, lsn)
+}
+
+// ContinueRequest asks for the next chunk of the response which returned the token.
+func ContinueRequest(token string) *Request {
+	req := newRequest(Continue, "")
+	req.Token = token
+
+	return req
+}
+
+// FullSyncRequest asks for a point-in-time dump of the master,
+// the wal of the master continues after the lsn of the dump.
+func FullSyncRequest() *Request {
+	return newRequest(FullSync, "")
+}
+
+// FullSyncChunkRequest asks for the dump of the lsn from the offset, it is sent in the token.
+func FullSyncChunkRequest(fileName string, offset int64, lsn uint64) *Request {
+	return newPositionRequest(FullSync, fileName, offset, lsn)
+}
+
+// AckRequest tells the master the lsn the replica has durably stored.
+func AckRequest(replica string, lsn uint64) *Request {
+	req := newRequest(Ack, "")
+	req.Replica = replica
+	req.LSN = lsn
+
+	return req
+}
+
+// NewToken encodes the request which continues a chunked response,
+// the master keeps no state between the chunks of the wal.
+func NewToken(req *Request) string {
+	data, _ := json.Marshal(req)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func ParseToken(token string) (*Request, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+
+	if err != nil {
+		return nil, ErrIncorrectToken
+	}
+
+	req := &Request{}
+
+	err = json.Unmarshal(data, req)
+
+	if err != nil || req.Type == Continue || req.Type == Subscribe || req.Type == Ack {
+		return nil, ErrIncorrectToken
+	}
+
+	return req, nil
+}
+
+func newPositionRequest(reqType int, fileName string, offset int64, lsn uint64) *Request {
+	req := newRequest(reqType, "")
+	req.FileName = fileName
+	req.Offset = offset
+	req.LSN = lsn
+
+	return req
+}
+
 func OkResponseOneFile(fileName string, data []byte) *Response {
 	return newResponse(OkStatus, []string{fileName}, [][]byte{data})
 }
@@ -42,14 +155,61 @@ func OkResponseAllFiles(fileNames []string, data [][]byte) *Response {
 	return newResponse(OkStatus, fileNames, data)
 }
 
+func OkResponseUpToOffset(fileNames []string, data [][]byte, offset int64) *Response {
+	resp := newResponse(OkStatus, fileNames, data)
+	resp.Offset = offset
+
+	return resp
+}
+
+// Seal sets the checksum of the chunk over its file names and data.
+func (r *Response) Seal() *Response {
+	r.Checksum = r.checksum()
+
+	return r
+}
+
+func (r *Response) Verify() error {
+	if r.Checksum != r.checksum() {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+func (r *Response) checksum() uint32 {
+	var crc uint32
+
+	for i, name := range r.FileNames {
+		crc = crc32.Update(crc, crcTable, binary.BigEndian.AppendUint32(nil, uint32(len(name))))
+		crc = crc32.Update(crc, crcTable, []byte(name))
+
+		if i < len(r.Data) {
+			crc = crc32.Update(crc, crcTable, binary.BigEndian.AppendUint32(nil, uint32(len(r.Data[i]))))
+			crc = crc32.Update(crc, crcTable, r.Data[i])
+		}
+	}
+
+	return crc
+}
+
 func newResponse(status int, fileName []string, data [][]byte) *Response {
 	return &Response{Status: status, FileNames: fileName, Data: data}
 }
 
+func OkResponse() *Response {
+	return newResponse(OkStatus, []string(nil), [][]byte(nil))
+}
+
 func UnfoundResponse() *Response {
 	return newResponse(UnfoundStatus, []string(nil), [][]byte(nil))
 }
 
+// ResyncResponse tells the slave its lsn is before the truncated wal and only a full sync restores it.
+func ResyncResponse() *Response {
+	return newResponse(ResyncStatus, []string(nil), [][]byte(nil))
+}
+
 func ErrorResponse(err error) *Response {
 	return newResponse(ErrorStatus, []string{"error"}, [][]byte{[]byte(err.Error())})
 }
@@ -66,3 +66,139 @@ func Test_Request(t *testing.T) {
 
 	assert.Equal(t, req, unmarshaled)
 }
+
+func Test_ReadAfterLSNRequest(t *testing.T) {
+	t.Parallel()
+
+	req := ReadAfterLSNRequest(42)
+
+	marshaled, err := Marshal(req)
+	assert.Nil(t, err)
+
+	unmarshaled := &Request{}
+	err = Unmarshal(unmarshaled, marshaled)
+	assert.Nil(t, err)
+
+	assert.Equal(t, &Request{Type: ReadAfterLSN, LSN: 42}, unmarshaled)
+}
+
+func Test_ReadFromOffsetRequest(t *testing.T) {
+	t.Parallel()
+
+	req := ReadFromOffsetRequest("wal1.log", 128, 42)
+
+	marshaled, err := Marshal(req)
+	assert.Nil(t, err)
+
+	unmarshaled := &Request{}
+	err = Unmarshal(unmarshaled, marshaled)
+	assert.Nil(t, err)
+
+	assert.Equal(t, &Request{Type: ReadFromOffset, FileName: "wal1.log", Offset: 128, LSN: 42}, unmarshaled)
+}
+
+func Test_OkResponseUpToOffset(t *testing.T) {
+	t.Parallel()
+
+	resp := OkResponseUpToOffset([]string{"wal1.log"}, [][]byte{[]byte("biba")}, 4)
+
+	marshaled, err := Marshal(resp)
+	assert.Nil(t, err)
+
+	unmarshaled := &Response{}
+	err = Unmarshal(unmarshaled, marshaled)
+	assert.Nil(t, err)
+
+	assert.Equal(t, &Response{Status: OkStatus, FileNames: []string{"wal1.log"}, Data: [][]byte{[]byte("biba")}, Offset: 4}, unmarshaled)
+}
+
+func Test_ParseToken(t *testing.T) {
+	t.Parallel()
+
+	next := ReadFromOffsetRequest("wal1.log", 128, 42)
+
+	type testCase struct {
+		name string
+
+		token string
+
+		expectedReq *Request
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "correct token",
+
+			token: NewToken(next),
+
+			expectedReq: next,
+		},
+		{
+			name: "not a base64",
+
+			token: "biba boba",
+
+			expectedErr: ErrIncorrectToken,
+		},
+		{
+			name: "continue in token",
+
+			token: NewToken(ContinueRequest("biba")),
+
+			expectedErr: ErrIncorrectToken,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := ParseToken(test.token)
+
+			assert.Equal(t, test.expectedReq, req)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func Test_Verify(t *testing.T) {
+	t.Parallel()
+
+	resp := OkResponseAllFiles([]string{"wal1.log", "wal2.log"}, [][]byte{[]byte("biba"), []byte("boba")}).Seal()
+
+	assert.NoError(t, resp.Verify())
+	assert.NoError(t, UnfoundResponse().Verify())
+
+	resp.Data[1] = []byte("bobo")
+
+	assert.Equal(t, ErrChecksumMismatch, resp.Verify())
+
+	moved := OkResponseAllFiles([]string{"wal1.log", "wal2.log"}, [][]byte{[]byte("bibab"), []byte("oba")})
+	moved.Checksum = OkResponseAllFiles([]string{"wal1.log", "wal2.log"}, [][]byte{[]byte("biba"), []byte("boba")}).Seal().Checksum
+
+	assert.Equal(t, ErrChecksumMismatch, moved.Verify())
+}
+
+func Test_FullSyncChunkRequest(t *testing.T) {
+	t.Parallel()
+
+	req, err := ParseToken(NewToken(FullSyncChunkRequest("wal1.log", 128, 42)))
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Request{Type: FullSync, FileName: "wal1.log", Offset: 128, LSN: 42}, req)
+	assert.Equal(t, &Request{Type: FullSync}, FullSyncRequest())
+}
+
+func Test_AckRequest(t *testing.T) {
+	t.Parallel()
+
+	marshaled, err := Marshal(AckRequest("biba", 42))
+	assert.Nil(t, err)
+
+	unmarshaled := &Request{}
+	err = Unmarshal(unmarshaled, marshaled)
+	assert.Nil(t, err)
+
+	assert.Equal(t, &Request{Type: Ack, Replica: "biba", LSN: 42}, unmarshaled)
+}
@@ -0,0 +1,164 @@
+package replication
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultAckTimeout lets a polling slave miss a few pulls before the write times out
+	defaultAckTimeout = 5 * defaultInterval
+	ackWait           = time.Second
+	replicaIDSize     = 8
+)
+
+var ErrReplicaTimeout = errors.New("write is not acknowledged by enough replicas in time")
+
+// WaitReplicas returns when the configured number of slaves have durably stored the lsn.
+func (m *Master) WaitReplicas(lsn uint64) error {
+	if m.minReplicas == 0 {
+		return nil
+	}
+
+	timeout := time.NewTimer(m.ackTimeout)
+	defer timeout.Stop()
+
+	for {
+		count, acked := m.replicasAt(lsn)
+
+		if count >= m.minReplicas {
+			return nil
+		}
+
+		select {
+		case <-acked:
+		case <-timeout.C:
+			return ErrReplicaTimeout
+		}
+	}
+}
+
+// replicasAt counts the slaves which have stored the lsn, the channel is closed by the next ack.
+func (m *Master) replicasAt(lsn uint64) (int, <-chan struct{}) {
+	m.ackMutex.Lock()
+	defer m.ackMutex.Unlock()
+
+	if m.acked == nil {
+		m.acked = make(chan struct{})
+	}
+
+	var count int
+
+	for _, acked := range m.acks {
+		if acked >= lsn {
+			count++
+		}
+	}
+
+	return count, m.acked
+}
+
+func (m *Master) acknowledge(req *protocol.Request) (*protocol.Response, error) {
+	if req.Replica == "" {
+		return nil, errors.New("replica could not be empty")
+	}
+
+	m.ackMutex.Lock()
+	defer m.ackMutex.Unlock()
+
+	if m.acks == nil {
+		m.acks = make(map[string]uint64)
+	}
+
+	m.acks[req.Replica] = max(m.acks[req.Replica], req.LSN)
+
+	if m.acked != nil {
+		close(m.acked)
+	}
+
+	m.acked = make(chan struct{})
+
+	return protocol.OkResponse(), nil
+}
+
+// acknowledge reports the applied records to the master once they are stored on disk.
+func (s *Slave) acknowledge() {
+	if s.lastLSN <= s.ackedLSN {
+		return
+	}
+
+	timeout := time.NewTimer(ackWait)
+	defer timeout.Stop()
+
+	for {
+		written := s.Written()
+
+		if s.durableLSN.Load() >= s.lastLSN {
+			break
+		}
+
+		select {
+		case <-written:
+		case <-timeout.C:
+			s.logger.Warn(fmt.Sprintf("records up to lsn %d are not written in time", s.lastLSN))
+			return
+		}
+	}
+
+	lsn := s.durableLSN.Load()
+
+	resp, err := s.exchange(protocol.AckRequest(s.id, lsn))
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+
+	if resp.Status == protocol.ErrorStatus {
+		s.logger.Debug("master does not accept acknowledgements")
+	}
+
+	s.ackedLSN = lsn
+}
+
+// Written returns a channel which is closed after the next records are stored on disk.
+func (s *Slave) Written() <-chan struct{} {
+	s.writtenMutex.Lock()
+	defer s.writtenMutex.Unlock()
+
+	if s.written == nil {
+		s.written = make(chan struct{})
+	}
+
+	return s.written
+}
+
+func (s *Slave) markDurable(lsn uint64) {
+	s.writtenMutex.Lock()
+	defer s.writtenMutex.Unlock()
+
+	s.durableLSN.Store(max(s.durableLSN.Load(), lsn))
+
+	if s.written != nil {
+		close(s.written)
+	}
+
+	s.written = make(chan struct{})
+}
+
+func newReplicaID() string {
+	id := make([]byte, replicaIDSize)
+
+	_, err := rand.Read(id)
+
+	if err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,67 @@
+package replication
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_WaitReplicas(t *testing.T) {
+	t.Parallel()
+
+	master := newTestMaster(t.TempDir() + "/")
+
+	assert.NoError(t, master.WaitReplicas(5))
+
+	master.minReplicas = 2
+	master.ackTimeout = 100 * time.Millisecond
+
+	_, err := master.createResponse(protocol.AckRequest("", 5))
+
+	assert.Equal(t, errors.New("replica could not be empty"), err)
+
+	resp, err := master.createResponse(protocol.AckRequest("biba", 5))
+
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.OkResponse(), resp)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		master.createResponse(protocol.AckRequest("boba", 7))
+	}()
+
+	assert.NoError(t, master.WaitReplicas(5))
+	assert.Equal(t, ErrReplicaTimeout, master.WaitReplicas(6))
+
+	master.createResponse(protocol.AckRequest("biba", 3))
+
+	assert.NoError(t, master.WaitReplicas(5))
+}
+
+func Test_acknowledge(t *testing.T) {
+	t.Parallel()
+
+	client := &scriptedClient{responses: []*protocol.Response{protocol.OkResponse()}}
+
+	slave := &Slave{slaveClient: client, logger: zap.NewNop(), id: "biba", lastLSN: 3}
+
+	slave.acknowledge()
+
+	assert.Empty(t, client.requests)
+	assert.Equal(t, uint64(0), slave.ackedLSN)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		slave.markDurable(3)
+	}()
+
+	slave.acknowledge()
+	slave.acknowledge()
+
+	assert.Equal(t, []*protocol.Request{protocol.AckRequest("biba", 3)}, client.requests)
+	assert.Equal(t, uint64(3), slave.ackedLSN)
+}
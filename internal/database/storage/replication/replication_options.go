@@ -2,6 +2,7 @@ package replication
 
 import (
 	"errors"
+	"inmemorykvdb/internal/database/storage/encryption"
 	"time"
 )
 
@@ -22,6 +23,103 @@ func WithDirectorySlave(directory string) SlaveOption {
 	}
 }
 
+func WithPatternMaster(pattern string) MasterOption {
+	return func(m *Master) error {
+		if pattern == "" {
+			return errors.New("pattern could not be a empty string")
+		}
+
+		m.pattern = pattern
+		return nil
+	}
+}
+
+func WithPatternSlave(pattern string) SlaveOption {
+	return func(s *Slave) error {
+		if pattern == "" {
+			return errors.New("pattern could not be a empty string")
+		}
+
+		s.pattern = pattern
+		return nil
+	}
+}
+
+func WithKeyringMaster(keyring *encryption.Keyring) MasterOption {
+	return func(m *Master) error {
+		if keyring == nil {
+			return errors.New("keyring could not be nil")
+		}
+
+		m.keyring = keyring
+		return nil
+	}
+}
+
+func WithKeyringSlave(keyring *encryption.Keyring) SlaveOption {
+	return func(s *Slave) error {
+		if keyring == nil {
+			return errors.New("keyring could not be nil")
+		}
+
+		s.keyring = keyring
+		return nil
+	}
+}
+
+// WithMaxChunkSize limits the records in one response, the rest is sent
+// in the next chunks requested by the continuation token.
+func WithMaxChunkSize(size int) MasterOption {
+	return func(m *Master) error {
+		if size <= 0 {
+			return errors.New("max chunk size should be positive")
+		}
+
+		m.maxChunkSize = size
+		return nil
+	}
+}
+
+// WithQuorum makes the writes on the master wait until the replicas
+// have stored them, a write without the quorum fails after the timeout.
+func WithQuorum(minReplicas int, timeout time.Duration) MasterOption {
+	return func(m *Master) error {
+		if minReplicas < 0 {
+			return errors.New("min replicas to ack could not be negative")
+		}
+
+		if timeout < 0 {
+			return errors.New("ack timeout could not be negative")
+		}
+
+		m.minReplicas = minReplicas
+		m.ackTimeout = timeout
+		return nil
+	}
+}
+
+// WithFlushes lets the master push new records to the streaming slaves
+// as soon as the wal flushes them.
+func WithFlushes(flushes flushNotifier) MasterOption {
+	return func(m *Master) error {
+		if flushes == nil {
+			return errors.New("flush notifier could not be nil")
+		}
+
+		m.flushes = flushes
+		return nil
+	}
+}
+
+// WithStreaming makes the slave subscribe to the master instead of polling it,
+// polling is kept while the stream is broken.
+func WithStreaming() SlaveOption {
+	return func(s *Slave) error {
+		s.streaming = true
+		return nil
+	}
+}
+
 func WithInterval(interval time.Duration) SlaveOption {
 	return func(s *Slave) error {
 		if interval == 0 {
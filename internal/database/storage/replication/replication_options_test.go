@@ -2,6 +2,7 @@ package replication
 
 import (
 	"errors"
+	"inmemorykvdb/internal/database/storage/encryption"
 	"testing"
 	"time"
 
@@ -76,3 +77,51 @@ func Test_WithInterval(t *testing.T) {
 		})
 	}
 }
+
+func Test_WithKeyring(t *testing.T) {
+	keyring, _ := encryption.NewKeyring([]byte("1:000102030405060708090a0b0c0d0e0f"))
+
+	master := &Master{}
+
+	assert.Equal(t, errors.New("keyring could not be nil"), WithKeyringMaster(nil)(master))
+	assert.NoError(t, WithKeyringMaster(keyring)(master))
+	assert.Equal(t, &Master{keyring: keyring}, master)
+
+	slave := &Slave{}
+
+	assert.Equal(t, errors.New("keyring could not be nil"), WithKeyringSlave(nil)(slave))
+	assert.NoError(t, WithKeyringSlave(keyring)(slave))
+	assert.Equal(t, &Slave{keyring: keyring}, slave)
+}
+
+func Test_WithMaxChunkSize(t *testing.T) {
+	master := &Master{}
+
+	assert.Equal(t, errors.New("max chunk size should be positive"), WithMaxChunkSize(0)(master))
+	assert.NoError(t, WithMaxChunkSize(1024)(master))
+	assert.Equal(t, &Master{maxChunkSize: 1024}, master)
+}
+
+func Test_WithQuorum(t *testing.T) {
+	master := &Master{}
+
+	assert.Equal(t, errors.New("min replicas to ack could not be negative"), WithQuorum(-1, time.Second)(master))
+	assert.Equal(t, errors.New("ack timeout could not be negative"), WithQuorum(1, -time.Second)(master))
+	assert.NoError(t, WithQuorum(2, time.Second)(master))
+	assert.Equal(t, &Master{minReplicas: 2, ackTimeout: time.Second}, master)
+}
+
+func Test_WithStreaming(t *testing.T) {
+	flushes := newTestFlushes()
+
+	master := &Master{}
+
+	assert.Equal(t, errors.New("flush notifier could not be nil"), WithFlushes(nil)(master))
+	assert.NoError(t, WithFlushes(flushes)(master))
+	assert.Equal(t, &Master{flushes: flushes}, master)
+
+	slave := &Slave{}
+
+	assert.NoError(t, WithStreaming()(slave))
+	assert.Equal(t, &Slave{streaming: true}, slave)
+}
@@ -1,10 +1,17 @@
 package replication
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/encryption"
 	"inmemorykvdb/internal/database/storage/filesystem"
 	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"inmemorykvdb/internal/database/storage/wal/manifest"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -12,6 +19,7 @@ import (
 
 const (
 	defaultInterval = 1 * time.Second
+	streamRetry     = 10 * time.Second
 	maxBatchSize    = 4096
 	delimElement    = ' '
 )
@@ -22,13 +30,29 @@ type client interface {
 
 type Slave struct {
 	directory       string
+	pattern         string
+	keyring         *encryption.Keyring
+	manifest        *manifest.Manifest
 	slaveClient     client
 	logger          *zap.Logger
 	requestInterval time.Duration
 
-	lastFileName string
+	lastLSN uint64
 
-	diskChannel chan *protocol.Response
+	// position is advanced and persisted only after the records reach the disk
+	position position
+
+	streaming     bool
+	streamRetryAt time.Time
+
+	needsFullSync bool
+
+	// durableLSN is stored after the records reach the disk, the master is acknowledged up to ackedLSN
+	id           string
+	durableLSN   atomic.Uint64
+	ackedLSN     uint64
+	writtenMutex sync.Mutex
+	written      chan struct{}
 
 	storageChannel chan *request.Batch
 	ticker         *time.Ticker
@@ -57,19 +81,39 @@ func NewSlave(slaveClient client, logger *zap.Logger, options ...SlaveOption) (*
 		slave.directory = defaultDirectory
 	}
 
-	lastFileName, err := filesystem.FindLastFile(slave.directory)
+	if slave.pattern == "" {
+		slave.pattern = defaultPattern
+	}
+
+	segments, err := manifest.NewManifest(logger, slave.pattern, manifest.WithDirectory(slave.directory))
+
+	if err != nil {
+		return nil, err
+	}
+
+	slave.manifest = segments
+
+	lastLSN, err := slave.localLSN()
+
+	if encryption.IsKeyError(err) {
+		return nil, err
+	}
 
 	if err != nil {
 		return nil, errors.New("incorrect directory")
 	}
 
-	slave.lastFileName = lastFileName
+	slave.id = newReplicaID()
+	slave.lastLSN = lastLSN
+	slave.ackedLSN = lastLSN
+	slave.durableLSN.Store(lastLSN)
+	slave.needsFullSync = lastLSN == 0
+	slave.position = slave.resumePosition()
 
 	if slave.requestInterval == 0 {
 		slave.requestInterval = defaultInterval
 	}
 
-	slave.diskChannel = make(chan *protocol.Response)
 	slave.ticker = time.NewTicker(slave.requestInterval)
 	slave.storageChannel = make(chan *request.Batch)
 
@@ -84,7 +128,6 @@ func (s *Slave) IsMaster() bool {
 
 func (s *Slave) start() {
 	go s.work()
-	go s.writeToDisk()
 }
 
 func (s *Slave) work() {
@@ -92,6 +135,21 @@ func (s *Slave) work() {
 		func() {
 			defer s.ticker.Reset(s.requestInterval)
 
+			if s.needsFullSync {
+				err := s.fullSync()
+
+				if err != nil {
+					s.logger.Error(err.Error())
+					return
+				}
+
+				s.acknowledge()
+			}
+
+			if s.streaming && !time.Now().Before(s.streamRetryAt) {
+				s.stream()
+			}
+
 			resp, err := s.pull()
 
 			if err != nil {
@@ -99,19 +157,153 @@ func (s *Slave) work() {
 				return
 			}
 
-			if s.hasNewFiles(resp) {
-				s.diskChannel <- resp
-				s.sendToStorage(resp)
+			err = s.apply(resp)
+
+			if err != nil {
+				s.logger.Error(err.Error())
+				return
 			}
+
+			s.follow(resp)
+			s.acknowledge()
 		}()
 	}
 }
 
+// stream subscribes to the master again after every answer,
+// it returns when the stream breaks and the slave falls back to polling.
+func (s *Slave) stream() {
+	s.logger.Debug("started streaming from master")
+
+	for {
+		resp, err := s.subscribe()
+
+		if err != nil {
+			s.logger.Warn(fmt.Sprintf("stream is broken, falling back to polling: %s", err.Error()))
+			s.streamRetryAt = time.Now().Add(streamRetry)
+			return
+		}
+
+		err = s.apply(resp)
+
+		if err != nil && s.needsFullSync {
+			s.logger.Warn(err.Error())
+			return
+		}
+
+		if err != nil {
+			s.logger.Warn(fmt.Sprintf("stream is broken, falling back to polling: %s", err.Error()))
+			s.streamRetryAt = time.Now().Add(streamRetry)
+			return
+		}
+
+		s.acknowledge()
+	}
+}
+
+// follow requests the rest of a chunked response until the master sends the last chunk.
+func (s *Slave) follow(resp *protocol.Response) {
+	for resp.Token != "" {
+		s.logger.Debug("started pulling the next chunk")
+
+		next, err := s.exchange(protocol.ContinueRequest(resp.Token))
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return
+		}
+
+		err = s.apply(next)
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return
+		}
+
+		resp = next
+	}
+}
+
+func (s *Slave) subscribe() (*protocol.Response, error) {
+	resp, err := s.exchange(protocol.SubscribeRequest(s.position.FileName, s.position.Offset, s.lastLSN))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status == protocol.ErrorStatus {
+		return nil, fmt.Errorf("master refused the stream: %s", bytes.Join(resp.Data, nil))
+	}
+
+	return resp, nil
+}
+
+// apply keeps the position when the records could not be loaded or written,
+// so they are requested again from the last records on disk.
+func (s *Slave) apply(resp *protocol.Response) error {
+	if resp.Status == protocol.ResyncStatus {
+		s.needsFullSync = true
+		return errors.New("master truncated the wal after the slave position, full sync is needed")
+	}
+
+	if !s.hasNewFiles(resp) {
+		return nil
+	}
+
+	batch, err := loadBatch(resp)
+
+	if err != nil {
+		return fmt.Errorf("could not apply records from master: %w", err)
+	}
+
+	err = s.write(resp)
+
+	if err != nil {
+		return fmt.Errorf("could not write records from master: %w", err)
+	}
+
+	s.sendToStorage(batch)
+
+	return nil
+}
+
+// resumePosition trusts the persisted position only when it matches the local wal,
+// a crash between appending the records and saving the position leaves it behind.
+func (s *Slave) resumePosition() position {
+	pos, err := loadPosition(s.positionPath())
+
+	if errors.Is(err, os.ErrNotExist) {
+		return position{LSN: s.lastLSN}
+	}
+
+	if err != nil {
+		s.logger.Warn(err.Error())
+		return position{LSN: s.lastLSN}
+	}
+
+	if pos.LSN != s.lastLSN {
+		s.logger.Warn(fmt.Sprintf("replication position %s:%d does not match local lsn %d, resuming after the lsn",
+			pos.FileName, pos.Offset, s.lastLSN))
+		return position{LSN: s.lastLSN}
+	}
+
+	s.logger.Debug(fmt.Sprintf("resuming replication from %s:%d", pos.FileName, pos.Offset))
+
+	return pos
+}
+
+func (s *Slave) positionPath() string {
+	return s.directory + s.pattern + positionExtension
+}
+
 func (s *Slave) pull() (*protocol.Response, error) {
 
 	s.logger.Debug("started pulling a request")
 
-	req := s.createRequest()
+	return s.exchange(s.createRequest())
+}
+
+func (s *Slave) exchange(req *protocol.Request) (*protocol.Response, error) {
 
 	s.logger.Debug("started marshalling request")
 
@@ -140,6 +332,13 @@ func (s *Slave) pull() (*protocol.Response, error) {
 		return nil, err
 	}
 
+	err = resp.Verify()
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
 	return resp, nil
 }
 
@@ -155,59 +354,140 @@ func (s *Slave) send(data []byte) ([]byte, error) {
 	return resp, nil
 }
 
+func (s *Slave) localLSN() (uint64, error) {
+	_, err := os.Stat(s.directory)
+
+	if err != nil {
+		return 0, err
+	}
+
+	segments, err := s.manifest.Segments()
+
+	if err != nil {
+		return 0, err
+	}
+
+	fileNames := make([]string, 0, len(segments))
+
+	for _, seg := range segments {
+		fileNames = append(fileNames, seg.Name)
+	}
+
+	files := make([][]byte, 0, len(fileNames))
+	files, err = filesystem.ReadAll(s.directory, fileNames, files)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+	}
+
+	batch := request.NewBatch(0)
+
+	for i, data := range files {
+		plain, _, err := s.keyring.Decrypt(data)
+
+		if encryption.IsKeyError(err) {
+			return 0, fmt.Errorf("could not read segment %s: %w", fileNames[i], err)
+		}
+
+		batch.LoadData(plain)
+	}
+
+	return batch.LastLSN, nil
+}
+
 func (s *Slave) createRequest() *protocol.Request {
-	req := &protocol.Request{}
+	s.logger.Debug("creating read after lsn request")
 
-	if s.lastFileName == "" {
-		s.logger.Debug("creating read all request")
-		req = protocol.ReadAllRequest()
-	} else {
-		s.logger.Debug("creating read last request")
-		req = protocol.ReadLastRequest(s.lastFileName)
+	if s.position.FileName != "" {
+		return protocol.ReadFromOffsetRequest(s.position.FileName, s.position.Offset, s.lastLSN)
 	}
 
-	return req
+	return protocol.ReadAfterLSNRequest(s.lastLSN)
 }
 
 func (s *Slave) hasNewFiles(resp *protocol.Response) bool {
-	if resp.Status == protocol.UnfoundStatus || len(resp.FileNames) == 0 {
-		return false
+	return resp.Status != protocol.UnfoundStatus && len(resp.FileNames) != 0
+}
+
+// write appends the records to the local segments and advances the position after them.
+func (s *Slave) write(resp *protocol.Response) error {
+	s.logger.Debug("started write files to disk")
+
+	fileNames, fileData, err := s.localSegments(resp)
+
+	if err != nil {
+		return err
+	}
+
+	err = filesystem.AppendFiles(s.directory, fileNames, fileData)
+
+	if err != nil {
+		return err
+	}
+
+	s.position = s.position.advance(resp)
+	s.markDurable(s.position.LSN)
+
+	err = savePosition(s.positionPath(), s.position)
+
+	if err != nil {
+		s.logger.Error(err.Error())
 	}
 
-	s.lastFileName = resp.FileNames[len(resp.FileNames)-1]
+	s.logger.Debug("writing is done")
 
-	return true
+	return nil
 }
 
-/*
-	start gourutine for send to server
-	start gourutine for write on disk
-	for timeout send req to master
-	if response is nil do nothing
-	if response has data send it to write module
-*/
+// localSegments maps the segments of the master to the local manifest by their index.
+func (s *Slave) localSegments(resp *protocol.Response) ([]string, [][]byte, error) {
+	fileNames := make([]string, 0, len(resp.FileNames))
+	fileData := make([][]byte, 0, len(resp.FileNames))
+
+	for i, name := range resp.FileNames {
+		index, ok := manifest.ParseIndex(s.pattern, name)
 
-func (s *Slave) writeToDisk() {
-	for resp := range s.diskChannel {
-		s.logger.Debug("started write files to disk")
+		if !ok || i >= len(resp.Data) {
+			s.logger.Error(fmt.Sprintf("skipped unexpected segment %s from master", name))
+			continue
+		}
 
-		err := filesystem.WriteFiles(s.directory, resp.FileNames, resp.Data)
+		seg, err := s.manifest.Add(index)
 
 		if err != nil {
-			s.logger.Error(err.Error())
+			return nil, nil, err
 		}
 
-		s.logger.Debug("writing is done")
+		data, err := s.keyring.Encrypt(resp.Data[i])
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fileNames = append(fileNames, seg.Name)
+		fileData = append(fileData, data)
 	}
+
+	return fileNames, fileData, nil
 }
 
-func (s *Slave) sendToStorage(resp *protocol.Response) {
+func loadBatch(resp *protocol.Response) (*request.Batch, error) {
 	batch := request.NewBatch(maxBatchSize)
 
 	for _, data := range resp.Data {
-		batch.LoadData(data)
+		err := batch.LoadData(data)
+
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	return batch, nil
+}
+
+func (s *Slave) sendToStorage(batch *request.Batch) {
+	s.lastLSN = max(s.lastLSN, batch.LastLSN)
+
 	s.storageChannel <- batch
 }
 
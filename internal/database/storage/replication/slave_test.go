@@ -6,6 +6,7 @@ import (
 	"inmemorykvdb/internal/database/request"
 	"inmemorykvdb/internal/database/storage/filesystem"
 	"inmemorykvdb/internal/database/storage/replication/protocol"
+	"inmemorykvdb/internal/database/storage/wal/manifest"
 	"inmemorykvdb/internal/network"
 	"os"
 	"sync"
@@ -16,6 +17,50 @@ import (
 	"go.uber.org/zap"
 )
 
+func lsnRecord(lsn uint64) []byte {
+	req := &request.Request{LSN: lsn, RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}
+
+	data, _ := req.ParseToBytes()
+
+	return data
+}
+
+type scriptedClient struct {
+	responses []*protocol.Response
+	requests  []*protocol.Request
+
+	corrupt bool
+}
+
+func (c *scriptedClient) Send(data []byte) ([]byte, error) {
+	req := &protocol.Request{}
+	protocol.Unmarshal(req, data)
+
+	c.requests = append(c.requests, req)
+
+	if len(c.responses) == 0 {
+		return nil, errors.New("failed to read data")
+	}
+
+	resp := c.responses[0].Seal()
+	c.responses = c.responses[1:]
+
+	if c.corrupt {
+		resp.Checksum++
+	}
+
+	return protocol.Marshal(resp)
+}
+
+func newTestSlave(t *testing.T, slaveClient client) *Slave {
+	directory := t.TempDir() + "/"
+
+	segments, _ := manifest.NewManifest(zap.NewNop(), "wal", manifest.WithDirectory(directory))
+
+	return &Slave{slaveClient: slaveClient, logger: zap.NewNop(), directory: directory, pattern: "wal", manifest: segments,
+		storageChannel: make(chan *request.Batch, 2)}
+}
+
 func Test_NewSlave(t *testing.T) {
 	type testCase struct {
 		name string
@@ -101,73 +146,53 @@ func Test_NewSlave(t *testing.T) {
 	}
 }
 
-func Test_writeToDisk(t *testing.T) {
-	dir := "C:/go/InMemoryKeyValueDB/test/slave/writetodisk/"
-	resps := []*protocol.Response{
-		{
-			Status:    protocol.OkStatus,
-			FileNames: []string{"wal.log"},
-			Data: [][]byte{
-				[]byte("set biba boba"),
-			},
-		},
-		{
-			Status:    protocol.OkStatus,
-			FileNames: []string{"wal1.log"},
-			Data: [][]byte{
-				[]byte("del biba"),
-			},
-		},
-		{
-			Status:    protocol.OkStatus,
-			FileNames: []string{"wal2.log"},
-			Data: [][]byte{
-				[]byte("set boba biba"),
-			},
-		},
-	}
+func Test_write(t *testing.T) {
+	t.Parallel()
 
-	client, _ := network.NewClient(":8080")
-	slave, _ := NewSlave(client, zap.NewNop(), WithDirectorySlave(dir))
+	slave := newTestSlave(t, &scriptedClient{})
 
-	block := make(chan struct{})
+	size := int64(len(lsnRecord(1)))
 
-	go func() {
-		defer func() {
-			block <- struct{}{}
-		}()
+	resps := []*protocol.Response{
+		protocol.OkResponseUpToOffset([]string{"wal1.log"}, [][]byte{lsnRecord(1)}, size),
+		protocol.OkResponseUpToOffset([]string{"wal1.log", "wal2.log"}, [][]byte{lsnRecord(2), lsnRecord(3)}, size),
+	}
 
-		slave.writeToDisk()
-	}()
+	for _, resp := range resps {
+		assert.NoError(t, slave.write(resp))
+	}
 
-	wg := &sync.WaitGroup{}
+	actualData := make([][]byte, 0, 2)
+	actualData, _ = filesystem.ReadAll(slave.directory, []string{manifest.SegmentName("wal", 1), manifest.SegmentName("wal", 2)}, actualData)
 
-	wg.Add(len(resps))
+	assert.Equal(t, [][]byte{append(lsnRecord(1), lsnRecord(2)...), lsnRecord(3)}, actualData)
+	assert.Equal(t, position{FileName: "wal2.log", Offset: size, LSN: 3}, slave.position)
+	assert.Equal(t, uint64(3), slave.durableLSN.Load())
 
-	for i := range len(resps) {
-		go func() {
-			defer wg.Done()
-			slave.diskChannel <- resps[i]
-		}()
-	}
+	os.RemoveAll(slave.directory)
 
-	wg.Wait()
-	close(slave.diskChannel)
-	<-block
+	err := slave.write(protocol.OkResponseUpToOffset([]string{"wal2.log"}, [][]byte{lsnRecord(4)}, 2*size))
 
-	fileNames := make([]string, len(resps))
-	expectedData := make([][]byte, 0, len(resps))
+	assert.Error(t, err)
+	assert.Equal(t, position{FileName: "wal2.log", Offset: size, LSN: 3}, slave.position)
+	assert.Equal(t, uint64(3), slave.durableLSN.Load())
+}
 
-	for i, resp := range resps {
-		fileNames[i] = resp.FileNames[0]
-		expectedData = append(expectedData, resp.Data...)
-	}
+func Test_applyKeepsPositionOnFailedWrite(t *testing.T) {
+	t.Parallel()
+
+	slave := newTestSlave(t, &scriptedClient{})
+	slave.lastLSN = 1
+	slave.position = position{LSN: 1}
 
-	actualData := make([][]byte, 0, len(resps))
+	os.RemoveAll(slave.directory)
 
-	actualData, _ = filesystem.ReadAll(dir, fileNames, actualData)
+	err := slave.apply(protocol.OkResponseAllFiles([]string{"wal1.log"}, [][]byte{lsnRecord(2)}))
 
-	assert.Equal(t, expectedData, actualData)
+	assert.ErrorContains(t, err, "could not write records from master")
+	assert.Equal(t, position{LSN: 1}, slave.position)
+	assert.Equal(t, protocol.ReadAfterLSNRequest(1), slave.createRequest())
+	assert.Empty(t, slave.storageChannel)
 }
 
 func Test_hasNewFiles(t *testing.T) {
@@ -176,8 +201,7 @@ func Test_hasNewFiles(t *testing.T) {
 
 		resp *protocol.Response
 
-		expectedAnswer       bool
-		expectedLastFileName string
+		expectedAnswer bool
 	}
 
 	testCases := []testCase{
@@ -186,15 +210,13 @@ func Test_hasNewFiles(t *testing.T) {
 
 			resp: &protocol.Response{Status: protocol.OkStatus, FileNames: []string{"wal.log"}},
 
-			expectedAnswer:       true,
-			expectedLastFileName: "wal.log",
+			expectedAnswer: true,
 		},
 		{
 			name: "has not new files",
 			resp: &protocol.Response{Status: protocol.UnfoundStatus},
 
-			expectedAnswer:       false,
-			expectedLastFileName: "",
+			expectedAnswer: false,
 		},
 	}
 
@@ -206,7 +228,6 @@ func Test_hasNewFiles(t *testing.T) {
 			ans := slave.hasNewFiles(test.resp)
 
 			assert.Equal(t, test.expectedAnswer, ans)
-			assert.Equal(t, test.expectedLastFileName, slave.lastFileName)
 		})
 	}
 }
@@ -220,22 +241,27 @@ func Test_createRequest(t *testing.T) {
 		expectedRequest *protocol.Request
 	}
 
+	notEmpty := t.TempDir() + "/"
+
+	os.WriteFile(notEmpty+"wal1.log", lsnRecord(4), 0644)
+	os.WriteFile(notEmpty+"wal2.log", lsnRecord(5), 0644)
+
 	testCases := []testCase{
 		{
-			name: "read all req",
+			name: "read from the beginning",
 
-			directory: "C:/go/InMemoryKeyValueDB/test/slave/createrequest/empty/",
+			directory: t.TempDir() + "/",
 
-			expectedRequest: &protocol.Request{Type: protocol.ReadAll},
+			expectedRequest: &protocol.Request{Type: protocol.ReadAfterLSN},
 		},
 		{
-			name: "read last req",
+			name: "read after local lsn",
 
-			directory: "C:/go/InMemoryKeyValueDB/test/slave/createrequest/notempty/",
+			directory: notEmpty,
 
 			expectedRequest: &protocol.Request{
-				Type:         protocol.ReadLast,
-				LastFileName: "wal.log",
+				Type: protocol.ReadAfterLSN,
+				LSN:  5,
 			},
 		},
 	}
@@ -243,7 +269,7 @@ func Test_createRequest(t *testing.T) {
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
 			client, _ := network.NewClient(":8080")
-			slave, _ := NewSlave(client, zap.NewNop(), WithDirectorySlave(test.directory))
+			slave, _ := NewSlave(client, zap.NewNop(), WithDirectorySlave(test.directory), WithPatternSlave("wal"))
 
 			req := slave.createRequest()
 
@@ -274,7 +300,7 @@ func Test_send(t *testing.T) {
 func Test_pull(t *testing.T) {
 	masterDir := "C:/go/InMemoryKeyValueDB/test/slave/pull/masterdir/"
 	fileNames := []string{"wal0.log", "wal1.log", "wal2.log"}
-	fileData := [][]byte{[]byte("set biba boba"), []byte("del biba"), []byte("set boba biba")}
+	fileData := [][]byte{lsnRecord(1), lsnRecord(2), lsnRecord(3)}
 
 	i := 0
 	filesystem.ForEach(masterDir, fileNames, func(file *os.File) error {
@@ -284,14 +310,14 @@ func Test_pull(t *testing.T) {
 	})
 
 	server, _ := network.NewServer(":8080", zap.NewNop())
-	master, _ := NewMaster(server, zap.NewNop(), WithDirectoryMaster(masterDir))
+	master, _ := NewMaster(server, zap.NewNop(), WithDirectoryMaster(masterDir), WithPatternMaster("wal"))
 	master.start()
 
 	type testCase struct {
 		name string
 
-		dir          string
-		lastFileName string
+		dir       string
+		localData []byte
 
 		expectedResponse *protocol.Response
 		expectedErr      error
@@ -301,25 +327,27 @@ func Test_pull(t *testing.T) {
 		{
 			name: "read all req",
 
-			dir:          "C:/go/InMemoryKeyValueDB/test/slave/pull/slavedirreadall/",
-			lastFileName: "",
+			dir:       t.TempDir() + "/",
+			localData: nil,
 
-			expectedResponse: &protocol.Response{
+			expectedResponse: (&protocol.Response{
 				Status:    protocol.OkStatus,
 				FileNames: fileNames,
-				Data:      fileData},
+				Data:      fileData,
+				Offset:    int64(len(fileData[2]))}).Seal(),
 			expectedErr: nil,
 		},
 
 		{
-			name: "read last req",
+			name: "read after lsn req",
 
-			dir:          "C:/go/InMemoryKeyValueDB/test/slave/pull/slavedirreadlast/",
-			lastFileName: fileNames[0],
-			expectedResponse: &protocol.Response{
+			dir:       t.TempDir() + "/",
+			localData: fileData[0],
+			expectedResponse: (&protocol.Response{
 				Status:    protocol.OkStatus,
-				FileNames: []string{fileNames[1]},
-				Data:      [][]byte{fileData[1]}},
+				FileNames: fileNames[1:],
+				Data:      fileData[1:],
+				Offset:    int64(len(fileData[2]))}).Seal(),
 			expectedErr: nil,
 		},
 	}
@@ -327,10 +355,12 @@ func Test_pull(t *testing.T) {
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
 
-			os.Create(test.dir + test.lastFileName)
+			if test.localData != nil {
+				os.WriteFile(test.dir+fileNames[0], test.localData, 0644)
+			}
 
 			client, _ := network.NewClient(":8080")
-			slave, _ := NewSlave(client, zap.NewNop(), WithDirectorySlave(test.dir))
+			slave, _ := NewSlave(client, zap.NewNop(), WithDirectorySlave(test.dir), WithPatternSlave("wal"))
 
 			resp, err := slave.pull()
 
@@ -343,7 +373,7 @@ func Test_pull(t *testing.T) {
 func Test_StartSlave(t *testing.T) {
 	masterDir := "C:/go/InMemoryKeyValueDB/test/slave/start/masterdir/"
 	fileNames := []string{"wal0.log", "wal1.log", "wal2.log"}
-	fileData := [][]byte{[]byte("SET biba boba\n"), []byte("DEL biba\n"), []byte("SET boba biba\n")}
+	fileData := [][]byte{lsnRecord(1), lsnRecord(2), lsnRecord(3)}
 
 	i := 0
 	filesystem.ForEach(masterDir, fileNames, func(file *os.File) error {
@@ -353,13 +383,13 @@ func Test_StartSlave(t *testing.T) {
 	})
 
 	server, _ := network.NewServer(":8080", zap.NewNop())
-	master, _ := NewMaster(server, zap.NewNop(), WithDirectoryMaster(masterDir))
+	master, _ := NewMaster(server, zap.NewNop(), WithDirectoryMaster(masterDir), WithPatternMaster("wal"))
 
 	master.start()
 
 	slaveDir := "C:/go/InMemoryKeyValueDB/test/slave/start/slavedir/"
 	client, _ := network.NewClient(":8080")
-	slave, _ := NewSlave(client, zap.NewNop(), WithDirectorySlave(slaveDir))
+	slave, _ := NewSlave(client, zap.NewNop(), WithDirectorySlave(slaveDir), WithPatternSlave("wal"))
 
 	slave.start()
 
@@ -399,6 +429,7 @@ func Test_sendToStorage(t *testing.T) {
 				Args: []string{"biba", "boba"},
 			},
 			{
+				LSN:         3,
 				RequestType: commands.SetCommand,
 				Args:        []string{"BOBA", "BABA"},
 			},
@@ -408,28 +439,174 @@ func Test_sendToStorage(t *testing.T) {
 	resp := &protocol.Response{
 		Status:    protocol.OkStatus,
 		FileNames: []string{"wal.log"},
-		Data: [][]byte{
-			[]byte("SET biba boba\n"),
-			[]byte("DEL biba boba\n"),
-			[]byte("SET BOBA BABA\n"),
-		},
+		Data:      make([][]byte, len(expectedBatch.Data)),
+	}
+
+	for i, req := range expectedBatch.Data {
+		resp.Data[i], _ = req.ParseToBytes()
 	}
 
 	client, _ := network.NewClient(":8080")
 	slave, _ := NewSlave(client, zap.NewNop())
 
-	var batch *request.Batch
+	var sent *request.Batch
 	var wg sync.WaitGroup
 	wg.Add(1)
 
 	go func() {
 		defer wg.Done()
-		batch = <-slave.storageChannel
+		sent = <-slave.storageChannel
 	}()
 
-	slave.sendToStorage(resp)
+	batch, err := loadBatch(resp)
+
+	assert.NoError(t, err)
+
+	slave.sendToStorage(batch)
 
 	wg.Wait()
 
-	assert.Equal(t, expectedBatch.Data, batch.Data)
+	assert.Equal(t, expectedBatch.Data, sent.Data)
+	assert.Equal(t, uint64(3), slave.lastLSN)
+}
+
+func Test_localSegments(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	os.WriteFile(dir+"wal9.log", lsnRecord(1), 0644)
+
+	client, _ := network.NewClient(":8080")
+	slave, _ := NewSlave(client, zap.NewNop(), WithDirectorySlave(dir), WithPatternSlave("wal"))
+
+	resp := protocol.OkResponseAllFiles([]string{"wal9.log", "wal10.log", "biba.log"},
+		[][]byte{lsnRecord(2), lsnRecord(3), lsnRecord(4)})
+
+	fileNames, fileData, err := slave.localSegments(resp)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"wal9.log", manifest.SegmentName("wal", 10)}, fileNames)
+	assert.Equal(t, [][]byte{lsnRecord(2), lsnRecord(3)}, fileData)
+}
+
+func Test_stream(t *testing.T) {
+	t.Parallel()
+
+	client := &scriptedClient{responses: []*protocol.Response{
+		protocol.OkResponseAllFiles([]string{"wal1.log"}, [][]byte{lsnRecord(1)}),
+		protocol.OkResponse(),
+		protocol.UnfoundResponse(),
+		protocol.OkResponseAllFiles([]string{"wal1.log"}, [][]byte{lsnRecord(2)}),
+		protocol.OkResponse(),
+		protocol.ErrorResponse(errors.New("streaming is not enabled")),
+	}}
+
+	slave := newTestSlave(t, client)
+	slave.streaming = true
+	slave.id = "biba"
+
+	slave.stream()
+
+	assert.Equal(t, []*protocol.Request{
+		protocol.SubscribeRequest("", 0, 0),
+		protocol.AckRequest("biba", 1),
+		protocol.SubscribeRequest("", 0, 1),
+		protocol.SubscribeRequest("", 0, 1),
+		protocol.AckRequest("biba", 2),
+		protocol.SubscribeRequest("", 0, 2),
+	}, client.requests)
+
+	assert.Equal(t, uint64(2), slave.lastLSN)
+	assert.Len(t, slave.storageChannel, 2)
+	assert.True(t, slave.streamRetryAt.After(time.Now()))
+}
+
+func Test_follow(t *testing.T) {
+	t.Parallel()
+
+	first := protocol.OkResponseAllFiles([]string{"wal1.log"}, [][]byte{lsnRecord(1)})
+	first.Token = "biba"
+
+	second := protocol.OkResponseAllFiles([]string{"wal1.log"}, [][]byte{lsnRecord(2)})
+	second.Token = "boba"
+
+	client := &scriptedClient{responses: []*protocol.Response{
+		second,
+		protocol.OkResponseAllFiles([]string{"wal2.log"}, [][]byte{lsnRecord(3)}),
+	}}
+
+	slave := newTestSlave(t, client)
+
+	slave.follow(first)
+
+	assert.Equal(t, []*protocol.Request{
+		protocol.ContinueRequest("biba"),
+		protocol.ContinueRequest("boba"),
+	}, client.requests)
+
+	assert.Equal(t, uint64(3), slave.lastLSN)
+	assert.Len(t, slave.storageChannel, 2)
+}
+
+func Test_exchangeWithCorruptedChunk(t *testing.T) {
+	t.Parallel()
+
+	client := &scriptedClient{corrupt: true, responses: []*protocol.Response{
+		protocol.OkResponseAllFiles([]string{"wal1.log"}, [][]byte{lsnRecord(1)}),
+	}}
+
+	slave := &Slave{slaveClient: client, logger: zap.NewNop()}
+
+	resp, err := slave.exchange(protocol.ReadAllRequest())
+
+	assert.Nil(t, resp)
+	assert.Equal(t, protocol.ErrChecksumMismatch, err)
+}
+
+func Test_resumeFromPosition(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	slave, err := NewSlave(&scriptedClient{}, zap.NewNop(), WithDirectorySlave(dir), WithPatternSlave("wal"),
+		WithInterval(time.Hour))
+
+	assert.NoError(t, err)
+
+	size := int64(len(lsnRecord(1)))
+
+	slave.write(protocol.OkResponseUpToOffset([]string{"wal1.log"}, [][]byte{append(lsnRecord(1), lsnRecord(2)...)}, 2*size))
+
+	pos, _ := loadPosition(dir + "wal.pos")
+
+	assert.Equal(t, position{FileName: "wal1.log", Offset: 2 * size, LSN: 2}, pos)
+
+	restarted, _ := NewSlave(&scriptedClient{}, zap.NewNop(), WithDirectorySlave(dir), WithPatternSlave("wal"),
+		WithInterval(time.Hour))
+
+	assert.Equal(t, protocol.ReadFromOffsetRequest("wal1.log", 2*size, 2), restarted.createRequest())
+
+	savePosition(dir+"wal.pos", position{FileName: "wal1.log", Offset: size, LSN: 1})
+
+	behind, _ := NewSlave(&scriptedClient{}, zap.NewNop(), WithDirectorySlave(dir), WithPatternSlave("wal"),
+		WithInterval(time.Hour))
+
+	assert.Equal(t, protocol.ReadAfterLSNRequest(2), behind.createRequest())
+}
+
+func Test_applyIncompleteTransaction(t *testing.T) {
+	t.Parallel()
+
+	slave := newTestSlave(t, &scriptedClient{})
+	slave.position = position{LSN: 1}
+
+	resp := protocol.OkResponseUpToOffset([]string{"wal1.log"},
+		[][]byte{append(lsnRecord(2), typedRecord(3, commands.MultiCommand)...)}, 100)
+
+	err := slave.apply(resp)
+
+	assert.EqualError(t, err, "could not apply records from master: has incomplete transaction")
+	assert.Equal(t, position{LSN: 1}, slave.position)
+	assert.Zero(t, slave.lastLSN)
+	assert.Zero(t, slave.durableLSN.Load())
+	assert.Empty(t, slave.storageChannel)
 }
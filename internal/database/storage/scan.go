@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultScanCount = 10
+)
+
+func parseScan(args []string) (uint64, string, int, error) {
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+
+	if err != nil {
+		return 0, "", 0, errors.New("incorrect cursor")
+	}
+
+	var match string
+	count := defaultScanCount
+
+	for i := 1; i+1 < len(args); i += 2 {
+		switch args[i] {
+		case commands.MatchModifier:
+			match = args[i+1]
+		case commands.CountModifier:
+			count, err = strconv.Atoi(args[i+1])
+
+			if err != nil || count <= 0 {
+				return 0, "", 0, errors.New("incorrect count")
+			}
+		}
+	}
+
+	return cursor, match, count, nil
+}
+
+func formatScan(next uint64, keys []string) string {
+	return strings.Join(append([]string{strconv.FormatUint(next, 10)}, keys...), pairEndElement)
+}
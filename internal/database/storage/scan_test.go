@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_parseScan(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		args []string
+
+		expectedCursor uint64
+		expectedMatch  string
+		expectedCount  int
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "only cursor",
+
+			args: []string{"42"},
+
+			expectedCursor: 42,
+			expectedMatch:  "",
+			expectedCount:  defaultScanCount,
+			expectedErr:    nil,
+		},
+		{
+			name: "cursor with modifiers",
+
+			args: []string{"0", commands.MatchModifier, "user:*", commands.CountModifier, "100"},
+
+			expectedCursor: 0,
+			expectedMatch:  "user:*",
+			expectedCount:  100,
+			expectedErr:    nil,
+		},
+		{
+			name: "incorrect cursor",
+
+			args: []string{"-1"},
+
+			expectedErr: errors.New("incorrect cursor"),
+		},
+		{
+			name: "incorrect count",
+
+			args: []string{"0", commands.CountModifier, "biba"},
+
+			expectedErr: errors.New("incorrect count"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cursor, match, count, err := parseScan(test.args)
+
+			assert.Equal(t, test.expectedCursor, cursor)
+			assert.Equal(t, test.expectedMatch, match)
+			assert.Equal(t, test.expectedCount, count)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func Test_HandleScanRequest(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"user:1", "biba"}})
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"order:1", "boba"}})
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"user:2", "biba"}})
+
+	answer, err := stor.HandleRequest(request.Request{RequestType: commands.ScanCommand, Args: []string{"0", commands.MatchModifier, "user:*"}})
+
+	assert.NoError(t, err)
+
+	lines := strings.Split(answer, "\n")
+
+	assert.Equal(t, "0", lines[0])
+	assert.ElementsMatch(t, []string{"user:1", "user:2"}, lines[1:])
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.ScanCommand, Args: []string{"0", commands.CountModifier, "1"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1\nuser:1", answer)
+}
@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"time"
+)
+
+const (
+	stringType = "string"
+	hashType   = "hash"
+	listType   = "list"
+	setType    = "set"
+	zsetType   = "zset"
+
+	backgroundSaveAnswer = "BACKGROUND SAVING STARTED"
+)
+
+var errBusy = errors.New("snapshot saving or wal compaction is already in progress")
+
+type snapshotLayer interface {
+	Save(segment uint64, lsn uint64, reqs []request.Request) error
+	Load() (uint64, *request.Batch, error)
+}
+
+// fullSyncReplica serves the new slaves with the dump of the storage instead of the whole wal.
+type fullSyncReplica interface {
+	ServeFullSync(dump func() (uint64, []request.Request))
+}
+
+// truncatingReplica learns the lsn of the snapshot which replaced the removed segments.
+type truncatingReplica interface {
+	Truncated(lsn uint64)
+}
+
+type segmentedWal interface {
+	Checkpoint() uint64
+	ReadAfter(segment uint64) *request.Batch
+	Truncate(segment uint64) error
+}
+
+func (s *Storage) Save() error {
+	if !s.maintaining.CompareAndSwap(false, true) {
+		return errBusy
+	}
+
+	defer s.maintaining.Store(false)
+
+	segment, lsn, reqs, err := s.capture()
+
+	if err != nil {
+		return err
+	}
+
+	return s.persist(segment, lsn, reqs)
+}
+
+func (s *Storage) BackgroundSave() error {
+	if !s.maintaining.CompareAndSwap(false, true) {
+		return errBusy
+	}
+
+	segment, lsn, reqs, err := s.capture()
+
+	if err != nil {
+		s.maintaining.Store(false)
+		return err
+	}
+
+	go func() {
+		defer s.maintaining.Store(false)
+
+		err := s.persist(segment, lsn, reqs)
+
+		if err != nil {
+			s.logger.Error(err.Error())
+		}
+	}()
+
+	return nil
+}
+
+func (s *Storage) capture() (uint64, uint64, []request.Request, error) {
+	if s.snapshot == nil {
+		return 0, 0, nil, errors.New("snapshots are not configured")
+	}
+
+	if s.replica != nil && !s.replica.IsMaster() {
+		return 0, 0, nil, errors.New("slave node could not save snapshot")
+	}
+
+	s.gate.Lock()
+	defer s.gate.Unlock()
+
+	var segment uint64
+
+	if segmented, ok := s.wal.(segmentedWal); ok {
+		segment = segmented.Checkpoint()
+	}
+
+	return segment, s.lsn(), s.dump(), nil
+}
+
+// pointInTime returns the dump with the lsn of the last request in it,
+// the pending batch is flushed first so the lsn covers every request in the dump.
+func (s *Storage) pointInTime() (uint64, []request.Request) {
+	s.gate.Lock()
+	defer s.gate.Unlock()
+
+	if segmented, ok := s.wal.(segmentedWal); ok {
+		segmented.Checkpoint()
+	}
+
+	return s.lsn(), s.dump()
+}
+
+func (s *Storage) persist(segment uint64, lsn uint64, reqs []request.Request) error {
+	err := s.snapshot.Save(segment, lsn, reqs)
+
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info(fmt.Sprintf("snapshot is saved at wal segment %d with lsn %d", segment, lsn))
+
+	return s.truncate(segment, lsn)
+}
+
+func (s *Storage) truncate(segment uint64, lsn uint64) error {
+	segmented, ok := s.wal.(segmentedWal)
+
+	if !ok || segment == 0 || !s.isLogging() {
+		return nil
+	}
+
+	if truncating, ok := s.replica.(truncatingReplica); ok {
+		truncating.Truncated(lsn)
+	}
+
+	return segmented.Truncate(segment)
+}
+
+func (s *Storage) dump() []request.Request {
+	return s.dumpWith(s.engine.DUMP)
+}
+
+func (s *Storage) dumpKeys(keys []string) []request.Request {
+	return s.dumpWith(func(visit func(key string, kind string, items []string, deadline time.Time)) {
+		s.engine.DUMPKEYS(keys, visit)
+	})
+}
+
+// dumpWith turns the visited keys into the requests which restore them.
+func (s *Storage) dumpWith(dump func(visit func(key string, kind string, items []string, deadline time.Time))) []request.Request {
+	reqs := make([]request.Request, 0)
+
+	dump(func(key string, kind string, items []string, deadline time.Time) {
+		args := append([]string{key}, items...)
+
+		switch kind {
+		case stringType:
+			if !deadline.IsZero() {
+				args = append(args, commands.ExpireAtMilliseconds, formatUnixMilli(deadline))
+			}
+
+			reqs = append(reqs, request.Request{RequestType: commands.SetCommand, Args: args})
+			return
+		case hashType:
+			reqs = append(reqs, request.Request{RequestType: commands.HSetCommand, Args: args})
+		case listType:
+			reqs = append(reqs, request.Request{RequestType: commands.RPushCommand, Args: args})
+		case setType:
+			reqs = append(reqs, request.Request{RequestType: commands.SAddCommand, Args: args})
+		case zsetType:
+			reqs = append(reqs, request.Request{RequestType: commands.ZAddCommand, Args: args})
+		default:
+			s.logger.Error(fmt.Sprintf("could not dump key %s of unknown type %s", key, kind))
+			return
+		}
+
+		if !deadline.IsZero() {
+			reqs = append(reqs, request.Request{RequestType: commands.ExpireAtCommand, Args: []string{key, formatUnixMilli(deadline)}})
+		}
+	})
+
+	return reqs
+}
+
+// recoverableWal reports a read which stopped before the end of the wal.
+type recoverableWal interface {
+	RecoveryError() error
+}
+
+// recover fails on a snapshot it could not load, the segments it replaced are already removed.
+func (s *Storage) recover() error {
+	var segment, lsn uint64
+
+	if s.snapshot != nil {
+		loaded, batch, err := s.snapshot.Load()
+
+		if err != nil {
+			return err
+		}
+
+		if batch != nil {
+			segment, lsn = loaded, batch.LastLSN
+			s.recoverData(batch)
+			s.advanceLSN(batch.LastLSN)
+		}
+	}
+
+	if s.wal == nil {
+		return nil
+	}
+
+	var recovered *request.Batch
+
+	if segmented, ok := s.wal.(segmentedWal); ok {
+		recovered = segmented.ReadAfter(segment)
+	} else {
+		recovered = s.wal.Read()
+	}
+
+	if recoverable, ok := s.wal.(recoverableWal); ok && recoverable.RecoveryError() != nil {
+		return recoverable.RecoveryError()
+	}
+
+	if recovered != nil {
+		s.recoverData(recovered)
+	}
+
+	err := s.truncate(segment, lsn)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+	}
+
+	return nil
+}
+
+func (s *Storage) scheduleSnapshots() {
+	ticker := time.NewTicker(s.snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		err := s.Save()
+
+		if err != nil && !errors.Is(err, errBusy) {
+			s.logger.Error(err.Error())
+		}
+	}
+}
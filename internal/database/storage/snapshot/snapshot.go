@@ -0,0 +1,281 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/encryption"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultFileName = "dump.snap"
+	tmpExtension    = ".tmp"
+
+	legacyFormatVersion = 1
+	formatVersion       = 2
+	checksumSize        = 4
+)
+
+var magic = []byte("IMKVSNAP")
+
+type Snapshot struct {
+	directory string
+	fileName  string
+
+	keyring *encryption.Keyring
+	logger  *zap.Logger
+}
+
+func NewSnapshot(logger *zap.Logger, options ...SnapshotOption) (*Snapshot, error) {
+	if logger == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	snap := &Snapshot{logger: logger}
+
+	for _, option := range options {
+		err := option(snap)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if snap.fileName == "" {
+		snap.fileName = defaultFileName
+	}
+
+	return snap, nil
+}
+
+func (s *Snapshot) Save(segment uint64, lsn uint64, reqs []request.Request) error {
+	s.logger.Debug("started save snapshot")
+
+	err := os.MkdirAll(s.directory, 0755)
+
+	if err != nil {
+		return fmt.Errorf("could not create snapshot directory %s", s.directory)
+	}
+
+	path := filepath.Join(s.directory, s.fileName)
+	tmpPath := path + tmpExtension
+
+	data, err := s.keyring.Encrypt(Encode(segment, lsn, reqs))
+
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(tmpPath, data)
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	err = os.Rename(tmpPath, path)
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("could not replace snapshot %s", path)
+	}
+
+	syncDirectory(s.directory)
+
+	s.logger.Debug(fmt.Sprintf("snapshot with %d requests is saved", len(reqs)))
+
+	return nil
+}
+
+func (s *Snapshot) Load() (uint64, *request.Batch, error) {
+	s.logger.Debug("started load snapshot")
+
+	data, err := os.ReadFile(filepath.Join(s.directory, s.fileName))
+
+	if errors.Is(err, os.ErrNotExist) {
+		s.logger.Debug("snapshot is not found")
+		return 0, nil, nil
+	}
+
+	if err != nil {
+		return 0, nil, fmt.Errorf("could not read snapshot with error %s", err.Error())
+	}
+
+	data, _, err = s.keyring.Decrypt(data)
+
+	if err != nil {
+		return 0, nil, fmt.Errorf("could not decrypt snapshot: %w", err)
+	}
+
+	return Decode(data)
+}
+
+func Encode(segment uint64, lsn uint64, reqs []request.Request) []byte {
+	buf := &bytes.Buffer{}
+
+	buf.Write(magic)
+	buf.WriteByte(formatVersion)
+
+	buf.Write(binary.AppendUvarint(nil, segment))
+	buf.Write(binary.AppendUvarint(nil, lsn))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(reqs))))
+
+	for _, req := range reqs {
+		buf.Write(binary.AppendUvarint(nil, uint64(req.RequestType)))
+		buf.Write(binary.AppendUvarint(nil, uint64(len(req.Args))))
+
+		for _, arg := range req.Args {
+			buf.Write(binary.AppendUvarint(nil, uint64(len(arg))))
+			buf.WriteString(arg)
+		}
+	}
+
+	return binary.BigEndian.AppendUint32(buf.Bytes(), crc32.ChecksumIEEE(buf.Bytes()))
+}
+
+func Decode(data []byte) (uint64, *request.Batch, error) {
+	if len(data) < len(magic)+1+checksumSize || !bytes.Equal(data[:len(magic)], magic) {
+		return 0, nil, errors.New("snapshot has incorrect format")
+	}
+
+	body := data[:len(data)-checksumSize]
+
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(data[len(body):]) {
+		return 0, nil, errors.New("snapshot is corrupted")
+	}
+
+	version := body[len(magic)]
+
+	if version != formatVersion && version != legacyFormatVersion {
+		return 0, nil, fmt.Errorf("snapshot has unsupported version %d", version)
+	}
+
+	reader := &decoder{data: body[len(magic)+1:]}
+
+	segment := reader.uvarint()
+
+	var lsn uint64
+
+	if version != legacyFormatVersion {
+		lsn = reader.uvarint()
+	}
+
+	count := reader.uvarint()
+
+	if reader.err != nil || count > uint64(len(reader.data)) {
+		return 0, nil, errors.New("snapshot has incorrect format")
+	}
+
+	batch := request.NewBatch(int(count))
+	batch.LastLSN = lsn
+
+	for range count {
+		req := &request.Request{RequestType: int(reader.uvarint())}
+
+		argsCount := reader.uvarint()
+
+		if argsCount > uint64(len(reader.data)) {
+			return 0, nil, errors.New("snapshot has incorrect format")
+		}
+
+		req.Args = make([]string, 0, argsCount)
+
+		for range argsCount {
+			req.Args = append(req.Args, reader.string())
+		}
+
+		if reader.err != nil {
+			return 0, nil, reader.err
+		}
+
+		batch.Add(req)
+	}
+
+	if len(reader.data) != 0 {
+		return 0, nil, errors.New("snapshot has trailing data")
+	}
+
+	return segment, batch, nil
+}
+
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	value, n := binary.Uvarint(d.data)
+
+	if n <= 0 {
+		d.err = errors.New("snapshot has incorrect format")
+		return 0
+	}
+
+	d.data = d.data[n:]
+
+	return value
+}
+
+func (d *decoder) string() string {
+	length := d.uvarint()
+
+	if d.err != nil {
+		return ""
+	}
+
+	if length > uint64(len(d.data)) {
+		d.err = errors.New("snapshot has incorrect format")
+		return ""
+	}
+
+	value := string(d.data[:length])
+	d.data = d.data[length:]
+
+	return value
+}
+
+func writeFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+
+	if err != nil {
+		return fmt.Errorf("could not create snapshot file %s", path)
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(data)
+
+	if err != nil {
+		return fmt.Errorf("could not write snapshot file %s", path)
+	}
+
+	err = file.Sync()
+
+	if err != nil {
+		return fmt.Errorf("could not sync snapshot file %s", path)
+	}
+
+	return nil
+}
+
+func syncDirectory(directory string) {
+	dir, err := os.Open(directory)
+
+	if err != nil {
+		return
+	}
+
+	dir.Sync()
+	dir.Close()
+}
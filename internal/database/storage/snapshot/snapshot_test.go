@@ -0,0 +1,207 @@
+package snapshot
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/encryption"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_NewSnapshot(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		logger  *zap.Logger
+		options []SnapshotOption
+
+		expectedNilObj bool
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "correct snapshot",
+
+			logger:  zap.NewNop(),
+			options: []SnapshotOption{WithDirectory("biba/"), WithFileName("boba.snap")},
+
+			expectedNilObj: false,
+			expectedErr:    nil,
+		},
+		{
+			name: "snapshot without logger",
+
+			logger: nil,
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("logger is nil"),
+		},
+		{
+			name: "snapshot with empty directory",
+
+			logger:  zap.NewNop(),
+			options: []SnapshotOption{WithDirectory("")},
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("directory could not be a empty string"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			snap, err := NewSnapshot(test.logger, test.options...)
+
+			assert.Equal(t, test.expectedNilObj, snap == nil)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func Test_SaveAndLoadSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	snap, _ := NewSnapshot(zap.NewNop(), WithDirectory(dir))
+
+	segment, batch, err := snap.Load()
+
+	assert.NoError(t, err)
+	assert.Zero(t, segment)
+	assert.Nil(t, batch)
+
+	reqs := []request.Request{
+		{RequestType: commands.SetCommand, Args: []string{"biba", "boba with spaces\n", commands.ExpireAtMilliseconds, "4102444800000"}},
+		{RequestType: commands.HSetCommand, Args: []string{"user", "name", ""}},
+	}
+
+	err = snap.Save(12, 40, reqs)
+
+	assert.NoError(t, err)
+
+	segment, batch, err = snap.Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12), segment)
+	assert.Equal(t, uint64(40), batch.LastLSN)
+	assert.Equal(t, []*request.Request{&reqs[0], &reqs[1]}, batch.Data)
+
+	_, err = os.Stat(filepath.Join(dir, defaultFileName+tmpExtension))
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_DecodeCorruptedSnapshot(t *testing.T) {
+	t.Parallel()
+
+	data := Encode(3, 0, []request.Request{{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}})
+
+	type testCase struct {
+		name string
+
+		data []byte
+
+		expectedErr error
+	}
+
+	corrupted := append([]byte(nil), data...)
+	corrupted[len(magic)+3] ^= 0xFF
+
+	testCases := []testCase{
+		{
+			name: "flipped byte",
+
+			data: corrupted,
+
+			expectedErr: errors.New("snapshot is corrupted"),
+		},
+		{
+			name: "truncated file",
+
+			data: data[:len(data)-1],
+
+			expectedErr: errors.New("snapshot is corrupted"),
+		},
+		{
+			name: "unknown format",
+
+			data: []byte("biba boba biba boba"),
+
+			expectedErr: errors.New("snapshot has incorrect format"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, batch, err := Decode(test.data)
+
+			assert.Nil(t, batch)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func Test_DecodeLegacySnapshot(t *testing.T) {
+	t.Parallel()
+
+	body := append([]byte(nil), magic...)
+	body = append(body, legacyFormatVersion, 3, 1, byte(commands.SetCommand), 2, 4, 'b', 'i', 'b', 'a', 4, 'b', 'o', 'b', 'a')
+
+	data := binary.BigEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+
+	segment, batch, err := Decode(data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), segment)
+	assert.Zero(t, batch.LastLSN)
+	assert.Equal(t, []*request.Request{{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}}, batch.Data)
+}
+
+func Test_SaveAndLoadEncryptedSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	keyring, _ := encryption.NewKeyring([]byte("1:000102030405060708090a0b0c0d0e0f"))
+	wrongKeyring, _ := encryption.NewKeyring([]byte("1:ffffffffffffffffffffffffffffffff"))
+
+	snap, _ := NewSnapshot(zap.NewNop(), WithDirectory(dir), WithKeyring(keyring))
+
+	reqs := []request.Request{{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}}
+
+	err := snap.Save(3, 7, reqs)
+
+	assert.NoError(t, err)
+
+	data, _ := os.ReadFile(filepath.Join(dir, defaultFileName))
+
+	assert.True(t, encryption.IsEncrypted(data))
+	assert.NotContains(t, string(data), "boba")
+
+	segment, batch, err := snap.Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), segment)
+	assert.Equal(t, []*request.Request{&reqs[0]}, batch.Data)
+
+	wrongSnap, _ := NewSnapshot(zap.NewNop(), WithDirectory(dir), WithKeyring(wrongKeyring))
+
+	_, batch, err = wrongSnap.Load()
+
+	assert.ErrorIs(t, err, encryption.ErrWrongKey)
+	assert.Nil(t, batch)
+}
@@ -0,0 +1,41 @@
+package snapshot
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/storage/encryption"
+)
+
+type SnapshotOption func(*Snapshot) error
+
+func WithDirectory(dir string) SnapshotOption {
+	return func(s *Snapshot) error {
+		if dir == "" {
+			return errors.New("directory could not be a empty string")
+		}
+
+		s.directory = dir
+		return nil
+	}
+}
+
+func WithFileName(fileName string) SnapshotOption {
+	return func(s *Snapshot) error {
+		if fileName == "" {
+			return errors.New("file name could not be a empty string")
+		}
+
+		s.fileName = fileName
+		return nil
+	}
+}
+
+func WithKeyring(keyring *encryption.Keyring) SnapshotOption {
+	return func(s *Snapshot) error {
+		if keyring == nil {
+			return errors.New("keyring could not be nil")
+		}
+
+		s.keyring = keyring
+		return nil
+	}
+}
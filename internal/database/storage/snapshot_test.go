@@ -0,0 +1,362 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/encryption"
+	"inmemorykvdb/internal/database/storage/engine"
+	"inmemorykvdb/internal/database/storage/snapshot"
+	"inmemorykvdb/internal/database/storage/wal"
+	"inmemorykvdb/internal/database/storage/wal/readlevel"
+	"inmemorykvdb/internal/database/storage/wal/writelevel"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newPersistentStorage(t *testing.T, dir string) (*Storage, *engine.InMemoryEngine) {
+	t.Helper()
+
+	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir), writelevel.WithFileName("wal"))
+	rl, _ := readlevel.NewReadLevel(zap.NewNop(), "wal", readlevel.WithDirectory(dir))
+	writeAheadLog, _ := wal.NewWal(zap.NewNop(), wal.WithBatchSize(100), wal.WithBatchTimeout(time.Hour),
+		wal.WithWriter(wl), wal.WithReader(rl), wal.WithDurability(wal.DurabilityAlways))
+	snap, _ := snapshot.NewSnapshot(zap.NewNop(), snapshot.WithDirectory(filepath.Join(dir, "snapshot")))
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, err := NewStorage(zap.NewNop(), eng, WithWal(writeAheadLog), WithSnapshot(snap))
+
+	assert.NoError(t, err)
+
+	return stor, eng
+}
+
+func writtenSegments(dir string) []string {
+	segments, _ := filepath.Glob(dir + "wal*.log")
+
+	return slices.DeleteFunc(segments, func(name string) bool {
+		stat, err := os.Stat(name)
+		return err == nil && stat.Size() == 0
+	})
+}
+
+func encodeRequests(reqs ...request.Request) []byte {
+	batch := request.NewBatch(len(reqs))
+
+	for i := range reqs {
+		batch.Add(&reqs[i])
+	}
+
+	data, _ := batch.ParseBatch()
+
+	return data
+}
+
+func Test_SaveAndRecover(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	stor, _ := newPersistentStorage(t, dir)
+
+	reqs := []request.Request{
+		{RequestType: commands.SetCommand, Args: []string{"biba", "boba", commands.ExpireSeconds, "3600"}},
+		{RequestType: commands.RPushCommand, Args: []string{"queue", "a", "b"}},
+		{RequestType: commands.ZAddCommand, Args: []string{"board", "1.5", "biba"}},
+		{RequestType: commands.HSetCommand, Args: []string{"user", "name", "biba"}},
+		{RequestType: commands.ExpireCommand, Args: []string{"user", "3600"}},
+		{RequestType: commands.SaveCommand},
+		{RequestType: commands.RPushCommand, Args: []string{"queue", "c"}},
+		{RequestType: commands.SAddCommand, Args: []string{"tags", "x"}},
+	}
+
+	for _, req := range reqs {
+		_, err := stor.HandleRequest(req)
+
+		assert.NoError(t, err)
+	}
+
+	stor.wal.(segmentedWal).Checkpoint()
+
+	assert.Len(t, writtenSegments(dir), 1)
+
+	recovered, eng := newPersistentStorage(t, dir)
+
+	value, _ := eng.GET("biba")
+	assert.Equal(t, "boba", value)
+
+	elements, _ := eng.LRANGE("queue", 0, -1)
+	assert.Equal(t, []string{"a", "b", "c"}, elements)
+
+	score, _, _ := eng.ZSCORE("board", "biba")
+	assert.Equal(t, 1.5, score)
+
+	members, _ := eng.SMEMBERS("tags")
+	assert.Equal(t, []string{"x"}, members)
+
+	ttl, _ := eng.TTL("user")
+	assert.Positive(t, ttl)
+
+	answer, err := recovered.HandleRequest(request.Request{RequestType: commands.BgSaveCommand})
+
+	assert.NoError(t, err)
+	assert.Equal(t, backgroundSaveAnswer, answer)
+
+	assert.Eventually(t, func() bool {
+		return len(writtenSegments(dir)) == 0 && !recovered.maintaining.Load()
+	}, time.Second, 10*time.Millisecond)
+
+	_, eng = newPersistentStorage(t, dir)
+
+	elements, _ = eng.LRANGE("queue", 0, -1)
+	assert.Equal(t, []string{"a", "b", "c"}, elements)
+}
+
+func Test_SaveErrors(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	_, err := stor.HandleRequest(request.Request{RequestType: commands.SaveCommand})
+
+	assert.Equal(t, errors.New("snapshots are not configured"), err)
+
+	answers, _, err := stor.HandleTransaction([]request.Request{{RequestType: commands.BgSaveCommand}}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"save is not allowed inside transaction"}, answers)
+}
+
+func Test_recoverFromCorruptedSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	logged := encodeRequests(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+
+	os.MkdirAll(filepath.Join(dir, "snapshot"), 0755)
+	os.WriteFile(filepath.Join(dir, "snapshot", "dump.snap"), []byte("biba boba"), 0644)
+	os.WriteFile(dir+"wal1.log", logged, 0644)
+
+	rl, _ := readlevel.NewReadLevel(zap.NewNop(), "wal", readlevel.WithDirectory(dir))
+	writeAheadLog, _ := wal.NewWal(zap.NewNop(), wal.WithReader(rl))
+	snap, _ := snapshot.NewSnapshot(zap.NewNop(), snapshot.WithDirectory(filepath.Join(dir, "snapshot")))
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, err := NewStorage(zap.NewNop(), eng, WithWal(writeAheadLog), WithSnapshot(snap))
+
+	assert.Nil(t, stor)
+	assert.Error(t, err)
+
+	onDisk, _ := os.ReadFile(dir + "wal1.log")
+
+	assert.Equal(t, logged, onDisk)
+}
+
+func Test_HandleRequestWaitsForDurability(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		durability   string
+		batchTimeout time.Duration
+
+		expectedSegments int
+	}
+
+	testCases := []testCase{
+		{
+			name: "always flushes at once",
+
+			durability:   wal.DurabilityAlways,
+			batchTimeout: time.Hour,
+
+			expectedSegments: 1,
+		},
+		{
+			name: "batch waits for the flush on timer",
+
+			durability:   wal.DurabilityBatch,
+			batchTimeout: 20 * time.Millisecond,
+
+			expectedSegments: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir() + "/"
+
+			wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir), writelevel.WithFileName("wal"))
+			writeAheadLog, _ := wal.NewWal(zap.NewNop(), wal.WithBatchSize(100), wal.WithBatchTimeout(test.batchTimeout),
+				wal.WithWriter(wl), wal.WithDurability(test.durability))
+
+			eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+			stor, _ := NewStorage(zap.NewNop(), eng, WithWal(writeAheadLog))
+
+			_, err := stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+
+			assert.NoError(t, err)
+			assert.Len(t, writtenSegments(dir), test.expectedSegments)
+
+			answers, _, err := stor.HandleTransaction([]request.Request{{RequestType: commands.DelCommand, Args: []string{"biba"}}}, nil)
+
+			assert.NoError(t, err)
+			assert.Len(t, answers, 1)
+			assert.Len(t, writtenSegments(dir), test.expectedSegments)
+		})
+	}
+}
+
+func Test_recoverWithWrongKey(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	keyring, _ := encryption.NewKeyring([]byte("1:000102030405060708090a0b0c0d0e0f"))
+	wrongKeyring, _ := encryption.NewKeyring([]byte("1:ffffffffffffffffffffffffffffffff"))
+
+	encrypted, _ := keyring.Encrypt(encodeRequests(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}))
+
+	os.WriteFile(dir+"wal1.log", encrypted, 0644)
+
+	rl, _ := readlevel.NewReadLevel(zap.NewNop(), "wal", readlevel.WithDirectory(dir), readlevel.WithKeyring(wrongKeyring))
+	writeAheadLog, _ := wal.NewWal(zap.NewNop(), wal.WithReader(rl))
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, err := NewStorage(zap.NewNop(), eng, WithWal(writeAheadLog))
+
+	assert.Nil(t, stor)
+	assert.ErrorIs(t, err, encryption.ErrWrongKey)
+
+	onDisk, _ := os.ReadFile(dir + "wal1.log")
+
+	assert.Equal(t, encrypted, onDisk)
+}
+
+type syncingReplica struct {
+	dump func() (uint64, []request.Request)
+}
+
+func (r *syncingReplica) IsMaster() bool {
+	return true
+}
+
+func (r *syncingReplica) ServeFullSync(dump func() (uint64, []request.Request)) {
+	r.dump = dump
+}
+
+func Test_serveFullSync(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir), writelevel.WithFileName("wal"))
+	rl, _ := readlevel.NewReadLevel(zap.NewNop(), "wal", readlevel.WithDirectory(dir))
+	writeAheadLog, _ := wal.NewWal(zap.NewNop(), wal.WithBatchSize(1), wal.WithBatchTimeout(time.Hour),
+		wal.WithWriter(wl), wal.WithReader(rl))
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	replica := &syncingReplica{}
+
+	stor, err := NewStorage(zap.NewNop(), eng, WithWal(writeAheadLog), WithReplica(replica))
+
+	assert.NoError(t, err)
+	assert.NotNil(t, replica.dump)
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+	stor.HandleRequest(request.Request{RequestType: commands.SAddCommand, Args: []string{"tags", "x"}})
+
+	lsn, reqs := replica.dump()
+
+	assert.Equal(t, uint64(2), lsn)
+	assert.ElementsMatch(t, []request.Request{
+		{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+		{RequestType: commands.SAddCommand, Args: []string{"tags", "x"}},
+	}, reqs)
+}
+
+func Test_pointInTimeFlushesPendingBatch(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir), writelevel.WithFileName("wal"))
+	writeAheadLog, _ := wal.NewWal(zap.NewNop(), wal.WithBatchSize(100), wal.WithBatchTimeout(time.Hour), wal.WithWriter(wl))
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	replica := &syncingReplica{}
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(writeAheadLog), WithReplica(replica))
+
+	push := request.Request{RequestType: commands.RPushCommand, Args: []string{"queue", "a"}}
+
+	pushed := stor.wal.Write(push)
+	eng.RPUSH("queue", []string{"a"}, nil)
+
+	assert.Equal(t, uint64(0), stor.lsn())
+
+	lsn, reqs := replica.dump()
+
+	assert.Equal(t, uint64(1), lsn)
+	assert.Equal(t, []request.Request{push}, reqs)
+
+	written, err := pushed()
+
+	assert.NoError(t, err)
+	assert.Equal(t, lsn, written)
+}
+
+type truncatingReplicaStub struct {
+	truncated []uint64
+}
+
+func (r *truncatingReplicaStub) IsMaster() bool {
+	return true
+}
+
+func (r *truncatingReplicaStub) Truncated(lsn uint64) {
+	r.truncated = append(r.truncated, lsn)
+}
+
+func Test_truncateNotifiesReplica(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	newStorage := func(replica Replica) *Storage {
+		wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir), writelevel.WithFileName("wal"))
+		rl, _ := readlevel.NewReadLevel(zap.NewNop(), "wal", readlevel.WithDirectory(dir))
+		writeAheadLog, _ := wal.NewWal(zap.NewNop(), wal.WithBatchSize(1), wal.WithBatchTimeout(time.Hour),
+			wal.WithWriter(wl), wal.WithReader(rl))
+		snap, _ := snapshot.NewSnapshot(zap.NewNop(), snapshot.WithDirectory(filepath.Join(dir, "snapshot")))
+
+		eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+		stor, err := NewStorage(zap.NewNop(), eng, WithWal(writeAheadLog), WithSnapshot(snap), WithReplica(replica))
+
+		assert.NoError(t, err)
+
+		return stor
+	}
+
+	replica := &truncatingReplicaStub{}
+	stor := newStorage(replica)
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"boba", "biba"}})
+
+	assert.NoError(t, stor.Save())
+	assert.Equal(t, []uint64{2}, replica.truncated)
+
+	restarted := &truncatingReplicaStub{}
+	newStorage(restarted)
+
+	assert.Equal(t, []uint64{2}, restarted.truncated)
+}
@@ -4,27 +4,80 @@ import (
 	"errors"
 	"inmemorykvdb/internal/database/commands"
 	"inmemorykvdb/internal/database/request"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 const (
-	okAnswer = "SUCCESS"
-	notFound = "NOT FOUND"
+	okAnswer     = "SUCCESS"
+	notFound     = "NOT FOUND"
+	noExpiration = "-1"
+	notApplied   = "NOT APPLIED"
 )
 
 type engineLayer interface {
-	SET(key string, value string)
+	SET(key string, value string, commit func() func() error) error
+	SETEX(key string, value string, deadline time.Time, commit func() func() error) error
 	GET(key string) (string, bool)
 	DEL(key string)
+	EXPIREAT(key string, deadline time.Time) bool
+	TTL(key string) (time.Duration, bool)
+	PERSIST(key string) bool
+	SCAN(cursor uint64, match string, count int) (uint64, []string, error)
+	MGET(keys []string) ([]string, []bool)
+	MSET(keys []string, values []string, commit func() func() error) error
+	MDEL(keys []string) int
+	INCRBY(key string, delta int64, commit func(value string, deadline time.Time) func() error) (int64, error)
+	INCRBYFLOAT(key string, delta float64, commit func(value string, deadline time.Time) func() error) (float64, error)
+	SETIF(key string, value string, deadline time.Time, condition func(current string, found bool, version uint64) bool,
+		commit func(value string, deadline time.Time) func() error) (bool, error)
+	CAS(key string, expected string, value string, commit func(value string, deadline time.Time) func() error) (bool, error)
+	VERSION(key string) (uint64, bool)
+	TYPE(key string) string
+	HSET(key string, fields []string, values []string, commit func() func() error) (int, error)
+	HGET(key string, field string) (string, bool, error)
+	HDEL(key string, fields []string) (int, error)
+	HGETALL(key string) ([]string, []string, error)
+	LPUSH(key string, elements []string, commit func() func() error) (int, error)
+	RPUSH(key string, elements []string, commit func() func() error) (int, error)
+	LPOP(key string) (string, bool, error)
+	RPOP(key string) (string, bool, error)
+	LRANGE(key string, start int, stop int) ([]string, error)
+	SADD(key string, members []string, commit func() func() error) (int, error)
+	SREM(key string, members []string) (int, error)
+	SMEMBERS(key string) ([]string, error)
+	SISMEMBER(key string, member string) (bool, error)
+	ZADD(key string, scores []float64, members []string, commit func() func() error) (int, error)
+	ZREM(key string, members []string) (int, error)
+	ZINCRBY(key string, delta float64, member string, commit func(score float64) func() error) (float64, error)
+	ZSCORE(key string, member string) (float64, bool, error)
+	ZRANK(key string, member string) (int, bool, error)
+	ZRANGE(key string, start int, stop int) ([]string, []float64, error)
+	ZRANGEBYSCORE(key string, min float64, max float64) ([]string, []float64, error)
+	DUMP(visit func(key string, kind string, items []string, deadline time.Time))
+	DUMPKEYS(keys []string, visit func(key string, kind string, items []string, deadline time.Time))
 }
 
 type Replica interface {
 	IsMaster() bool
 }
 
+type closingEngine interface {
+	Close()
+}
+
+// quorumReplica holds the answer of a write until enough slaves have durably stored it.
+type quorumReplica interface {
+	WaitReplicas(lsn uint64) error
+}
+
 type WAL interface {
-	Write(req request.Request)
+	Write(req request.Request) func() (uint64, error)
+	WriteGroup(reqs []request.Request) func() (uint64, error)
 	Read() *request.Batch
 }
 
@@ -33,26 +86,118 @@ type Storage struct {
 	engine  engineLayer
 	logger  *zap.Logger
 	replica Replica
+	gate    sync.RWMutex
+
+	snapshot         snapshotLayer
+	snapshotInterval time.Duration
+
+	compactor          compactionLayer
+	compactionInterval time.Duration
+
+	maintaining atomic.Bool
 
 	dataChan <-chan *request.Batch
+
+	done      chan struct{}
+	closeOnce sync.Once
+
+	pendingMutex sync.Mutex
+	pending      map[string]chan struct{}
 }
 
 func (s *Storage) HandleRequest(req request.Request) (string, error) {
-	if s.wal != nil && req.RequestType != commands.GetCommand && (s.replica == nil || s.replica.IsMaster()) {
-		s.wal.Write(req)
+	switch req.RequestType {
+
+	case commands.SaveCommand:
+		s.logger.Debug("started save command")
+
+		err := s.Save()
+
+		if err != nil {
+			return "", err
+		}
+
+		return okAnswer, nil
+
+	case commands.BgSaveCommand:
+		s.logger.Debug("started bgsave command")
+
+		err := s.BackgroundSave()
+
+		if err != nil {
+			return "", err
+		}
+
+		return backgroundSaveAnswer, nil
 	}
 
-	resp, err := s.requestToEngine(req, true)
+	resp, lsn, err := s.handle(req)
+
+	if err != nil || lsn == 0 {
+		return resp, err
+	}
+
+	err = s.awaitReplicas(lsn)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return "", err
+	}
+
+	return resp, nil
+}
+
+func (s *Storage) handle(req request.Request) (string, uint64, error) {
+	s.gate.RLock()
+	defer s.gate.RUnlock()
+
+	var lsn uint64
+
+	write := s.walWriter(&lsn)
+
+	if write != nil && isMutation(req.RequestType) {
+		defer s.holdKeys(mutatedKeys(req))()
+	}
+
+	resp, err := s.handleRequest(req, write)
+
+	return resp, lsn, err
+}
+
+func (s *Storage) handleRequest(req request.Request, write func(request.Request) func() error) (string, error) {
+	req, err := resolveExpiration(req, time.Now())
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return "", err
+	}
+
+	if write != nil && isMutation(req.RequestType) && !isLoggedOnCommit(req) {
+		if wait := write(req); wait != nil {
+			err = wait()
+		}
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return "", err
+		}
+	}
+
+	resp, err := s.requestToEngine(req, true, write)
 	return resp, err
 }
 
-func (s *Storage) requestToEngine(req request.Request, fromClient bool) (string, error) {
+func (s *Storage) requestToEngine(req request.Request, fromClient bool, write func(request.Request) func() error) (string, error) {
 	switch req.RequestType {
 
 	case commands.GetCommand:
 		s.logger.Debug("started get command")
 		val, found := s.engine.GET(req.Args[0])
 
+		if !found && s.engine.TYPE(req.Args[0]) != noneType {
+			return "", errWrongType
+		}
+
 		if !found {
 			return notFound, nil
 		}
@@ -65,7 +210,28 @@ func (s *Storage) requestToEngine(req request.Request, fromClient bool) (string,
 		}
 
 		s.logger.Debug("started set command")
-		s.engine.SET(req.Args[0], req.Args[1])
+
+		deadline, hasDeadline, err := parseDeadline(req.Args)
+
+		if err != nil {
+			return "", err
+		}
+
+		if condition, conditional := parseCondition(req.Args); conditional {
+			applied, err := s.engine.SETIF(req.Args[0], req.Args[1], deadline, condition, commitSet(req.Args[0], write))
+
+			return appliedAnswer(applied, err)
+		}
+
+		if hasDeadline {
+			err = s.engine.SETEX(req.Args[0], req.Args[1], deadline, commitRequest(req, write))
+		} else {
+			err = s.engine.SET(req.Args[0], req.Args[1], commitRequest(req, write))
+		}
+
+		if err != nil {
+			return "", err
+		}
 
 		return okAnswer, nil
 
@@ -79,12 +245,276 @@ func (s *Storage) requestToEngine(req request.Request, fromClient bool) (string,
 
 		return okAnswer, nil
 
+	case commands.ExpireAtCommand:
+		if s.isNotMutable(fromClient) {
+			return "", errors.New("slave node is read-only")
+		}
+
+		s.logger.Debug("started expireat command")
+
+		deadline, err := parseUnixMilli(req.Args[1])
+
+		if err != nil {
+			return "", err
+		}
+
+		if !s.engine.EXPIREAT(req.Args[0], deadline) {
+			return notFound, nil
+		}
+
+		return okAnswer, nil
+
+	case commands.TTLCommand:
+		s.logger.Debug("started ttl command")
+		ttl, found := s.engine.TTL(req.Args[0])
+
+		if !found {
+			return notFound, nil
+		}
+
+		if ttl < 0 {
+			return noExpiration, nil
+		}
+
+		return strconv.FormatInt(int64((ttl+time.Second-1)/time.Second), 10), nil
+
+	case commands.PersistCommand:
+		if s.isNotMutable(fromClient) {
+			return "", errors.New("slave node is read-only")
+		}
+
+		s.logger.Debug("started persist command")
+
+		if !s.engine.PERSIST(req.Args[0]) {
+			return notFound, nil
+		}
+
+		return okAnswer, nil
+
+	case commands.RangeCommand:
+		s.logger.Debug("started range command")
+
+		ordered, err := s.orderedEngine()
+
+		if err != nil {
+			return "", err
+		}
+
+		limit, err := parseLimit(req.Args[2:])
+
+		if err != nil {
+			return "", err
+		}
+
+		return formatPairs(ordered.RANGE(req.Args[0], req.Args[1], limit)), nil
+
+	case commands.PrefixCommand:
+		s.logger.Debug("started prefix command")
+
+		ordered, err := s.orderedEngine()
+
+		if err != nil {
+			return "", err
+		}
+
+		limit, err := parseLimit(req.Args[1:])
+
+		if err != nil {
+			return "", err
+		}
+
+		return formatPairs(ordered.PREFIX(req.Args[0], limit)), nil
+
+	case commands.ScanCommand:
+		s.logger.Debug("started scan command")
+
+		cursor, match, count, err := parseScan(req.Args)
+
+		if err != nil {
+			return "", err
+		}
+
+		next, keys, err := s.engine.SCAN(cursor, match, count)
+
+		if err != nil {
+			return "", err
+		}
+
+		return formatScan(next, keys), nil
+
+	case commands.GetVerCommand:
+		s.logger.Debug("started getver command")
+		version, found := s.engine.VERSION(req.Args[0])
+
+		if !found {
+			return notFound, nil
+		}
+
+		return strconv.FormatUint(version, 10), nil
+
+	case commands.MGetCommand:
+		s.logger.Debug("started mget command")
+
+		return formatValues(s.engine.MGET(req.Args)), nil
+
+	case commands.MSetCommand:
+		if s.isNotMutable(fromClient) {
+			return "", errors.New("slave node is read-only")
+		}
+
+		s.logger.Debug("started mset command")
+
+		keys, values := splitPairs(req.Args)
+
+		err := s.engine.MSET(keys, values, commitRequest(req, write))
+
+		if err != nil {
+			return "", err
+		}
+
+		return okAnswer, nil
+
+	case commands.MDelCommand:
+		if s.isNotMutable(fromClient) {
+			return "", errors.New("slave node is read-only")
+		}
+
+		s.logger.Debug("started mdel command")
+
+		return strconv.Itoa(s.engine.MDEL(req.Args)), nil
+
+	case commands.CasCommand:
+		if s.isNotMutable(fromClient) {
+			return "", errors.New("slave node is read-only")
+		}
+
+		s.logger.Debug("started cas command")
+
+		applied, err := s.engine.CAS(req.Args[0], req.Args[1], req.Args[2], commitSet(req.Args[0], write))
+
+		return appliedAnswer(applied, err)
+
+	case commands.IncrCommand, commands.DecrCommand, commands.IncrByCommand:
+		if s.isNotMutable(fromClient) {
+			return "", errors.New("slave node is read-only")
+		}
+
+		s.logger.Debug("started incrby command")
+
+		delta, err := parseDelta(req)
+
+		if err != nil {
+			return "", err
+		}
+
+		result, err := s.engine.INCRBY(req.Args[0], delta, commitSet(req.Args[0], write))
+
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(result, 10), nil
+
+	case commands.IncrByFloatCommand:
+		if s.isNotMutable(fromClient) {
+			return "", errors.New("slave node is read-only")
+		}
+
+		s.logger.Debug("started incrbyfloat command")
+
+		delta, err := strconv.ParseFloat(req.Args[1], 64)
+
+		if err != nil {
+			return "", errors.New("incorrect increment")
+		}
+
+		result, err := s.engine.INCRBYFLOAT(req.Args[0], delta, commitSet(req.Args[0], write))
+
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatFloat(result, 'f', -1, 64), nil
+
+	case commands.HSetCommand, commands.HGetCommand, commands.HDelCommand, commands.HGetAllCommand,
+		commands.LPushCommand, commands.RPushCommand, commands.LPopCommand, commands.RPopCommand, commands.LRangeCommand,
+		commands.SAddCommand, commands.SRemCommand, commands.SMembersCommand, commands.SIsMemberCommand:
+		if s.isNotMutable(fromClient) && isMutation(req.RequestType) {
+			return "", errors.New("slave node is read-only")
+		}
+
+		return s.typedToEngine(req, write)
+
+	case commands.ZAddCommand, commands.ZRemCommand, commands.ZIncrByCommand, commands.ZScoreCommand, commands.ZRankCommand,
+		commands.ZRangeCommand, commands.ZRangeByScoreCommand:
+		if s.isNotMutable(fromClient) && isMutation(req.RequestType) {
+			return "", errors.New("slave node is read-only")
+		}
+
+		return s.sortedSetToEngine(req, write)
+
+	case commands.SaveCommand, commands.BgSaveCommand:
+		return "", errors.New("save is not allowed inside transaction")
+
+	case commands.InfoCommand:
+		s.logger.Debug("started info command")
+
+		return s.info(), nil
+
 	default:
 		s.logger.Error("incorrect request type")
 		return "", errors.New("incorrect request type")
 	}
 }
 
+func isMutation(requestType int) bool {
+	switch requestType {
+	case commands.GetCommand, commands.TTLCommand, commands.RangeCommand, commands.PrefixCommand, commands.ScanCommand,
+		commands.MGetCommand, commands.GetVerCommand, commands.HGetCommand, commands.HGetAllCommand, commands.LRangeCommand,
+		commands.SMembersCommand, commands.SIsMemberCommand, commands.ZScoreCommand, commands.ZRankCommand, commands.ZRangeCommand,
+		commands.ZRangeByScoreCommand, commands.SaveCommand, commands.BgSaveCommand, commands.InfoCommand:
+		return false
+	}
+
+	return true
+}
+
+func (s *Storage) isLogging() bool {
+	return s.wal != nil && (s.replica == nil || s.replica.IsMaster())
+}
+
+// walWriter adds the request to the wal and returns the wait for its flush, the request
+// is applied after the wait, so the engine never holds a mutation the wal refused.
+// lsn gets the one of the logged request.
+func (s *Storage) walWriter(lsn *uint64) func(request.Request) func() error {
+	if !s.isLogging() {
+		return nil
+	}
+
+	return func(req request.Request) func() error {
+		wait := s.wal.Write(req)
+
+		return func() error {
+			written, err := wait()
+			*lsn = written
+
+			return err
+		}
+	}
+}
+
+// awaitReplicas waits for the lsn of the write, it is applied on the master
+// even when the slaves do not confirm it in time.
+func (s *Storage) awaitReplicas(lsn uint64) error {
+	quorum, ok := s.replica.(quorumReplica)
+
+	if !ok || !s.isLogging() {
+		return nil
+	}
+
+	return quorum.WaitReplicas(lsn)
+}
+
 func (s *Storage) isNotMutable(fromClient bool) bool {
 	return s.replica != nil && !s.replica.IsMaster() && fromClient
 }
@@ -99,7 +529,7 @@ func NewStorage(logger *zap.Logger, engine engineLayer, options ...StorageOption
 		return nil, errors.New("could not create storage without engine")
 	}
 
-	storage := &Storage{logger: logger, engine: engine}
+	storage := &Storage{logger: logger, engine: engine, done: make(chan struct{})}
 
 	for _, option := range options {
 		option(storage)
@@ -113,21 +543,52 @@ func NewStorage(logger *zap.Logger, engine engineLayer, options ...StorageOption
 		storage.synchronization()
 	}
 
-	if storage.wal != nil {
-		recovered := storage.wal.Read()
+	if storage.wal != nil || storage.snapshot != nil {
+		err := storage.recover()
 
-		if recovered != nil {
-			storage.recoverData(recovered)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	if syncing, ok := storage.replica.(fullSyncReplica); ok && storage.replica.IsMaster() {
+		syncing.ServeFullSync(storage.pointInTime)
+	}
+
+	if storage.snapshot != nil && storage.snapshotInterval > 0 {
+		go storage.scheduleSnapshots()
+	}
+
+	if storage.compactor != nil && storage.compactionInterval > 0 {
+		go storage.scheduleCompactions()
+	}
+
 	return storage, nil
 }
 
+// Close stops the scheduled snapshots and compactions and the background work of the engine.
+func (s *Storage) Close() {
+	s.closeOnce.Do(func() {
+		s.logger.Debug("started closing storage")
+
+		close(s.done)
+
+		if closing, ok := s.engine.(closingEngine); ok {
+			closing.Close()
+		}
+	})
+}
+
 func (s *Storage) recoverData(batch *request.Batch) {
+	s.gate.Lock()
+	defer s.gate.Unlock()
+
+	if batch.Reset {
+		s.clear()
+	}
 
 	for _, req := range batch.Data {
-		_, err := s.requestToEngine(*req, false)
+		_, err := s.requestToEngine(*req, false, nil)
 
 		if err != nil {
 			s.logger.Error(err.Error())
@@ -135,6 +596,17 @@ func (s *Storage) recoverData(batch *request.Batch) {
 	}
 }
 
+func (s *Storage) clear() {
+	keys := make([]string, 0)
+
+	s.engine.DUMP(func(key string, kind string, items []string, deadline time.Time) {
+		keys = append(keys, key)
+	})
+
+	s.engine.MDEL(keys)
+	s.logger.Info("storage is cleared for full sync")
+}
+
 func (s *Storage) synchronization() {
 	go func() {
 		for data := range s.dataChan {
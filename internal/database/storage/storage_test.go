@@ -7,7 +7,10 @@ import (
 	"inmemorykvdb/internal/database/storage/engine"
 	"inmemorykvdb/internal/database/storage/replication"
 	"inmemorykvdb/internal/network"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -135,7 +138,7 @@ func Test_HandleRequest(t *testing.T) {
 		{
 			name: "not a correct request",
 
-			request: request.Request{RequestType: 10, Args: []string{"asdfg"}},
+			request: request.Request{RequestType: commands.IncorrectCommand, Args: []string{"asdfg"}},
 
 			expectStr:   "",
 			expectedErr: errors.New("incorrect request type"),
@@ -197,6 +200,208 @@ func Test_recoverData(t *testing.T) {
 
 	answer, _ = stor.engine.GET("boba")
 	assert.Equal(t, "biba", answer)
+
+	batch = request.Batch{Reset: true, Data: []*request.Request{
+		{
+			RequestType: commands.RPushCommand,
+			Args:        []string{"queue", "a"},
+		},
+	}}
+
+	stor.recoverData(&batch)
+
+	_, found := stor.engine.GET("boba")
+	assert.False(t, found)
+
+	elements, _ := stor.engine.LRANGE("queue", 0, -1)
+	assert.Equal(t, []string{"a"}, elements)
+}
+
+func Test_HandleExpirationRequests(t *testing.T) {
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	type testCase struct {
+		name string
+
+		request request.Request
+
+		expectStr   string
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "set with expiration",
+
+			request: request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "EX", "30"}},
+
+			expectStr:   okAnswer,
+			expectedErr: nil,
+		},
+		{
+			name: "ttl of expiring key",
+
+			request: request.Request{RequestType: commands.TTLCommand, Args: []string{"biba"}},
+
+			expectStr:   "30",
+			expectedErr: nil,
+		},
+		{
+			name: "persist key",
+
+			request: request.Request{RequestType: commands.PersistCommand, Args: []string{"biba"}},
+
+			expectStr:   okAnswer,
+			expectedErr: nil,
+		},
+		{
+			name: "ttl of persistent key",
+
+			request: request.Request{RequestType: commands.TTLCommand, Args: []string{"biba"}},
+
+			expectStr:   noExpiration,
+			expectedErr: nil,
+		},
+		{
+			name: "expire key",
+
+			request: request.Request{RequestType: commands.ExpireCommand, Args: []string{"biba", "-1"}},
+
+			expectStr:   okAnswer,
+			expectedErr: nil,
+		},
+		{
+			name: "get expired key",
+
+			request: request.Request{RequestType: commands.GetCommand, Args: []string{"biba"}},
+
+			expectStr:   notFound,
+			expectedErr: nil,
+		},
+		{
+			name: "ttl of missing key",
+
+			request: request.Request{RequestType: commands.TTLCommand, Args: []string{"biba"}},
+
+			expectStr:   notFound,
+			expectedErr: nil,
+		},
+		{
+			name: "expire missing key",
+
+			request: request.Request{RequestType: commands.ExpireCommand, Args: []string{"biba", "10"}},
+
+			expectStr:   notFound,
+			expectedErr: nil,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			actualValue, actualErr := stor.HandleRequest(test.request)
+
+			assert.Equal(t, test.expectStr, actualValue)
+			assert.Equal(t, test.expectedErr, actualErr)
+		})
+	}
+}
+
+func Test_HandleRequestOutOfMemory(t *testing.T) {
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop(), engine.WithMaxMemory(100, engine.NoEviction))
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	answer, err := stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+
+	assert.Equal(t, okAnswer, answer)
+	assert.NoError(t, err)
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"boba", "biba"}})
+
+	assert.Equal(t, "", answer)
+	assert.Equal(t, errors.New("out of memory"), err)
+
+	answer, _ = stor.HandleRequest(request.Request{RequestType: commands.GetCommand, Args: []string{"boba"}})
+
+	assert.Equal(t, notFound, answer)
+}
+
+func Test_HandleRequestOutOfMemoryNotLogged(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop(), engine.WithPartitions(1, 10), engine.WithMaxMemory(256, engine.NoEviction))
+	testWal := &recordingWal{}
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(testWal))
+
+	big := strings.Repeat("a", 512)
+
+	tests := map[string]request.Request{
+		"set":   {RequestType: commands.SetCommand, Args: []string{"boba", big}},
+		"mset":  {RequestType: commands.MSetCommand, Args: []string{"boba", big, "biba", "1"}},
+		"rpush": {RequestType: commands.RPushCommand, Args: []string{"queue", big}},
+		"hset":  {RequestType: commands.HSetCommand, Args: []string{"hash", "field", big}},
+		"sadd":  {RequestType: commands.SAddCommand, Args: []string{"set", big}},
+		"zadd":  {RequestType: commands.ZAddCommand, Args: []string{"zset", "1", big}},
+	}
+
+	for name, req := range tests {
+		answer, err := stor.HandleRequest(req)
+
+		assert.Error(t, err, name)
+		assert.Equal(t, "", answer, name)
+	}
+
+	answer, err := stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, okAnswer, answer)
+	assert.Equal(t, []request.Request{{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}}, testWal.requests)
+}
+
+func Test_recoverExpiredData(t *testing.T) {
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10)
+	future := strconv.FormatInt(time.Now().Add(time.Minute).UnixMilli(), 10)
+
+	batch := request.Batch{Data: []*request.Request{
+		{
+			RequestType: commands.SetCommand,
+			Args:        []string{"biba", "boba", commands.ExpireAtMilliseconds, past},
+		},
+
+		{
+			RequestType: commands.SetCommand,
+			Args:        []string{"boba", "biba", commands.ExpireAtMilliseconds, future},
+		},
+
+		{
+			RequestType: commands.SetCommand,
+			Args:        []string{"bib", "bob"},
+		},
+
+		{
+			RequestType: commands.ExpireAtCommand,
+			Args:        []string{"bib", past},
+		},
+	}}
+
+	stor.recoverData(&batch)
+
+	_, found := stor.engine.GET("biba")
+	assert.False(t, found)
+
+	answer, found := stor.engine.GET("boba")
+	assert.True(t, found)
+	assert.Equal(t, "biba", answer)
+
+	_, found = stor.engine.GET("bib")
+	assert.False(t, found)
 }
 
 func Test_synchronization(t *testing.T) {
@@ -247,3 +452,76 @@ func Test_synchronization(t *testing.T) {
 	answ, _ = stor.engine.GET("bib")
 	assert.Equal(t, "", answ)
 }
+
+type quorumReplicaStub struct {
+	waited []uint64
+	err    error
+}
+
+func (r *quorumReplicaStub) IsMaster() bool {
+	return true
+}
+
+func (r *quorumReplicaStub) WaitReplicas(lsn uint64) error {
+	r.waited = append(r.waited, lsn)
+
+	return r.err
+}
+
+func Test_HandleRequestWithQuorum(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	replica := &quorumReplicaStub{err: replication.ErrReplicaTimeout}
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(&recordingWal{}), WithReplica(replica))
+
+	answer, err := stor.HandleRequest(request.Request{RequestType: commands.GetCommand, Args: []string{"biba"}})
+
+	assert.Equal(t, notFound, answer)
+	assert.NoError(t, err)
+	assert.Empty(t, replica.waited)
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+
+	assert.Empty(t, answer)
+	assert.Equal(t, replica.err, err)
+	assert.Equal(t, []uint64{1}, replica.waited)
+
+	replica.err = nil
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.DelCommand, Args: []string{"biba"}})
+
+	assert.Equal(t, okAnswer, answer)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2}, replica.waited)
+
+	answers, applied, err := stor.HandleTransaction([]request.Request{
+		{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+		{RequestType: commands.IncrCommand, Args: []string{"counter"}},
+	}, nil)
+
+	assert.Equal(t, []string{okAnswer, "1"}, answers)
+	assert.True(t, applied)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 6}, replica.waited)
+
+	answer, err = stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba", "NX"}})
+
+	assert.Equal(t, notApplied, answer)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 6}, replica.waited)
+}
+
+func Test_Close(t *testing.T) {
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	stor.Close()
+	stor.Close()
+
+	_, open := <-stor.done
+
+	assert.False(t, open)
+}
@@ -1,6 +1,9 @@
 package storage
 
-import "inmemorykvdb/internal/database/request"
+import (
+	"inmemorykvdb/internal/database/request"
+	"time"
+)
 
 type StorageOption func(*Storage)
 
@@ -21,3 +24,27 @@ func WithDataChan(dataChan chan *request.Batch) StorageOption {
 		s.dataChan = dataChan
 	}
 }
+
+func WithSnapshot(snapshot snapshotLayer) StorageOption {
+	return func(s *Storage) {
+		s.snapshot = snapshot
+	}
+}
+
+func WithSnapshotInterval(interval time.Duration) StorageOption {
+	return func(s *Storage) {
+		s.snapshotInterval = interval
+	}
+}
+
+func WithCompactor(compactor compactionLayer) StorageOption {
+	return func(s *Storage) {
+		s.compactor = compactor
+	}
+}
+
+func WithCompactionInterval(interval time.Duration) StorageOption {
+	return func(s *Storage) {
+		s.compactionInterval = interval
+	}
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/request"
+	"slices"
+)
+
+func (s *Storage) Watch(keys []string) map[string]uint64 {
+	s.gate.RLock()
+	defer s.gate.RUnlock()
+
+	versions := make(map[string]uint64, len(keys))
+
+	for _, key := range keys {
+		versions[key], _ = s.engine.VERSION(key)
+	}
+
+	return versions
+}
+
+func (s *Storage) HandleTransaction(reqs []request.Request, watched map[string]uint64) ([]string, bool, error) {
+	if s.replica != nil && !s.replica.IsMaster() {
+		return nil, false, errors.New("slave node is read-only")
+	}
+
+	if err := s.walFailure(); err != nil && s.isLogging() {
+		return nil, false, err
+	}
+
+	answers, applied, lsn, err := s.transaction(reqs, watched)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, false, err
+	}
+
+	if lsn != 0 {
+		err = s.awaitReplicas(lsn)
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return nil, false, err
+		}
+	}
+
+	return answers, applied, nil
+}
+
+// transaction holds the gate until its group is flushed, so no one sees the changes
+// before they are logged, and a failed flush restores the keys the transaction changed.
+func (s *Storage) transaction(reqs []request.Request, watched map[string]uint64) ([]string, bool, uint64, error) {
+	s.gate.Lock()
+	defer s.gate.Unlock()
+
+	s.logger.Debug("started transaction")
+
+	for key, watchedVersion := range watched {
+		if version, _ := s.engine.VERSION(key); version != watchedVersion {
+			s.logger.Debug("transaction aborted, watched key changed")
+			return nil, false, 0, nil
+		}
+	}
+
+	var group []request.Request
+	var write func(request.Request) func() error
+
+	if s.isLogging() {
+		write = func(req request.Request) func() error {
+			group = append(group, req)
+			return nil
+		}
+	}
+
+	var touched []string
+	var restore []request.Request
+
+	answers := make([]string, len(reqs))
+
+	for i, req := range reqs {
+		if write != nil && isMutation(req.RequestType) {
+			keys := untouchedKeys(req, touched)
+
+			touched = append(touched, keys...)
+			restore = append(restore, s.dumpKeys(keys)...)
+		}
+
+		answer, err := s.handleRequest(req, write)
+
+		if err != nil {
+			answers[i] = err.Error()
+			continue
+		}
+
+		answers[i] = answer
+	}
+
+	if len(group) == 0 {
+		s.logger.Debug("transaction is done")
+		return answers, true, 0, nil
+	}
+
+	lsn, err := s.wal.WriteGroup(group)()
+
+	if err != nil {
+		s.rollback(touched, restore)
+		return nil, false, 0, err
+	}
+
+	s.logger.Debug("transaction is done")
+
+	return answers, true, lsn, nil
+}
+
+func (s *Storage) rollback(keys []string, restore []request.Request) {
+	s.logger.Warn("transaction is not logged, restoring its keys")
+
+	for _, key := range keys {
+		s.engine.DEL(key)
+	}
+
+	for _, req := range restore {
+		_, err := s.requestToEngine(req, false, nil)
+
+		if err != nil {
+			s.logger.Error(err.Error())
+		}
+	}
+}
+
+// untouchedKeys returns the keys the request changes which are not changed by the transaction yet.
+func untouchedKeys(req request.Request, touched []string) []string {
+	return slices.DeleteFunc(mutatedKeys(req), func(key string) bool {
+		return slices.Contains(touched, key)
+	})
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type slaveReplica struct{}
+
+func (slaveReplica) IsMaster() bool {
+	return false
+}
+
+func Test_HandleTransaction(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop(), engine.WithPartitions(4, 10))
+	testWal := &recordingWal{}
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(testWal))
+
+	answers, applied, err := stor.HandleTransaction([]request.Request{
+		{RequestType: commands.SetCommand, Args: []string{"biba", "1"}},
+		{RequestType: commands.IncrCommand, Args: []string{"biba"}},
+		{RequestType: commands.GetCommand, Args: []string{"biba"}},
+		{RequestType: commands.SetCommand, Args: []string{"boba", "1", commands.ExistsModifier}},
+		{RequestType: commands.DelCommand, Args: []string{"aboba"}},
+	}, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	assert.Equal(t, []string{okAnswer, "2", "2", notApplied, okAnswer}, answers)
+
+	expected := [][]request.Request{
+		{
+			{RequestType: commands.SetCommand, Args: []string{"biba", "1"}},
+			{RequestType: commands.SetCommand, Args: []string{"biba", "2"}},
+			{RequestType: commands.DelCommand, Args: []string{"aboba"}},
+		},
+	}
+
+	assert.Equal(t, expected, testWal.groups)
+	assert.Empty(t, testWal.requests)
+}
+
+type unflushedWal struct {
+	recordingWal
+}
+
+func (w *unflushedWal) WriteGroup(reqs []request.Request) func() (uint64, error) {
+	return func() (uint64, error) { return 0, errors.New("no space left on device") }
+}
+
+func Test_HandleTransactionRestoresUnflushed(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop(), engine.WithPartitions(4, 10))
+
+	eng.SET("biba", "boba", nil)
+	eng.RPUSH("queue", []string{"a"}, nil)
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(&unflushedWal{}))
+
+	answers, applied, err := stor.HandleTransaction([]request.Request{
+		{RequestType: commands.SetCommand, Args: []string{"biba", "aboba"}},
+		{RequestType: commands.RPushCommand, Args: []string{"queue", "b"}},
+		{RequestType: commands.IncrCommand, Args: []string{"counter"}},
+		{RequestType: commands.MSetCommand, Args: []string{"boba", "1", "biba", "2"}},
+		{RequestType: commands.DelCommand, Args: []string{"queue"}},
+	}, nil)
+
+	assert.Nil(t, answers)
+	assert.False(t, applied)
+	assert.Equal(t, errors.New("no space left on device"), err)
+
+	value, _ := eng.GET("biba")
+	assert.Equal(t, "boba", value)
+
+	elements, _ := eng.LRANGE("queue", 0, -1)
+	assert.Equal(t, []string{"a"}, elements)
+
+	_, found := eng.GET("counter")
+	assert.False(t, found)
+
+	_, found = eng.GET("boba")
+	assert.False(t, found)
+}
+
+func Test_HandleTransactionOnSlave(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithReplica(slaveReplica{}), WithDataChan(make(chan *request.Batch)))
+
+	answers, applied, err := stor.HandleTransaction([]request.Request{
+		{RequestType: commands.GetCommand, Args: []string{"biba"}},
+	}, nil)
+
+	assert.Nil(t, answers)
+	assert.False(t, applied)
+	assert.Equal(t, errors.New("slave node is read-only"), err)
+}
+
+func Test_HandleTransactionWithWatch(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	testWal := &recordingWal{}
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(testWal))
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "1"}})
+
+	watched := stor.Watch([]string{"biba", "boba"})
+
+	queue := []request.Request{{RequestType: commands.SetCommand, Args: []string{"biba", "2"}}}
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"boba", "1"}})
+
+	answers, applied, err := stor.HandleTransaction(queue, watched)
+
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	assert.Nil(t, answers)
+	assert.Empty(t, testWal.groups)
+
+	answers, applied, err = stor.HandleTransaction(queue, stor.Watch([]string{"biba", "boba"}))
+
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	assert.Equal(t, []string{okAnswer}, answers)
+}
+
+func Test_HandleTransactionWithWatchOfDeletedKey(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	watched := stor.Watch([]string{"boba"})
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"boba", "1"}})
+	stor.HandleRequest(request.Request{RequestType: commands.DelCommand, Args: []string{"boba"}})
+
+	queue := []request.Request{{RequestType: commands.SetCommand, Args: []string{"biba", "2"}}}
+
+	answers, applied, err := stor.HandleTransaction(queue, watched)
+
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	assert.Nil(t, answers)
+}
+
+func Test_HandleVersionRequests(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	answer, _ := stor.HandleRequest(request.Request{RequestType: commands.GetVerCommand, Args: []string{"biba"}})
+
+	assert.Equal(t, notFound, answer)
+
+	stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "1"}})
+
+	version, _ := stor.HandleRequest(request.Request{RequestType: commands.GetVerCommand, Args: []string{"biba"}})
+
+	answer, _ = stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "2", commands.IfVersionModifier, version + "0"}})
+
+	assert.Equal(t, notApplied, answer)
+
+	answer, _ = stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "2", commands.IfVersionModifier, version}})
+
+	assert.Equal(t, okAnswer, answer)
+
+	answer, _ = stor.HandleRequest(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "3", commands.IfVersionModifier, version}})
+
+	assert.Equal(t, notApplied, answer)
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"strconv"
+	"strings"
+)
+
+const (
+	noneType = "none"
+
+	isMemberAnswer  = "1"
+	notMemberAnswer = "0"
+)
+
+var errWrongType = errors.New("WRONGTYPE operation against a key holding the wrong kind of value")
+
+func (s *Storage) typedToEngine(req request.Request, write func(request.Request) func() error) (string, error) {
+	key := req.Args[0]
+
+	switch req.RequestType {
+
+	case commands.HSetCommand:
+		s.logger.Debug("started hset command")
+
+		fields, values := splitPairs(req.Args[1:])
+
+		return countAnswer(s.engine.HSET(key, fields, values, commitRequest(req, write)))
+
+	case commands.HGetCommand:
+		s.logger.Debug("started hget command")
+
+		return elementAnswer(s.engine.HGET(key, req.Args[1]))
+
+	case commands.HDelCommand:
+		s.logger.Debug("started hdel command")
+
+		return countAnswer(s.engine.HDEL(key, req.Args[1:]))
+
+	case commands.HGetAllCommand:
+		s.logger.Debug("started hgetall command")
+
+		fields, values, err := s.engine.HGETALL(key)
+
+		if err != nil {
+			return "", err
+		}
+
+		return formatPairs(fields, values), nil
+
+	case commands.LPushCommand:
+		s.logger.Debug("started lpush command")
+
+		return countAnswer(s.engine.LPUSH(key, req.Args[1:], commitRequest(req, write)))
+
+	case commands.RPushCommand:
+		s.logger.Debug("started rpush command")
+
+		return countAnswer(s.engine.RPUSH(key, req.Args[1:], commitRequest(req, write)))
+
+	case commands.LPopCommand:
+		s.logger.Debug("started lpop command")
+
+		return elementAnswer(s.engine.LPOP(key))
+
+	case commands.RPopCommand:
+		s.logger.Debug("started rpop command")
+
+		return elementAnswer(s.engine.RPOP(key))
+
+	case commands.LRangeCommand:
+		s.logger.Debug("started lrange command")
+
+		start, startErr := strconv.Atoi(req.Args[1])
+		stop, stopErr := strconv.Atoi(req.Args[2])
+
+		if startErr != nil || stopErr != nil {
+			return "", errors.New("incorrect index")
+		}
+
+		return elementsAnswer(s.engine.LRANGE(key, start, stop))
+
+	case commands.SAddCommand:
+		s.logger.Debug("started sadd command")
+
+		return countAnswer(s.engine.SADD(key, req.Args[1:], commitRequest(req, write)))
+
+	case commands.SRemCommand:
+		s.logger.Debug("started srem command")
+
+		return countAnswer(s.engine.SREM(key, req.Args[1:]))
+
+	case commands.SMembersCommand:
+		s.logger.Debug("started smembers command")
+
+		return elementsAnswer(s.engine.SMEMBERS(key))
+
+	case commands.SIsMemberCommand:
+		s.logger.Debug("started sismember command")
+
+		isMember, err := s.engine.SISMEMBER(key, req.Args[1])
+
+		if err != nil {
+			return "", err
+		}
+
+		if !isMember {
+			return notMemberAnswer, nil
+		}
+
+		return isMemberAnswer, nil
+	}
+
+	return "", errors.New("incorrect request type")
+}
+
+func countAnswer(count int, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(count), nil
+}
+
+func elementAnswer(element string, found bool, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	if !found {
+		return notFound, nil
+	}
+
+	return element, nil
+}
+
+func elementsAnswer(elements []string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	if len(elements) == 0 {
+		return notFound, nil
+	}
+
+	return strings.Join(elements, pairEndElement), nil
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_HandleTypedRequests(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	type testCase struct {
+		name string
+
+		request request.Request
+
+		expectedAnswer string
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "hset",
+
+			request: request.Request{RequestType: commands.HSetCommand, Args: []string{"user", "name", "biba", "age", "20"}},
+
+			expectedAnswer: "2",
+		},
+		{
+			name: "hget",
+
+			request: request.Request{RequestType: commands.HGetCommand, Args: []string{"user", "name"}},
+
+			expectedAnswer: "biba",
+		},
+		{
+			name: "hgetall",
+
+			request: request.Request{RequestType: commands.HGetAllCommand, Args: []string{"user"}},
+
+			expectedAnswer: "age 20\nname biba",
+		},
+		{
+			name: "get on hash",
+
+			request: request.Request{RequestType: commands.GetCommand, Args: []string{"user"}},
+
+			expectedErr: errWrongType,
+		},
+		{
+			name: "rpush",
+
+			request: request.Request{RequestType: commands.RPushCommand, Args: []string{"queue", "a", "b"}},
+
+			expectedAnswer: "2",
+		},
+		{
+			name: "lrange",
+
+			request: request.Request{RequestType: commands.LRangeCommand, Args: []string{"queue", "0", "-1"}},
+
+			expectedAnswer: "a\nb",
+		},
+		{
+			name: "lpop",
+
+			request: request.Request{RequestType: commands.LPopCommand, Args: []string{"queue"}},
+
+			expectedAnswer: "a",
+		},
+		{
+			name: "sadd",
+
+			request: request.Request{RequestType: commands.SAddCommand, Args: []string{"tags", "a"}},
+
+			expectedAnswer: "1",
+		},
+		{
+			name: "sismember",
+
+			request: request.Request{RequestType: commands.SIsMemberCommand, Args: []string{"tags", "a"}},
+
+			expectedAnswer: isMemberAnswer,
+		},
+		{
+			name: "smembers on list",
+
+			request: request.Request{RequestType: commands.SMembersCommand, Args: []string{"queue"}},
+
+			expectedErr: errWrongType,
+		},
+	}
+
+	for _, test := range testCases {
+		answer, err := stor.HandleRequest(test.request)
+
+		assert.Equal(t, test.expectedAnswer, answer, test.name)
+		assert.Equal(t, test.expectedErr, err, test.name)
+	}
+}
+
+func Test_recoverTypedData(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	batch := request.NewBatch(100)
+
+	err := batch.LoadData(encodeRequests(
+		request.Request{RequestType: commands.RPushCommand, Args: []string{"queue", "a", "b", "c"}},
+		request.Request{RequestType: commands.LPopCommand, Args: []string{"queue"}},
+		request.Request{RequestType: commands.HSetCommand, Args: []string{"user", "name", "biba"}},
+		request.Request{RequestType: commands.SAddCommand, Args: []string{"tags", "a", "b"}},
+		request.Request{RequestType: commands.SRemCommand, Args: []string{"tags", "a"}},
+	))
+
+	assert.NoError(t, err)
+
+	stor.recoverData(batch)
+
+	elements, _ := eng.LRANGE("queue", 0, -1)
+	assert.Equal(t, []string{"b", "c"}, elements)
+
+	value, _, _ := eng.HGET("user", "name")
+	assert.Equal(t, "biba", value)
+
+	members, _ := eng.SMEMBERS("tags")
+	assert.Equal(t, []string{"b"}, members)
+}
@@ -0,0 +1,348 @@
+package compaction
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/encryption"
+	"inmemorykvdb/internal/database/storage/wal/manifest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+const (
+	workDirectory  = "compaction"
+	tmpFileName    = "segment.tmp"
+	markerFileName = "segment.marker"
+)
+
+type Compactor struct {
+	directory string
+	pattern   string
+
+	keyring  *encryption.Keyring
+	manifest *manifest.Manifest
+	logger   *zap.Logger
+}
+
+func NewCompactor(logger *zap.Logger, pattern string, options ...CompactorOption) (*Compactor, error) {
+	if logger == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	if pattern == "" {
+		return nil, errors.New("pattern could not be a empty string")
+	}
+
+	compactor := &Compactor{logger: logger, pattern: pattern}
+
+	for _, option := range options {
+		err := option(compactor)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifestOptions := make([]manifest.ManifestOption, 0, 1)
+
+	if compactor.directory != "" {
+		manifestOptions = append(manifestOptions, manifest.WithDirectory(compactor.directory))
+	}
+
+	segments, err := manifest.NewManifest(logger, pattern, manifestOptions...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	compactor.manifest = segments
+
+	err = compactor.finishInterrupted()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return compactor, nil
+}
+
+type segment struct {
+	index uint64
+	name  string
+}
+
+func (c *Compactor) Compact(upTo uint64) error {
+	c.logger.Debug(fmt.Sprintf("started compaction of segments up to %d", upTo))
+
+	segments, err := c.closedSegments(upTo)
+
+	if err != nil {
+		return err
+	}
+
+	if len(segments) < 2 {
+		c.logger.Debug("nothing to compact")
+		return nil
+	}
+
+	batch := request.NewBatch(0)
+
+	for _, seg := range segments {
+		data, err := os.ReadFile(seg.name)
+
+		if err != nil {
+			return fmt.Errorf("could not read segment %s", seg.name)
+		}
+
+		data, _, err = c.keyring.Decrypt(data)
+
+		if err != nil {
+			return fmt.Errorf("could not compact segment %s: %s", seg.name, err.Error())
+		}
+
+		err = batch.LoadData(data)
+
+		if err != nil {
+			return fmt.Errorf("could not compact segment %s: %s", seg.name, err.Error())
+		}
+	}
+
+	compacted := request.NewBatch(0)
+
+	kept := deduplicate(batch.Data)
+
+	slices.SortStableFunc(kept, func(a, b *request.Request) int {
+		return cmp.Compare(a.LSN, b.LSN)
+	})
+
+	for _, req := range kept {
+		compacted.Add(req)
+	}
+
+	var keptLSN uint64
+
+	if len(kept) != 0 {
+		keptLSN = kept[len(kept)-1].LSN
+	}
+
+	// an empty transaction keeps the last lsn on disk when its record was dropped
+	if keptLSN < batch.LastLSN {
+		compacted.Add(&request.Request{LSN: batch.LastLSN, RequestType: commands.MultiCommand})
+		compacted.Add(&request.Request{LSN: batch.LastLSN, RequestType: commands.ExecCommand})
+	}
+
+	data, err := compacted.ParseBatch()
+
+	if err != nil {
+		return err
+	}
+
+	data, err = c.keyring.Encrypt(data)
+
+	if err != nil {
+		return err
+	}
+
+	err = c.swap(segments, data)
+
+	if err != nil {
+		return err
+	}
+
+	c.logger.Info(fmt.Sprintf("compacted %d segments with %d requests into %d requests",
+		len(segments), len(batch.Data), len(compacted.Data)))
+
+	return nil
+}
+
+func (c *Compactor) swap(segments []segment, data []byte) error {
+	last := segments[len(segments)-1]
+
+	err := os.MkdirAll(c.workPath(""), 0755)
+
+	if err != nil {
+		return errors.New("could not create compaction directory")
+	}
+
+	err = writeFile(c.workPath(tmpFileName), data)
+
+	if err != nil {
+		return err
+	}
+
+	err = writeFile(c.workPath(markerFileName), []byte(strconv.FormatUint(last.index, 10)))
+
+	if err != nil {
+		os.Remove(c.workPath(tmpFileName))
+		return err
+	}
+
+	err = os.Rename(c.workPath(tmpFileName), last.name)
+
+	if err != nil {
+		os.Remove(c.workPath(tmpFileName))
+		os.Remove(c.workPath(markerFileName))
+		return fmt.Errorf("could not replace segment %s", last.name)
+	}
+
+	syncDirectory(c.directory)
+
+	return c.removeMerged(last.index)
+}
+
+func (c *Compactor) finishInterrupted() error {
+	marker, err := os.ReadFile(c.workPath(markerFileName))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.New("could not read compaction marker")
+	}
+
+	if _, err := os.Stat(c.workPath(tmpFileName)); err == nil {
+		c.logger.Info("rolling back interrupted compaction")
+
+		os.Remove(c.workPath(tmpFileName))
+
+		return os.Remove(c.workPath(markerFileName))
+	}
+
+	last, err := strconv.ParseUint(string(marker), 10, 64)
+
+	if err != nil {
+		return errors.New("compaction marker is corrupted")
+	}
+
+	c.logger.Info(fmt.Sprintf("finishing interrupted compaction into segment %d", last))
+
+	return c.removeMerged(last)
+}
+
+func (c *Compactor) removeMerged(last uint64) error {
+	if last != 0 {
+		err := c.manifest.Remove(last - 1)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	syncDirectory(c.directory)
+
+	return os.Remove(c.workPath(markerFileName))
+}
+
+func (c *Compactor) closedSegments(upTo uint64) ([]segment, error) {
+	found, err := c.manifest.Segments()
+
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]segment, 0, len(found))
+
+	for _, seg := range found {
+		if seg.Index <= upTo {
+			segments = append(segments, segment{index: seg.Index, name: c.manifest.Path(seg)})
+		}
+	}
+
+	return segments, nil
+}
+
+func (c *Compactor) workPath(name string) string {
+	return filepath.Join(c.directory, workDirectory, name)
+}
+
+func deduplicate(reqs []*request.Request) []*request.Request {
+	keys := make([]string, 0)
+	histories := make(map[string][]*request.Request)
+
+	apply := func(key string, req *request.Request) {
+		history, found := histories[key]
+
+		if !found {
+			keys = append(keys, key)
+		}
+
+		switch req.RequestType {
+		case commands.SetCommand:
+			history = []*request.Request{req}
+		case commands.DelCommand:
+			history = []*request.Request{req}
+		default:
+			history = append(history, req)
+		}
+
+		histories[key] = history
+	}
+
+	for _, req := range reqs {
+		switch req.RequestType {
+		case commands.MSetCommand:
+			for i := 0; i+1 < len(req.Args); i += 2 {
+				apply(req.Args[i], &request.Request{LSN: req.LSN, RequestType: commands.SetCommand, Args: req.Args[i : i+2]})
+			}
+		case commands.MDelCommand:
+			for _, key := range req.Args {
+				apply(key, &request.Request{LSN: req.LSN, RequestType: commands.DelCommand, Args: []string{key}})
+			}
+		default:
+			if len(req.Args) != 0 {
+				apply(req.Args[0], req)
+			}
+		}
+	}
+
+	compacted := make([]*request.Request, 0, len(keys))
+
+	for _, key := range keys {
+		compacted = append(compacted, histories[key]...)
+	}
+
+	return compacted
+}
+
+func writeFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+
+	if err != nil {
+		return fmt.Errorf("could not create file %s", path)
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(data)
+
+	if err != nil {
+		return fmt.Errorf("could not write file %s", path)
+	}
+
+	err = file.Sync()
+
+	if err != nil {
+		return fmt.Errorf("could not sync file %s", path)
+	}
+
+	return nil
+}
+
+func syncDirectory(directory string) {
+	dir, err := os.Open(directory)
+
+	if err != nil {
+		return
+	}
+
+	dir.Sync()
+	dir.Close()
+}
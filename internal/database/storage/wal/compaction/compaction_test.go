@@ -0,0 +1,358 @@
+package compaction
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_NewCompactor(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		logger  *zap.Logger
+		pattern string
+		options []CompactorOption
+
+		expectedNilObj bool
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "correct compactor",
+
+			logger:  zap.NewNop(),
+			pattern: "wal",
+			options: []CompactorOption{WithDirectory(t.TempDir() + "/")},
+
+			expectedNilObj: false,
+			expectedErr:    nil,
+		},
+		{
+			name: "compactor without logger",
+
+			logger:  nil,
+			pattern: "wal",
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("logger is nil"),
+		},
+		{
+			name: "compactor without pattern",
+
+			logger:  zap.NewNop(),
+			pattern: "",
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("pattern could not be a empty string"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			compactor, err := NewCompactor(test.logger, test.pattern, test.options...)
+
+			assert.Equal(t, test.expectedNilObj, compactor == nil)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func record(requestType int, args ...string) []byte {
+	req := &request.Request{RequestType: requestType, Args: args}
+
+	data, _ := req.ParseToBytes()
+
+	return data
+}
+
+func records(records ...[]byte) []byte {
+	var data []byte
+
+	for _, record := range records {
+		data = append(data, record...)
+	}
+
+	return data
+}
+
+func writeSegments(dir string, segments ...[]byte) {
+	for i, data := range segments {
+		os.WriteFile(dir+"wal"+strconv.Itoa(i+1)+".log", data, 0644)
+	}
+}
+
+func Test_Compact(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		segments [][]byte
+		upTo     uint64
+
+		expectedFiles []string
+		expectedData  []byte
+	}
+
+	testCases := []testCase{
+		{
+			name: "hot keys",
+
+			segments: [][]byte{
+				records(
+					record(commands.SetCommand, "biba", "1"),
+					record(commands.SetCommand, "boba", "1"),
+					record(commands.SetCommand, "biba", "2"),
+				),
+				records(record(commands.SetCommand, "biba", "3"), record(commands.DelCommand, "boba")),
+				record(commands.SetCommand, "aboba", "1"),
+			},
+			upTo: 2,
+
+			expectedFiles: []string{"wal2.log", "wal3.log"},
+			expectedData:  records(record(commands.SetCommand, "biba", "3"), record(commands.DelCommand, "boba")),
+		},
+		{
+			name: "deleted keys are kept as tombstones",
+
+			segments: [][]byte{
+				records(record(commands.SetCommand, "biba", "1"), record(commands.SetCommand, "boba", "1")),
+				records(record(commands.DelCommand, "boba"), record(commands.MSetCommand, "biba", "2", "aboba", "3")),
+			},
+			upTo: 2,
+
+			expectedFiles: []string{"wal2.log"},
+			expectedData: records(
+				record(commands.SetCommand, "biba", "2"),
+				record(commands.DelCommand, "boba"),
+				record(commands.SetCommand, "aboba", "3"),
+			),
+		},
+		{
+			name: "typed values keep history after reset",
+
+			segments: [][]byte{
+				records(record(commands.RPushCommand, "queue", "a"), record(commands.SetCommand, "queue", "b")),
+				records(
+					record(commands.RPushCommand, "queue", "c"),
+					record(commands.MultiCommand),
+					record(commands.LPopCommand, "queue"),
+					record(commands.ExpireAtCommand, "queue", "100"),
+					record(commands.ExecCommand),
+				),
+				records(record(commands.MDelCommand, "queue"), record(commands.SAddCommand, "tags", "x")),
+			},
+			upTo: 3,
+
+			expectedFiles: []string{"wal3.log"},
+			expectedData:  records(record(commands.DelCommand, "queue"), record(commands.SAddCommand, "tags", "x")),
+		},
+		{
+			name: "single segment",
+
+			segments: [][]byte{
+				records(record(commands.SetCommand, "biba", "1"), record(commands.SetCommand, "biba", "2")),
+				record(commands.SetCommand, "biba", "3"),
+			},
+			upTo: 1,
+
+			expectedFiles: []string{"wal1.log", "wal2.log"},
+			expectedData:  records(record(commands.SetCommand, "biba", "1"), record(commands.SetCommand, "biba", "2")),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir() + "/"
+			writeSegments(dir, test.segments...)
+
+			compactor, _ := NewCompactor(zap.NewNop(), "wal", WithDirectory(dir))
+
+			err := compactor.Compact(test.upTo)
+
+			assert.NoError(t, err)
+
+			names, _ := filepath.Glob(dir + "wal*.log")
+
+			for i := range names {
+				names[i] = filepath.Base(names[i])
+			}
+
+			assert.ElementsMatch(t, test.expectedFiles, names)
+
+			data, _ := os.ReadFile(dir + test.expectedFiles[0])
+
+			assert.Equal(t, test.expectedData, data)
+		})
+	}
+}
+
+func Test_CompactKeepsTypedHistory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+	writeSegments(dir,
+		records(record(commands.SetCommand, "queue", "b"), record(commands.RPushCommand, "tasks", "a")),
+		records(
+			record(commands.RPushCommand, "tasks", "c"),
+			record(commands.LPopCommand, "tasks"),
+			record(commands.ExpireAtCommand, "tasks", "100"),
+		),
+	)
+
+	compactor, _ := NewCompactor(zap.NewNop(), "wal", WithDirectory(dir))
+
+	err := compactor.Compact(2)
+
+	assert.NoError(t, err)
+
+	data, _ := os.ReadFile(dir + "wal2.log")
+
+	expectedData := records(
+		record(commands.SetCommand, "queue", "b"),
+		record(commands.RPushCommand, "tasks", "a"),
+		record(commands.RPushCommand, "tasks", "c"),
+		record(commands.LPopCommand, "tasks"),
+		record(commands.ExpireAtCommand, "tasks", "100"),
+	)
+
+	assert.Equal(t, expectedData, data)
+}
+
+func Test_finishInterrupted(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		withTmp bool
+
+		expectedFiles []string
+	}
+
+	testCases := []testCase{
+		{
+			name: "interrupted before swap",
+
+			withTmp: true,
+
+			expectedFiles: []string{"wal1.log", "wal2.log", "wal3.log"},
+		},
+		{
+			name: "interrupted after swap",
+
+			withTmp: false,
+
+			expectedFiles: []string{"wal2.log", "wal3.log"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir() + "/"
+			writeSegments(dir,
+				record(commands.SetCommand, "biba", "1"),
+				record(commands.SetCommand, "biba", "2"),
+				record(commands.SetCommand, "biba", "3"),
+			)
+
+			os.MkdirAll(filepath.Join(dir, workDirectory), 0755)
+			os.WriteFile(filepath.Join(dir, workDirectory, markerFileName), []byte("2"), 0644)
+
+			if test.withTmp {
+				os.WriteFile(filepath.Join(dir, workDirectory, tmpFileName), record(commands.SetCommand, "biba", "2"), 0644)
+			}
+
+			_, err := NewCompactor(zap.NewNop(), "wal", WithDirectory(dir))
+
+			assert.NoError(t, err)
+
+			names, _ := filepath.Glob(dir + "wal*.log")
+
+			for i := range names {
+				names[i] = filepath.Base(names[i])
+			}
+
+			assert.ElementsMatch(t, test.expectedFiles, names)
+
+			leftovers, _ := os.ReadDir(filepath.Join(dir, workDirectory))
+
+			assert.Empty(t, leftovers)
+		})
+	}
+}
+
+func Test_CompactKeepsLSNOrder(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	lsnRecord := func(lsn uint64, requestType int, args ...string) []byte {
+		req := &request.Request{LSN: lsn, RequestType: requestType, Args: args}
+
+		data, _ := req.ParseToBytes()
+
+		return data
+	}
+
+	writeSegments(dir,
+		records(lsnRecord(1, commands.SetCommand, "biba", "1"), lsnRecord(2, commands.SetCommand, "boba", "1")),
+		records(lsnRecord(3, commands.SetCommand, "biba", "2"), lsnRecord(4, commands.DelCommand, "boba")),
+	)
+
+	compactor, _ := NewCompactor(zap.NewNop(), "wal", WithDirectory(dir))
+
+	err := compactor.Compact(2)
+
+	assert.NoError(t, err)
+
+	data, _ := os.ReadFile(dir + "wal2.log")
+
+	expectedData := records(
+		lsnRecord(3, commands.SetCommand, "biba", "2"),
+		lsnRecord(4, commands.DelCommand, "boba"),
+	)
+
+	assert.Equal(t, expectedData, data)
+}
+
+func Test_CompactOrdersSegmentsNumerically(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	segments := make([][]byte, 11)
+
+	for i := range segments {
+		segments[i] = record(commands.SetCommand, "biba", strconv.Itoa(i+1))
+	}
+
+	writeSegments(dir, segments...)
+
+	compactor, _ := NewCompactor(zap.NewNop(), "wal", WithDirectory(dir))
+
+	err := compactor.Compact(11)
+
+	assert.NoError(t, err)
+
+	data, _ := os.ReadFile(dir + "wal11.log")
+
+	assert.Equal(t, record(commands.SetCommand, "biba", "11"), data)
+}
@@ -0,0 +1,30 @@
+package compaction
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/storage/encryption"
+)
+
+type CompactorOption func(*Compactor) error
+
+func WithDirectory(dir string) CompactorOption {
+	return func(c *Compactor) error {
+		if dir == "" {
+			return errors.New("directory could not be a empty string")
+		}
+
+		c.directory = dir
+		return nil
+	}
+}
+
+func WithKeyring(keyring *encryption.Keyring) CompactorOption {
+	return func(c *Compactor) error {
+		if keyring == nil {
+			return errors.New("keyring could not be nil")
+		}
+
+		c.keyring = keyring
+		return nil
+	}
+}
@@ -0,0 +1,325 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	segmentExtension  = ".log"
+	manifestExtension = ".manifest"
+	tmpExtension      = ".tmp"
+	header            = "IMKV-MANIFEST 1"
+	indexWidth        = 10
+)
+
+// locks serializes manifests of the same directory and prefix inside one process,
+// the writer, the reader and the compactor each hold their own instance.
+var locks sync.Map
+
+type Segment struct {
+	Index uint64
+	Name  string
+}
+
+type Manifest struct {
+	directory string
+	prefix    string
+
+	lock   *sync.Mutex
+	logger *zap.Logger
+}
+
+func NewManifest(logger *zap.Logger, prefix string, options ...ManifestOption) (*Manifest, error) {
+	if logger == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	if prefix == "" {
+		return nil, errors.New("prefix could not be a empty string")
+	}
+
+	m := &Manifest{logger: logger, prefix: prefix}
+
+	for _, option := range options {
+		err := option(m)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lock, _ := locks.LoadOrStore(m.path(), &sync.Mutex{})
+	m.lock = lock.(*sync.Mutex)
+
+	return m, nil
+}
+
+// SegmentName returns the name of a new segment, the index is zero-padded
+// so the names also sort in write order outside of the manifest.
+func SegmentName(prefix string, index uint64) string {
+	return fmt.Sprintf("%s%0*d%s", prefix, indexWidth, index, segmentExtension)
+}
+
+// ParseIndex accepts both padded and legacy unpadded segment names.
+func ParseIndex(prefix, name string) (uint64, bool) {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, segmentExtension) {
+		return 0, false
+	}
+
+	index, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, prefix), segmentExtension), 10, 64)
+
+	return index, err == nil
+}
+
+func (m *Manifest) Path(seg Segment) string {
+	return m.directory + seg.Name
+}
+
+// Segments returns the segments in write order.
+func (m *Manifest) Segments() ([]Segment, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.load()
+}
+
+// Add creates the segment with the index unless it is already known and returns it.
+func (m *Manifest) Add(index uint64) (Segment, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	segments, err := m.load()
+
+	if err != nil {
+		return Segment{}, err
+	}
+
+	position, found := slices.BinarySearchFunc(segments, index, func(seg Segment, index uint64) int {
+		return cmp.Compare(seg.Index, index)
+	})
+
+	if found {
+		return segments[position], nil
+	}
+
+	seg := Segment{Index: index, Name: SegmentName(m.prefix, index)}
+
+	file, err := os.OpenFile(m.Path(seg), os.O_WRONLY|os.O_CREATE, 0644)
+
+	if err != nil {
+		m.logger.Error(err.Error())
+		return Segment{}, fmt.Errorf("could not create segment %s", seg.Name)
+	}
+
+	file.Close()
+
+	err = m.save(slices.Insert(segments, position, seg))
+
+	if err != nil {
+		return Segment{}, err
+	}
+
+	m.logger.Debug(fmt.Sprintf("segment %s is added to manifest", seg.Name))
+
+	return seg, nil
+}
+
+// Remove deletes the segments up to the index, the files go first
+// so a crash never leaves the manifest without a file it still has to forget.
+func (m *Manifest) Remove(upTo uint64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	segments, err := m.load()
+
+	if err != nil {
+		return err
+	}
+
+	kept := make([]Segment, 0, len(segments))
+
+	for _, seg := range segments {
+		if seg.Index > upTo {
+			kept = append(kept, seg)
+			continue
+		}
+
+		err = os.Remove(m.Path(seg))
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not remove file %s", m.Path(seg))
+		}
+	}
+
+	if len(kept) == len(segments) {
+		return nil
+	}
+
+	return m.save(kept)
+}
+
+// load reads the manifest and reconciles it with the directory: segments
+// created before a crash are added, segments removed outside of it are dropped.
+func (m *Manifest) load() ([]Segment, error) {
+	recorded := m.read()
+
+	entries, err := os.ReadDir(m.readableDirectory())
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not read wal directory %s", m.directory)
+	}
+
+	existing := make(map[string]bool, len(entries))
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			existing[entry.Name()] = true
+		}
+	}
+
+	segments := make([]Segment, 0, len(existing))
+	known := make(map[uint64]bool, len(existing))
+
+	for _, seg := range recorded {
+		if existing[seg.Name] && !known[seg.Index] {
+			segments = append(segments, seg)
+			known[seg.Index] = true
+		}
+	}
+
+	changed := len(segments) != len(recorded)
+
+	for _, entry := range entries {
+		index, ok := ParseIndex(m.prefix, entry.Name())
+
+		if ok && existing[entry.Name()] && !known[index] {
+			segments = append(segments, Segment{Index: index, Name: entry.Name()})
+			known[index] = true
+			changed = true
+		}
+	}
+
+	slices.SortFunc(segments, func(a, b Segment) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+
+	if changed && len(entries) != 0 {
+		err = m.save(segments)
+
+		if err != nil {
+			m.logger.Warn(err.Error())
+		}
+	}
+
+	return segments, nil
+}
+
+func (m *Manifest) read() []Segment {
+	data, err := os.ReadFile(m.path())
+
+	if err != nil {
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	if !scanner.Scan() || scanner.Text() != header {
+		m.logger.Warn(fmt.Sprintf("manifest %s has unexpected header, rebuilding it", m.path()))
+		return nil
+	}
+
+	segments := make([]Segment, 0)
+
+	for scanner.Scan() {
+		unparsedIndex, name, found := strings.Cut(scanner.Text(), " ")
+
+		index, err := strconv.ParseUint(unparsedIndex, 10, 64)
+
+		if !found || err != nil || filepath.Base(name) != name {
+			m.logger.Warn(fmt.Sprintf("manifest %s has corrupted line %q, rebuilding it", m.path(), scanner.Text()))
+			return nil
+		}
+
+		segments = append(segments, Segment{Index: index, Name: name})
+	}
+
+	return segments
+}
+
+// save replaces the manifest atomically through a synced temporary file.
+func (m *Manifest) save(segments []Segment) error {
+	var buf bytes.Buffer
+
+	buf.WriteString(header + "\n")
+
+	for _, seg := range segments {
+		buf.WriteString(strconv.FormatUint(seg.Index, 10) + " " + seg.Name + "\n")
+	}
+
+	tmpPath := m.path() + tmpExtension
+
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+
+	if err != nil {
+		return fmt.Errorf("could not create manifest %s", tmpPath)
+	}
+
+	_, err = file.Write(buf.Bytes())
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	file.Close()
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("could not write manifest %s", tmpPath)
+	}
+
+	err = os.Rename(tmpPath, m.path())
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("could not replace manifest %s", m.path())
+	}
+
+	syncDirectory(m.readableDirectory())
+
+	return nil
+}
+
+func (m *Manifest) path() string {
+	return m.directory + m.prefix + manifestExtension
+}
+
+func (m *Manifest) readableDirectory() string {
+	if m.directory == "" {
+		return "."
+	}
+
+	return m.directory
+}
+
+func syncDirectory(directory string) {
+	dir, err := os.Open(directory)
+
+	if err != nil {
+		return
+	}
+
+	dir.Sync()
+	dir.Close()
+}
@@ -0,0 +1,198 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_NewManifest(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		logger  *zap.Logger
+		prefix  string
+		options []ManifestOption
+
+		expectedNilObj bool
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "correct manifest",
+
+			logger:  zap.NewNop(),
+			prefix:  "wal",
+			options: []ManifestOption{WithDirectory(t.TempDir() + "/")},
+
+			expectedNilObj: false,
+			expectedErr:    nil,
+		},
+		{
+			name: "manifest without logger",
+
+			logger: nil,
+			prefix: "wal",
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("logger is nil"),
+		},
+		{
+			name: "manifest without prefix",
+
+			logger: zap.NewNop(),
+			prefix: "",
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("prefix could not be a empty string"),
+		},
+		{
+			name: "manifest with empty directory",
+
+			logger:  zap.NewNop(),
+			prefix:  "wal",
+			options: []ManifestOption{WithDirectory("")},
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("directory could not be a empty string"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewManifest(test.logger, test.prefix, test.options...)
+
+			assert.Equal(t, test.expectedNilObj, m == nil)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func Test_ParseIndex(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		fileName string
+
+		expectedIndex uint64
+		expectedOk    bool
+	}
+
+	testCases := []testCase{
+		{name: "padded name", fileName: SegmentName("wal", 12), expectedIndex: 12, expectedOk: true},
+		{name: "legacy name", fileName: "wal12.log", expectedIndex: 12, expectedOk: true},
+		{name: "without index", fileName: "wal.log"},
+		{name: "other prefix", fileName: "biba12.log"},
+		{name: "manifest", fileName: "wal.manifest"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index, ok := ParseIndex("wal", test.fileName)
+
+			assert.Equal(t, test.expectedIndex, index)
+			assert.Equal(t, test.expectedOk, ok)
+		})
+	}
+}
+
+func Test_SegmentsInWriteOrder(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	for _, name := range []string{"wal10.log", "wal9.log", "wal2.log", "walx.log", "boba1.log"} {
+		os.WriteFile(dir+name, nil, 0644)
+	}
+
+	m, _ := NewManifest(zap.NewNop(), "wal", WithDirectory(dir))
+
+	segments, err := m.Segments()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Segment{{2, "wal2.log"}, {9, "wal9.log"}, {10, "wal10.log"}}, segments)
+
+	data, _ := os.ReadFile(dir + "wal.manifest")
+
+	assert.Equal(t, header+"\n2 wal2.log\n9 wal9.log\n10 wal10.log\n", string(data))
+}
+
+func Test_AddAndRemove(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	os.WriteFile(dir+"wal1.log", []byte("biba"), 0644)
+
+	m, _ := NewManifest(zap.NewNop(), "wal", WithDirectory(dir))
+
+	seg, err := m.Add(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Segment{1, "wal1.log"}, seg)
+
+	seg, err = m.Add(11)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Segment{11, SegmentName("wal", 11)}, seg)
+
+	_, err = os.Stat(dir + SegmentName("wal", 11))
+
+	assert.NoError(t, err)
+
+	m.Add(2)
+
+	reopened, _ := NewManifest(zap.NewNop(), "wal", WithDirectory(dir))
+
+	segments, _ := reopened.Segments()
+
+	assert.Equal(t, []Segment{{1, "wal1.log"}, {2, SegmentName("wal", 2)}, {11, SegmentName("wal", 11)}}, segments)
+
+	err = reopened.Remove(2)
+
+	assert.NoError(t, err)
+
+	segments, _ = m.Segments()
+
+	assert.Equal(t, []Segment{{11, SegmentName("wal", 11)}}, segments)
+
+	_, err = os.Stat(dir + "wal1.log")
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_SegmentsReconcileWithDirectory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	os.WriteFile(dir+"wal.manifest", []byte(header+"\n1 wal1.log\n3 wal3.log\n"), 0644)
+	os.WriteFile(dir+"wal1.log", nil, 0644)
+	os.WriteFile(dir+"wal2.log", nil, 0644)
+
+	m, _ := NewManifest(zap.NewNop(), "wal", WithDirectory(dir))
+
+	segments, err := m.Segments()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Segment{{1, "wal1.log"}, {2, "wal2.log"}}, segments)
+
+	os.WriteFile(dir+"wal.manifest", []byte("biba boba"), 0644)
+
+	segments, err = m.Segments()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Segment{{1, "wal1.log"}, {2, "wal2.log"}}, segments)
+}
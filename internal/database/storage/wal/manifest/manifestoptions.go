@@ -0,0 +1,16 @@
+package manifest
+
+import "errors"
+
+type ManifestOption func(*Manifest) error
+
+func WithDirectory(dir string) ManifestOption {
+	return func(m *Manifest) error {
+		if dir == "" {
+			return errors.New("directory could not be a empty string")
+		}
+
+		m.directory = dir
+		return nil
+	}
+}
@@ -2,7 +2,12 @@ package readlevel
 
 import (
 	"errors"
+	"fmt"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/encryption"
 	"inmemorykvdb/internal/database/storage/filesystem"
+	"inmemorykvdb/internal/database/storage/wal/manifest"
+	"os"
 	"path/filepath"
 
 	"go.uber.org/zap"
@@ -18,9 +23,12 @@ type readLevel struct {
 	directory   string
 	pattern     string
 	fileMaxSize int
+
+	keyring  *encryption.Keyring
+	manifest *manifest.Manifest
 }
 
-func NewReadLevel(logger *zap.Logger, pattern string, options ...readLevelOptions) (*readLevel, error) {
+func NewReadLevel(logger *zap.Logger, pattern string, options ...ReadLevelOption) (*readLevel, error) {
 
 	if logger == nil {
 		return nil, errors.New("logger is nil")
@@ -44,23 +52,43 @@ func NewReadLevel(logger *zap.Logger, pattern string, options ...readLevelOption
 		rl.fileMaxSize = defaultFileMaxSize
 	}
 
+	segments, err := manifest.NewManifest(logger, pattern, manifest.WithDirectory(rl.directory))
+
+	if err != nil {
+		return nil, err
+	}
+
+	rl.manifest = segments
+
 	return rl, nil
 }
 
-func (rl *readLevel) findFiles() ([]string, error) {
-	fileNames, err := filepath.Glob(rl.directory + rl.pattern + "*")
+func (rl *readLevel) findFiles(after uint64) ([]string, error) {
+	segments, err := rl.manifest.Segments()
 
 	if err != nil {
-		return nil, errors.New("incorrect pattern to find the files")
+		return nil, err
+	}
+
+	fileNames := make([]string, 0, len(segments))
+
+	for _, seg := range segments {
+		if after == 0 || seg.Index > after {
+			fileNames = append(fileNames, rl.manifest.Path(seg))
+		}
 	}
 
 	return fileNames, nil
 }
 
 func (rl *readLevel) Read() ([][]byte, error) {
-	rl.logger.Debug("started reading files")
+	return rl.ReadAfter(0)
+}
 
-	names, err := rl.findFiles()
+func (rl *readLevel) ReadAfter(segment uint64) ([][]byte, error) {
+	rl.logger.Debug(fmt.Sprintf("started reading files after segment %d", segment))
+
+	names, err := rl.findFiles(segment)
 
 	if err != nil {
 		return nil, err
@@ -74,5 +102,96 @@ func (rl *readLevel) Read() ([][]byte, error) {
 		rl.logger.Error(err.Error())
 	}
 
+	return rl.cutInvalidTail(names, files)
+}
+
+func (rl *readLevel) ReadAfterLSN(lsn uint64) ([][]byte, error) {
+	rl.logger.Debug(fmt.Sprintf("started reading records after lsn %d", lsn))
+
+	names, err := rl.findFiles(0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([][]byte, 0, len(names))
+
+	files, err = filesystem.ReadAll("", names, files)
+
+	if err != nil {
+		rl.logger.Error(err.Error())
+	}
+
+	records := make([][]byte, 0, len(files))
+
+	for i, data := range files {
+		plain, _, err := rl.keyring.Decrypt(data)
+
+		if encryption.IsKeyError(err) {
+			return nil, fmt.Errorf("could not recover segment %s: %w", filepath.Base(names[i]), err)
+		}
+
+		if tail := request.AfterLSN(plain, lsn); len(tail) != 0 {
+			records = append(records, tail)
+		}
+	}
+
+	return records, nil
+}
+
+func (rl *readLevel) cutInvalidTail(names []string, files [][]byte) ([][]byte, error) {
+	for i, data := range files {
+		plain, length, err := rl.open(data)
+
+		if encryption.IsKeyError(err) {
+			return files[:i], fmt.Errorf("could not recover segment %s: %w", filepath.Base(names[i]), err)
+		}
+
+		files[i] = plain
+
+		if err == nil {
+			continue
+		}
+
+		if !isTail(files[i+1:]) {
+			return files[:i+1], fmt.Errorf("segment %s is corrupted at offset %d: %w", filepath.Base(names[i]), length, err)
+		}
+
+		rl.logger.Warn(fmt.Sprintf("truncating torn tail of segment %s at offset %d: %s", filepath.Base(names[i]), length, err.Error()))
+
+		err = os.Truncate(names[i], int64(length))
+
+		if err != nil {
+			return files, fmt.Errorf("could not truncate segment %s", filepath.Base(names[i]))
+		}
+	}
+
 	return files, nil
 }
+
+// open returns the valid records of a segment and the length they take on disk.
+func (rl *readLevel) open(data []byte) ([]byte, int, error) {
+	if encryption.IsEncrypted(data) {
+		return rl.keyring.Decrypt(data)
+	}
+
+	length, err := request.ValidLength(data)
+
+	return data[:length], length, err
+}
+
+func isTail(following [][]byte) bool {
+	for _, data := range following {
+		if len(data) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (rl *readLevel) Remove(segment uint64) error {
+	rl.logger.Debug(fmt.Sprintf("started removing files up to segment %d", segment))
+
+	return rl.manifest.Remove(segment)
+}
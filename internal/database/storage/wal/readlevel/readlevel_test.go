@@ -2,6 +2,10 @@ package readlevel
 
 import (
 	"errors"
+	"fmt"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/encryption"
 	"os"
 	"strconv"
 	"testing"
@@ -14,6 +18,14 @@ const (
 	testFilesCount = 3
 )
 
+func record(args ...string) []byte {
+	req := &request.Request{RequestType: commands.SetCommand, Args: args}
+
+	data, _ := req.ParseToBytes()
+
+	return data
+}
+
 func Test_NewReadLevel(t *testing.T) {
 	t.Parallel()
 
@@ -22,7 +34,7 @@ func Test_NewReadLevel(t *testing.T) {
 
 		pattern string
 		logger  *zap.Logger
-		options []readLevelOptions
+		options []ReadLevelOption
 
 		expectedNilObj bool
 		expectedErr    error
@@ -34,7 +46,7 @@ func Test_NewReadLevel(t *testing.T) {
 
 			pattern: "wal",
 			logger:  zap.NewNop(),
-			options: []readLevelOptions{},
+			options: []ReadLevelOption{},
 
 			expectedNilObj: false,
 			expectedErr:    nil,
@@ -44,7 +56,7 @@ func Test_NewReadLevel(t *testing.T) {
 
 			pattern: "wal",
 			logger:  zap.NewNop(),
-			options: []readLevelOptions{WithFileMaxSize(1024)},
+			options: []ReadLevelOption{WithFileMaxSize(1024)},
 
 			expectedNilObj: false,
 			expectedErr:    nil,
@@ -54,7 +66,7 @@ func Test_NewReadLevel(t *testing.T) {
 
 			pattern: "wal",
 			logger:  nil,
-			options: []readLevelOptions{},
+			options: []ReadLevelOption{},
 
 			expectedNilObj: true,
 			expectedErr:    errors.New("logger is nil"),
@@ -84,69 +96,58 @@ func Test_NewReadLevel(t *testing.T) {
 }
 
 func Test_findFiles(t *testing.T) {
+	t.Parallel()
+
 	type testCase struct {
 		name string
 
 		pattern string
+		after   uint64
 
 		expectedFileNames []string
-		expectedErr       error
 	}
 
-	dir := "C:\\go\\InMemoryKeyValueDB\\test\\readlevel\\findfiles\\"
+	dir := t.TempDir() + "/"
 
-	fileNames := []string{"wal1.log", "wal2.log", "wal3.log", "wal4.log"}
-
-	for _, name := range fileNames {
-		os.Create(dir + name)
+	for _, name := range []string{"wal10.log", "wal2.log", "wal1.log", "wal9.log", "wal.log", "walx.log"} {
+		os.WriteFile(dir+name, nil, 0644)
 	}
 
 	testCases := []testCase{
 		{
-			name: "correct pattern",
+			name: "segments in write order",
 
 			pattern: "wal",
 
-			expectedFileNames: fileNames,
-			expectedErr:       nil,
+			expectedFileNames: []string{dir + "wal1.log", dir + "wal2.log", dir + "wal9.log", dir + "wal10.log"},
 		},
-
 		{
-			name: "correct pattern but empty filenames",
+			name: "segments after index",
 
-			pattern: "write",
+			pattern: "wal",
+			after:   2,
 
-			expectedFileNames: []string(nil),
-			expectedErr:       nil,
+			expectedFileNames: []string{dir + "wal9.log", dir + "wal10.log"},
 		},
-
 		{
-			name: "bad pattern",
+			name: "correct pattern but empty filenames",
 
-			pattern: "[anc",
+			pattern: "write",
 
-			expectedFileNames: []string(nil),
-			expectedErr:       errors.New("incorrect pattern to find the files"),
+			expectedFileNames: []string{},
 		},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
-			rl := &readLevel{pattern: test.pattern, directory: dir}
-
-			names, err := rl.findFiles()
+			rl, _ := NewReadLevel(zap.NewNop(), test.pattern, WithDirectory(dir))
 
-			for i, name := range names {
-				assert.Equal(t, dir+fileNames[i], name)
-			}
+			names, err := rl.findFiles(test.after)
 
-			assert.Equal(t, test.expectedErr, err)
+			assert.Equal(t, test.expectedFileNames, names)
+			assert.Nil(t, err)
 		})
 	}
-
-	for _, name := range fileNames {
-		os.Remove(dir + name)
-	}
 }
 
 func Test_Read(t *testing.T) {
@@ -169,9 +170,9 @@ func Test_Read(t *testing.T) {
 			name: "correct reading",
 
 			pattern:     "val",
-			dataToWrite: [][]byte{{'1', '2', '3', '4', '5'}, {'1', '2', '3', '4', '5'}, {'1', '2', '3', '4', '5'}},
+			dataToWrite: [][]byte{record("biba", "1"), record("biba", "2"), record("biba", "3")},
 
-			expectedData: [][]byte{{'1', '2', '3', '4', '5'}, {'1', '2', '3', '4', '5'}, {'1', '2', '3', '4', '5'}},
+			expectedData: [][]byte{record("biba", "1"), record("biba", "2"), record("biba", "3")},
 			expectedErr:  nil,
 		},
 	}
@@ -206,3 +207,189 @@ func Test_Read(t *testing.T) {
 		})
 	}
 }
+
+func Test_ReadAfterAndRemove(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	for i := 1; i <= testFilesCount; i++ {
+		os.WriteFile(dir+"wal"+strconv.Itoa(i)+".log", record("biba", strconv.Itoa(i)), 0644)
+	}
+
+	rl, _ := NewReadLevel(zap.NewNop(), "wal", WithDirectory(dir))
+
+	data, err := rl.ReadAfter(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{record("biba", "2"), record("biba", "3")}, data)
+
+	err = rl.Remove(2)
+
+	assert.NoError(t, err)
+
+	data, _ = rl.Read()
+
+	assert.Equal(t, [][]byte{record("biba", "3")}, data)
+}
+
+func Test_ReadTruncatesTornTail(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	first := record("biba", "1")
+	last := append(record("biba", "2"), record("boba", "3")...)
+
+	os.WriteFile(dir+"wal1.log", first, 0644)
+	os.WriteFile(dir+"wal2.log", last[:len(last)-2], 0644)
+	os.WriteFile(dir+"wal3.log", nil, 0644)
+
+	rl, _ := NewReadLevel(zap.NewNop(), "wal", WithDirectory(dir))
+
+	data, err := rl.Read()
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{first, record("biba", "2"), {}}, data)
+
+	onDisk, _ := os.ReadFile(dir + "wal2.log")
+
+	assert.Equal(t, record("biba", "2"), onDisk)
+}
+
+func Test_ReadStopsOnCorruptedSegment(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	corrupted := append(record("biba", "1"), record("biba", "2")...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	os.WriteFile(dir+"wal1.log", corrupted, 0644)
+	os.WriteFile(dir+"wal2.log", record("biba", "3"), 0644)
+
+	rl, _ := NewReadLevel(zap.NewNop(), "wal", WithDirectory(dir))
+
+	data, err := rl.Read()
+
+	expectedErr := fmt.Errorf("segment wal1.log is corrupted at offset %d: %w", len(record("biba", "1")), request.ErrCorruptedRecord)
+
+	assert.Equal(t, expectedErr, err)
+	assert.Equal(t, [][]byte{record("biba", "1")}, data)
+
+	onDisk, _ := os.ReadFile(dir + "wal1.log")
+
+	assert.Equal(t, corrupted, onDisk)
+}
+
+func Test_ReadAfterLSN(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	lsnRecord := func(lsn uint64) []byte {
+		req := &request.Request{LSN: lsn, RequestType: commands.SetCommand, Args: []string{"biba", strconv.FormatUint(lsn, 10)}}
+
+		data, _ := req.ParseToBytes()
+
+		return data
+	}
+
+	os.WriteFile(dir+"wal1.log", append(lsnRecord(1), lsnRecord(2)...), 0644)
+	os.WriteFile(dir+"wal2.log", append(lsnRecord(3), lsnRecord(4)...), 0644)
+
+	rl, _ := NewReadLevel(zap.NewNop(), "wal", WithDirectory(dir))
+
+	data, err := rl.ReadAfterLSN(2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{append(lsnRecord(3), lsnRecord(4)...)}, data)
+
+	data, _ = rl.ReadAfterLSN(1)
+
+	assert.Equal(t, [][]byte{lsnRecord(2), append(lsnRecord(3), lsnRecord(4)...)}, data)
+
+	data, _ = rl.ReadAfterLSN(4)
+
+	assert.Empty(t, data)
+}
+
+func Test_ReadEncryptedSegments(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	keyring, _ := encryption.NewKeyring([]byte("1:000102030405060708090a0b0c0d0e0f"))
+
+	first, _ := keyring.Encrypt(record("biba", "2"))
+	second, _ := keyring.Encrypt(record("boba", "3"))
+
+	os.WriteFile(dir+"wal1.log", record("biba", "1"), 0644)
+	os.WriteFile(dir+"wal2.log", append(first, second[:len(second)-2]...), 0644)
+
+	rl, _ := NewReadLevel(zap.NewNop(), "wal", WithDirectory(dir), WithKeyring(keyring))
+
+	data, err := rl.Read()
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{record("biba", "1"), record("biba", "2")}, data)
+
+	onDisk, _ := os.ReadFile(dir + "wal2.log")
+
+	assert.Equal(t, first, onDisk)
+}
+
+func Test_ReadEncryptedSegmentsWithWrongKey(t *testing.T) {
+	t.Parallel()
+
+	keyring, _ := encryption.NewKeyring([]byte("1:000102030405060708090a0b0c0d0e0f"))
+	wrongKeyring, _ := encryption.NewKeyring([]byte("1:ffffffffffffffffffffffffffffffff"))
+
+	encrypted, _ := keyring.Encrypt(record("biba", "2"))
+
+	type testCase struct {
+		name string
+
+		options []ReadLevelOption
+
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "wrong key",
+
+			options: []ReadLevelOption{WithKeyring(wrongKeyring)},
+
+			expectedErr: encryption.ErrWrongKey,
+		},
+		{
+			name: "without key file",
+
+			expectedErr: encryption.ErrMissingKey,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir() + "/"
+
+			os.WriteFile(dir+"wal1.log", record("biba", "1"), 0644)
+			os.WriteFile(dir+"wal2.log", encrypted, 0644)
+
+			rl, _ := NewReadLevel(zap.NewNop(), "wal", append(test.options, WithDirectory(dir))...)
+
+			data, err := rl.Read()
+
+			assert.ErrorIs(t, err, test.expectedErr)
+			assert.ErrorContains(t, err, "could not recover segment wal2.log")
+			assert.Equal(t, [][]byte{record("biba", "1")}, data)
+
+			onDisk, _ := os.ReadFile(dir + "wal2.log")
+
+			assert.Equal(t, encrypted, onDisk)
+		})
+	}
+}
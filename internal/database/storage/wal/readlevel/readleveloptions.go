@@ -1,10 +1,13 @@
 package readlevel
 
-import "errors"
+import (
+	"errors"
+	"inmemorykvdb/internal/database/storage/encryption"
+)
 
-type readLevelOptions func(rl *readLevel) error
+type ReadLevelOption func(rl *readLevel) error
 
-func WithFileMaxSize(maxSize int) readLevelOptions {
+func WithFileMaxSize(maxSize int) ReadLevelOption {
 	return func(rl *readLevel) error {
 		if maxSize == 0 {
 			return errors.New("max file size could not be a zero")
@@ -15,7 +18,7 @@ func WithFileMaxSize(maxSize int) readLevelOptions {
 	}
 }
 
-func WithDirectory(dir string) readLevelOptions {
+func WithDirectory(dir string) ReadLevelOption {
 	return func(rl *readLevel) error {
 		if dir == "" {
 			return errors.New("directory could not be a empty string")
@@ -25,3 +28,14 @@ func WithDirectory(dir string) readLevelOptions {
 		return nil
 	}
 }
+
+func WithKeyring(keyring *encryption.Keyring) ReadLevelOption {
+	return func(rl *readLevel) error {
+		if keyring == nil {
+			return errors.New("keyring could not be nil")
+		}
+
+		rl.keyring = keyring
+		return nil
+	}
+}
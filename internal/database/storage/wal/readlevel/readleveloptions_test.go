@@ -2,6 +2,7 @@ package readlevel
 
 import (
 	"errors"
+	"inmemorykvdb/internal/database/storage/encryption"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -90,3 +91,21 @@ func Test_WithDirectory(t *testing.T) {
 		})
 	}
 }
+
+func Test_WithKeyring(t *testing.T) {
+	t.Parallel()
+
+	keyring, _ := encryption.NewKeyring([]byte("1:000102030405060708090a0b0c0d0e0f"))
+
+	var rl readLevel
+
+	err := WithKeyring(nil)(&rl)
+
+	assert.Equal(t, errors.New("keyring could not be nil"), err)
+	assert.Nil(t, rl.keyring)
+
+	err = WithKeyring(keyring)(&rl)
+
+	assert.Nil(t, err)
+	assert.Equal(t, keyring, rl.keyring)
+}
@@ -3,7 +3,10 @@ package wal
 import (
 	"errors"
 	"fmt"
+	"inmemorykvdb/internal/database/commands"
 	"inmemorykvdb/internal/database/request"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -11,28 +14,96 @@ import (
 
 type readingLayer interface {
 	Read() ([][]byte, error)
+	ReadAfter(segment uint64) ([][]byte, error)
+	Remove(segment uint64) error
 }
 
 type writingLayer interface {
 	Write([]byte) (int, error)
+	Seal() uint64
+	SkipTo(segment uint64)
 }
 
 const (
 	defaultTickerTime = 10 * time.Millisecond
+	recoveryInterval  = time.Second
 )
 
+var ErrDegraded = errors.New("node is read-only, wal is degraded")
+
+// Durability modes decide when a write reaches the disk, every write is acknowledged
+// with the outcome of its flush: always flushes at once together with the writes
+// already waiting, so concurrent writes share one fsync, batch flushes when the batch
+// is filled or on the timer and none also skips the fsync.
+const (
+	DurabilityAlways = "always"
+	DurabilityBatch  = "batch"
+	DurabilityNone   = "none"
+)
+
+// flush is the outcome of the batch a write joined, lsn is the last one before the batch.
+type flush struct {
+	done chan struct{}
+	lsn  uint64
+	err  error
+}
+
+func newFlush() *flush {
+	return &flush{done: make(chan struct{})}
+}
+
+func finishedFlush(err error) *flush {
+	f := newFlush()
+	f.finish(0, err)
+
+	return f
+}
+
+func (f *flush) finish(lsn uint64, err error) {
+	f.lsn = lsn
+	f.err = err
+	close(f.done)
+}
+
+// acknowledgement waits for the flush and returns the lsn of the record at the position in the batch.
+func (f *flush) acknowledgement(position int) func() (uint64, error) {
+	return func() (uint64, error) {
+		<-f.done
+
+		if f.err != nil {
+			return 0, f.err
+		}
+
+		return f.lsn + uint64(position), nil
+	}
+}
+
 type WAL struct {
 	BatchSize int
 	Timeout   time.Duration
 
 	ticker         *time.Ticker
-	requestChannel chan request.Request
-	blockChannel   chan struct{}
+	requestChannel chan []request.Request
+	blockChannel   chan func() (uint64, error)
+	controlChannel chan func()
+
+	durability string
+	pending    *flush
+
+	failureMutex sync.RWMutex
+	failure      error
+	probedAt     time.Time
+
+	recoveryErr error
+
+	flushedMutex sync.Mutex
+	flushed      chan struct{}
 
 	writer writingLayer
 	reader readingLayer
 
 	batch *request.Batch
+	lsn   atomic.Uint64
 
 	logger *zap.Logger
 }
@@ -50,7 +121,17 @@ func NewWal(logger *zap.Logger, walOptions ...WalOptions) (*WAL, error) {
 		option(wal)
 	}
 
+	switch wal.durability {
+	case "":
+		wal.durability = DurabilityBatch
+	case DurabilityAlways, DurabilityBatch, DurabilityNone:
+	default:
+		return nil, fmt.Errorf("unknown durability mode %s", wal.durability)
+	}
+
 	wal.batch = request.NewBatch(wal.BatchSize)
+	wal.pending = newFlush()
+	wal.flushed = make(chan struct{})
 
 	if wal.Timeout == 0 {
 		wal.Timeout = defaultTickerTime
@@ -58,8 +139,9 @@ func NewWal(logger *zap.Logger, walOptions ...WalOptions) (*WAL, error) {
 
 	wal.ticker = time.NewTicker(wal.Timeout)
 
-	wal.blockChannel = make(chan struct{})
-	wal.requestChannel = make(chan request.Request)
+	wal.blockChannel = make(chan func() (uint64, error))
+	wal.requestChannel = make(chan []request.Request)
+	wal.controlChannel = make(chan func())
 
 	wal.startWAL()
 
@@ -84,24 +166,70 @@ func (w *WAL) handleEvents() {
 				w.writeOnDisk()
 			}
 
+			if w.Failure() != nil {
+				w.probe()
+			}
+
 			w.ticker.Reset(w.Timeout)
 
-		case request := <-w.requestChannel:
-			w.batch.Add(&request)
+		case requests := <-w.requestChannel:
+			if err := w.Failure(); err != nil {
+				w.blockChannel <- finishedFlush(err).acknowledgement(0)
+				continue
+			}
 
-			if w.batch.IsFilled() {
+			w.add(requests)
+
+			if w.durability == DurabilityAlways {
+				w.gather()
+			}
+
+			if w.batch.IsFilled() || w.durability == DurabilityAlways {
 				w.writeOnDisk()
 			}
 
-			w.blockChannel <- struct{}{}
+		case action := <-w.controlChannel:
+			action()
+
+			w.blockChannel <- nil
+		}
+	}
+}
+
+func (w *WAL) add(requests []request.Request) {
+	for _, request := range requests {
+		w.batch.Add(&request)
+	}
+
+	w.blockChannel <- w.pending.acknowledgement(len(w.batch.Data))
+}
+
+// gather adds the writes which are already waiting to the batch, they are committed by one flush.
+func (w *WAL) gather() {
+	for !w.batch.IsFilled() {
+		select {
+		case requests := <-w.requestChannel:
+			w.add(requests)
+		default:
+			return
 		}
 	}
 }
 
 func (w *WAL) writeOnDisk() {
 	w.logger.Debug("started write to disk")
+
+	first := w.lsn.Load()
+	lsn := first
+
+	for _, req := range w.batch.Data {
+		lsn++
+		req.LSN = lsn
+	}
+
 	batchInBytes, err := w.batch.ParseBatch()
 	w.batch.Clear()
+	w.lsn.Store(lsn)
 
 	if err != nil {
 		w.logger.Error(err.Error())
@@ -113,27 +241,169 @@ func (w *WAL) writeOnDisk() {
 
 	if err != nil {
 		w.logger.Error(fmt.Sprintf("%s: written %d bytes", err.Error(), count))
+
+		err = fmt.Errorf("%w: %s", ErrDegraded, err.Error())
+		w.setFailure(err)
 	} else {
 		w.logger.Debug("successful writed on disk")
+
+		w.setFailure(nil)
+		w.notifyFlushed()
 	}
+
+	w.pending.finish(first, err)
+	w.pending = newFlush()
 }
 
-func (w *WAL) Write(req request.Request) {
-	w.requestChannel <- req
+// Flushed returns a channel which is closed once the next batch is on disk,
+// replication waits on it to push new records to the slaves.
+func (w *WAL) Flushed() <-chan struct{} {
+	w.flushedMutex.Lock()
+	defer w.flushedMutex.Unlock()
+
+	return w.flushed
+}
+
+func (w *WAL) notifyFlushed() {
+	w.flushedMutex.Lock()
+	defer w.flushedMutex.Unlock()
+
+	close(w.flushed)
+	w.flushed = make(chan struct{})
+}
+
+// probe writes an empty transaction to find out whether the disk has recovered.
+func (w *WAL) probe() {
+	if time.Since(w.probedAt) < recoveryInterval {
+		return
+	}
+
+	w.probedAt = time.Now()
+
+	w.batch.Add(&request.Request{RequestType: commands.MultiCommand})
+	w.batch.Add(&request.Request{RequestType: commands.ExecCommand})
+
+	w.writeOnDisk()
+}
+
+func (w *WAL) setFailure(err error) {
+	w.failureMutex.Lock()
+	defer w.failureMutex.Unlock()
+
+	if w.failure != nil && err == nil {
+		w.logger.Info("wal is recovered, node is writable")
+	}
+
+	if w.failure == nil && err != nil {
+		w.logger.Warn("wal is degraded, node is read-only")
+		w.probedAt = time.Now()
+	}
+
+	w.failure = err
+}
+
+// RecoveryError returns the error which made the last read stop before the end of the wal,
+// a segment the key file could not decrypt or corrupted records followed by other segments,
+// recovering past it would lose data.
+func (w *WAL) RecoveryError() error {
+	return w.recoveryErr
+}
+
+// Failure returns the reason of the degraded state or nil when the wal is healthy.
+func (w *WAL) Failure() error {
+	w.failureMutex.RLock()
+	defer w.failureMutex.RUnlock()
+
+	return w.failure
+}
+
+// Write adds the request to the current batch, the returned function blocks
+// until the batch is flushed and returns the lsn of the request or the failure of the batch.
+func (w *WAL) Write(req request.Request) func() (uint64, error) {
+	w.requestChannel <- []request.Request{req}
+	return <-w.blockChannel
+}
+
+// WriteGroup logs the requests as one transaction, the returned lsn is the one of its exec.
+func (w *WAL) WriteGroup(reqs []request.Request) func() (uint64, error) {
+	group := make([]request.Request, 0, len(reqs)+2)
+
+	group = append(group, request.Request{RequestType: commands.MultiCommand})
+	group = append(group, reqs...)
+	group = append(group, request.Request{RequestType: commands.ExecCommand})
+
+	w.requestChannel <- group
+	return <-w.blockChannel
+}
+
+func (w *WAL) Checkpoint() uint64 {
+	if w.writer == nil {
+		return 0
+	}
+
+	var segment uint64
+
+	w.controlChannel <- func() {
+		if w.batch.ByteSize != 0 {
+			w.writeOnDisk()
+		}
+
+		segment = w.writer.Seal()
+	}
 	<-w.blockChannel
+
+	w.logger.Debug(fmt.Sprintf("checkpoint at segment %d", segment))
+
+	return segment
+}
+
+func (w *WAL) Truncate(segment uint64) error {
+	if w.writer != nil {
+		w.controlChannel <- func() {
+			w.writer.SkipTo(segment)
+		}
+		<-w.blockChannel
+	}
+
+	if w.reader == nil {
+		return nil
+	}
+
+	w.logger.Debug(fmt.Sprintf("started truncate wal up to segment %d", segment))
+
+	return w.reader.Remove(segment)
+}
+
+func (w *WAL) LSN() uint64 {
+	return w.lsn.Load()
+}
+
+func (w *WAL) AdvanceLSN(lsn uint64) {
+	for {
+		current := w.lsn.Load()
+
+		if current >= lsn || w.lsn.CompareAndSwap(current, lsn) {
+			return
+		}
+	}
 }
 
 func (w *WAL) Read() *request.Batch {
+	return w.ReadAfter(0)
+}
+
+func (w *WAL) ReadAfter(segment uint64) *request.Batch {
 	if w.reader == nil {
 		w.logger.Debug("could not read without reader")
 		return nil
 	}
 
 	w.logger.Debug("started read from wal")
-	data, err := w.reader.Read()
+	data, err := w.reader.ReadAfter(segment)
 
 	if err != nil {
 		w.logger.Error(err.Error())
+		w.recoveryErr = err
 	}
 
 	if len(data) == 0 {
@@ -149,5 +419,7 @@ func (w *WAL) Read() *request.Batch {
 		}
 	}
 
+	w.AdvanceLSN(batch.LastLSN)
+
 	return batch
 }
@@ -7,7 +7,11 @@ import (
 	"inmemorykvdb/internal/database/storage/wal/readlevel"
 	"inmemorykvdb/internal/database/storage/wal/writelevel"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,6 +19,38 @@ import (
 	"go.uber.org/zap"
 )
 
+type failingWriter struct {
+	failing atomic.Bool
+	written atomic.Int64
+}
+
+func (w *failingWriter) Write(data []byte) (int, error) {
+	if w.failing.Load() {
+		return 0, errors.New("no space left on device")
+	}
+
+	w.written.Add(int64(len(data)))
+
+	return len(data), nil
+}
+
+func (w *failingWriter) Seal() uint64 {
+	return 0
+}
+
+func (w *failingWriter) SkipTo(segment uint64) {}
+
+func encodeRequests(reqs ...request.Request) []byte {
+	var data []byte
+
+	for _, req := range reqs {
+		encoded, _ := req.ParseToBytes()
+		data = append(data, encoded...)
+	}
+
+	return data
+}
+
 func Test_NewWal(t *testing.T) {
 
 	t.Parallel()
@@ -29,7 +65,7 @@ func Test_NewWal(t *testing.T) {
 		expectedErr    error
 	}
 
-	dir := "C:\\go\\InMemoryKeyValueDB\\test\\wal\\newwal\\"
+	dir := t.TempDir() + "/"
 
 	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir))
 	rl, _ := readlevel.NewReadLevel(zap.NewNop(), "wal", readlevel.WithDirectory(dir))
@@ -65,6 +101,15 @@ func Test_NewWal(t *testing.T) {
 			expectedNilObj: true,
 			expectedErr:    errors.New("logger could not be nil"),
 		},
+		{
+			name: "wal with unknown durability",
+
+			logger:  zap.NewNop(),
+			options: []WalOptions{WithDurability("sometimes")},
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("unknown durability mode sometimes"),
+		},
 	}
 
 	for _, test := range testCases {
@@ -85,37 +130,58 @@ func Test_NewWal(t *testing.T) {
 }
 
 func Test_WriteToWal(t *testing.T) {
-	dir := "C:\\go\\InMemoryKeyValueDB\\test\\wal\\writetowal\\"
+	dir := t.TempDir() + "/"
 	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir))
 
 	wal, _ := NewWal(zap.NewNop(), WithWriter(wl))
 
 	go func() {
 		<-wal.requestChannel
-		wal.blockChannel <- struct{}{}
+		wal.blockChannel <- finishedFlush(nil).acknowledgement(0)
 	}()
 
 	wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
 }
 
+func Test_WriteGroupToWal(t *testing.T) {
+	wal := &WAL{requestChannel: make(chan []request.Request), blockChannel: make(chan func() (uint64, error))}
+
+	var group []request.Request
+
+	go func() {
+		group = <-wal.requestChannel
+		wal.blockChannel <- finishedFlush(nil).acknowledgement(0)
+	}()
+
+	wal.WriteGroup([]request.Request{{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}}})
+
+	expectedGroup := []request.Request{
+		{RequestType: commands.MultiCommand},
+		{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+		{RequestType: commands.ExecCommand},
+	}
+
+	assert.Equal(t, expectedGroup, group)
+}
+
 func Test_writeOnDisk(t *testing.T) {
-	dir := "C:\\go\\InMemoryKeyValueDB\\test\\wal\\writeondisk\\"
+	dir := t.TempDir() + "/"
 
 	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir))
 
 	wal, _ := NewWal(zap.NewNop(), WithBatchSize(100), WithWriter(wl))
 
 	wal.batch.Data = append(wal.batch.Data, &request.Request{RequestType: commands.DelCommand, Args: []string{"biba"}})
-	expectedData := "DEL biba\n"
+	expectedData := encodeRequests(request.Request{LSN: 1, RequestType: commands.DelCommand, Args: []string{"biba"}})
 
 	wal.writeOnDisk()
 
 	data, _ := os.ReadFile(wl.LastFileName)
-	assert.Equal(t, expectedData, string(data))
+	assert.Equal(t, expectedData, data)
 }
 
 func Test_handleEvents(t *testing.T) {
-	dir := "C:\\go\\InMemoryKeyValueDB\\test\\wal\\handleevents\\"
+	dir := t.TempDir() + "/"
 
 	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir))
 
@@ -134,7 +200,7 @@ func Test_handleEvents(t *testing.T) {
 
 func Test_Read(t *testing.T) {
 
-	dir := "C:\\go\\InMemoryKeyValueDB\\test\\wal\\read\\"
+	dir := t.TempDir() + "/"
 
 	type testCase struct {
 		name string
@@ -148,8 +214,10 @@ func Test_Read(t *testing.T) {
 		{
 			name: "correct data",
 
-			data: []byte("SET" + request.DelimElement + "BIBA" + request.DelimElement +
-				"BOBA" + request.EndElement + "DEL" + request.DelimElement + "BIBA" + request.EndElement),
+			data: encodeRequests(
+				request.Request{RequestType: commands.SetCommand, Args: []string{"BIBA", "BOBA"}},
+				request.Request{RequestType: commands.DelCommand, Args: []string{"BIBA"}},
+			),
 
 			expectedRequests: []*request.Request{
 				{
@@ -167,8 +235,10 @@ func Test_Read(t *testing.T) {
 		{
 			name: "reading with invalid data",
 
-			data: []byte("SET" + request.DelimElement + "BIBA" + request.DelimElement +
-				"BOBA" + request.EndElement + "LOL" + request.DelimElement + "KEK" + request.EndElement),
+			data: encodeRequests(
+				request.Request{RequestType: commands.SetCommand, Args: []string{"BIBA", "BOBA"}},
+				request.Request{RequestType: commands.DelCommand},
+			),
 
 			expectedRequests: []*request.Request{
 				{
@@ -198,3 +268,297 @@ func Test_Read(t *testing.T) {
 		})
 	}
 }
+
+func Test_CheckpointAndTruncate(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir), writelevel.WithFileName("wal"))
+	rl, _ := readlevel.NewReadLevel(zap.NewNop(), "wal", readlevel.WithDirectory(dir))
+
+	wal, _ := NewWal(zap.NewNop(), WithBatchSize(100), WithBatchTimeout(time.Hour), WithWriter(wl), WithReader(rl))
+
+	wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+
+	segment := wal.Checkpoint()
+
+	assert.Equal(t, uint64(1), segment)
+
+	wal.Write(request.Request{RequestType: commands.DelCommand, Args: []string{"biba"}})
+	wal.Checkpoint()
+
+	batch := wal.ReadAfter(segment)
+
+	assert.Equal(t, []*request.Request{{LSN: 2, RequestType: commands.DelCommand, Args: []string{"biba"}}}, batch.Data)
+
+	err := wal.Truncate(segment)
+
+	assert.NoError(t, err)
+	assert.Equal(t, batch.Data, wal.Read().Data)
+}
+
+func Test_RecoveryError(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir), writelevel.WithFileName("wal"))
+
+	wal, _ := NewWal(zap.NewNop(), WithBatchSize(100), WithBatchTimeout(time.Hour), WithWriter(wl))
+
+	wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+	wal.Checkpoint()
+
+	wal.Write(request.Request{RequestType: commands.DelCommand, Args: []string{"biba"}})
+	wal.Checkpoint()
+
+	names, _ := filepath.Glob(dir + "wal*.log")
+	slices.Sort(names)
+
+	corrupted, _ := os.ReadFile(names[0])
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	os.WriteFile(names[0], corrupted, 0644)
+
+	rl, _ := readlevel.NewReadLevel(zap.NewNop(), "wal", readlevel.WithDirectory(dir))
+
+	restarted, _ := NewWal(zap.NewNop(), WithReader(rl))
+
+	restarted.Read()
+
+	assert.ErrorIs(t, restarted.RecoveryError(), request.ErrCorruptedRecord)
+}
+
+func Test_LSN(t *testing.T) {
+	dir := t.TempDir() + "/"
+
+	wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir), writelevel.WithFileName("wal"))
+
+	wal, _ := NewWal(zap.NewNop(), WithBatchSize(100), WithBatchTimeout(time.Hour), WithWriter(wl))
+
+	set := wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+
+	assert.Equal(t, uint64(0), wal.LSN())
+
+	group := wal.WriteGroup([]request.Request{{RequestType: commands.DelCommand, Args: []string{"biba"}}})
+	wal.Checkpoint()
+
+	assert.Equal(t, uint64(4), wal.LSN())
+
+	lsn, err := set()
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), lsn)
+
+	lsn, err = group()
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), lsn)
+
+	rl, _ := readlevel.NewReadLevel(zap.NewNop(), "wal", readlevel.WithDirectory(dir))
+
+	restarted, _ := NewWal(zap.NewNop(), WithReader(rl))
+
+	batch := restarted.Read()
+
+	assert.Equal(t, uint64(4), restarted.LSN())
+	assert.Equal(t, []*request.Request{
+		{LSN: 1, RequestType: commands.SetCommand, Args: []string{"biba", "boba"}},
+		{LSN: 3, RequestType: commands.DelCommand, Args: []string{"biba"}},
+	}, batch.Data)
+
+	restarted.AdvanceLSN(2)
+
+	assert.Equal(t, uint64(4), restarted.LSN())
+
+	restarted.AdvanceLSN(10)
+
+	assert.Equal(t, uint64(10), restarted.LSN())
+}
+
+func Test_Durability(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		durability string
+
+		expectedAcknowledged bool
+	}
+
+	testCases := []testCase{
+		{name: "always flushes at once", durability: DurabilityAlways, expectedAcknowledged: true},
+		{name: "batch waits for the batch", durability: DurabilityBatch, expectedAcknowledged: false},
+		{name: "none waits for the batch", durability: DurabilityNone, expectedAcknowledged: false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir() + "/"
+
+			wl, _ := writelevel.NewWriteLevel(zap.NewNop(), writelevel.WithFilePath(dir), writelevel.WithFileName("wal"))
+
+			wal, _ := NewWal(zap.NewNop(), WithBatchSize(100), WithBatchTimeout(time.Hour),
+				WithWriter(wl), WithDurability(test.durability))
+
+			synced := wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+			grouped := wal.WriteGroup([]request.Request{{RequestType: commands.DelCommand, Args: []string{"biba"}}})
+
+			assert.Equal(t, test.expectedAcknowledged, isAcknowledged(synced))
+			assert.Equal(t, test.expectedAcknowledged, isAcknowledged(grouped))
+
+			wal.Checkpoint()
+
+			assert.True(t, isAcknowledged(synced))
+			assert.True(t, isAcknowledged(grouped))
+
+			next := wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"boba", "biba"}})
+
+			assert.Equal(t, test.expectedAcknowledged, isAcknowledged(next))
+		})
+	}
+}
+
+type blockingWriter struct {
+	release chan struct{}
+	writes  atomic.Int64
+}
+
+func (w *blockingWriter) Write(data []byte) (int, error) {
+	w.writes.Add(1)
+	<-w.release
+
+	return len(data), nil
+}
+
+func (w *blockingWriter) Seal() uint64 {
+	return 0
+}
+
+func (w *blockingWriter) SkipTo(segment uint64) {}
+
+func Test_DurabilityAlwaysGroupsWaitingWrites(t *testing.T) {
+	t.Parallel()
+
+	writer := &blockingWriter{release: make(chan struct{})}
+
+	wal, _ := NewWal(zap.NewNop(), WithBatchSize(1<<20), WithBatchTimeout(time.Hour),
+		WithWriter(writer), WithDurability(DurabilityAlways))
+
+	first := wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})
+
+	assert.Eventually(t, func() bool {
+		return writer.writes.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"boba", "biba"}})()
+
+			assert.NoError(t, err)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(writer.release)
+
+	wg.Wait()
+
+	lsn, err := first()
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), lsn)
+	assert.Equal(t, int64(2), writer.writes.Load())
+	assert.Equal(t, uint64(9), wal.LSN())
+}
+
+func isAcknowledged(wait func() (uint64, error)) bool {
+	done := make(chan struct{})
+
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+func Test_WriteFailureDegradesWal(t *testing.T) {
+	t.Parallel()
+
+	writer := &failingWriter{}
+	writer.failing.Store(true)
+
+	wal, _ := NewWal(zap.NewNop(), WithBatchSize(100), WithWriter(writer), WithDurability(DurabilityAlways))
+
+	_, err := wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})()
+
+	assert.ErrorIs(t, err, ErrDegraded)
+	assert.Equal(t, err, wal.Failure())
+
+	_, err = wal.WriteGroup([]request.Request{{RequestType: commands.DelCommand, Args: []string{"biba"}}})()
+
+	assert.ErrorIs(t, err, ErrDegraded)
+
+	writer.failing.Store(false)
+
+	assert.Eventually(t, func() bool {
+		return wal.Failure() == nil
+	}, 3*recoveryInterval, 10*time.Millisecond)
+
+	written := writer.written.Load()
+
+	assert.Equal(t, int64(len(encodeRequests(
+		request.Request{LSN: 2, RequestType: commands.MultiCommand},
+		request.Request{LSN: 3, RequestType: commands.ExecCommand},
+	))), written)
+
+	_, err = wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})()
+
+	assert.NoError(t, err)
+}
+
+func Test_Flushed(t *testing.T) {
+	t.Parallel()
+
+	writer := &failingWriter{}
+	writer.failing.Store(true)
+
+	wal, _ := NewWal(zap.NewNop(), WithBatchSize(100), WithWriter(writer), WithDurability(DurabilityAlways))
+
+	flushed := wal.Flushed()
+
+	wal.Write(request.Request{RequestType: commands.SetCommand, Args: []string{"biba", "boba"}})()
+
+	assert.NotNil(t, wal.Failure())
+
+	select {
+	case <-flushed:
+		assert.Fail(t, "failed write is notified as flushed")
+	default:
+	}
+
+	writer.failing.Store(false)
+
+	assert.Eventually(t, func() bool {
+		select {
+		case <-flushed:
+			return true
+		default:
+			return false
+		}
+	}, 3*recoveryInterval, 10*time.Millisecond)
+
+	assert.NotEqual(t, flushed, wal.Flushed())
+}
@@ -29,3 +29,9 @@ func WithWriter(writer writingLayer) WalOptions {
 		w.writer = writer
 	}
 }
+
+func WithDurability(durability string) WalOptions {
+	return func(w *WAL) {
+		w.durability = durability
+	}
+}
@@ -21,7 +21,7 @@ func Test_WithBatchSize(t *testing.T) {
 	option := WithBatchSize(testBatchSize)
 	option(&actualWal)
 
-	assert.Equal(t, expectedWal, actualWal)
+	assert.Equal(t, &expectedWal, &actualWal)
 }
 
 func Test_WithBatchTimeout(t *testing.T) {
@@ -35,7 +35,20 @@ func Test_WithBatchTimeout(t *testing.T) {
 	option := WithBatchTimeout(testBatchTimeout)
 	option(&actualWal)
 
-	assert.Equal(t, expectedWal, actualWal)
+	assert.Equal(t, &expectedWal, &actualWal)
+}
+
+func Test_WithDurability(t *testing.T) {
+	t.Parallel()
+
+	var actualWal WAL
+
+	expectedWal := WAL{durability: DurabilityAlways}
+
+	option := WithDurability(DurabilityAlways)
+	option(&actualWal)
+
+	assert.Equal(t, &expectedWal, &actualWal)
 }
 
 func Test_WithWriter(t *testing.T) {
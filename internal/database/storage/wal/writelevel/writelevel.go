@@ -3,32 +3,44 @@ package writelevel
 import (
 	"errors"
 	"fmt"
+	"inmemorykvdb/internal/database/storage/encryption"
+	"inmemorykvdb/internal/database/storage/wal/manifest"
+	"io"
 	"os"
-	"strconv"
 
 	"go.uber.org/zap"
 )
 
 const (
-	defaultFirstFileIndex = 1
-	defaultFileName       = "write_ahead"
-	fileExtension         = ".log"
-	defaultFileMaxSize    = 4096
+	defaultFileName    = "write_ahead"
+	defaultFileMaxSize = 4096
 )
 
+type segment struct {
+	index int
+	file  *os.File
+}
+
 type writeLevel struct {
 	LastFileName string
 
 	filePath    string
 	fileName    string
 	fileMaxSize int
+	skipSync    bool
 
-	nextFileIndex int
+	sealedIndex int
 
-	logger *zap.Logger
+	active     *segment
+	activeSize int
+	next       *segment
+
+	keyring  *encryption.Keyring
+	manifest *manifest.Manifest
+	logger   *zap.Logger
 }
 
-func NewWriteLevel(logger *zap.Logger, options ...writeLevelOptions) (*writeLevel, error) {
+func NewWriteLevel(logger *zap.Logger, options ...WriteLevelOption) (*writeLevel, error) {
 	if logger == nil {
 		return nil, errors.New("logger is nil")
 	}
@@ -49,7 +61,29 @@ func NewWriteLevel(logger *zap.Logger, options ...writeLevelOptions) (*writeLeve
 		wl.fileName = defaultFileName
 	}
 
-	wl.nextFileIndex = defaultFirstFileIndex
+	manifestOptions := make([]manifest.ManifestOption, 0, 1)
+
+	if wl.filePath != "" {
+		manifestOptions = append(manifestOptions, manifest.WithDirectory(wl.filePath))
+	}
+
+	segments, err := manifest.NewManifest(logger, wl.fileName, manifestOptions...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wl.manifest = segments
+
+	lastIndex, err := wl.findLastIndex()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lastIndex != 0 {
+		wl.sealedIndex = lastIndex - 1
+	}
 
 	return wl, nil
 }
@@ -61,64 +95,232 @@ func (wl *writeLevel) Write(data []byte) (int, error) {
 		return 0, errors.New("data is empty")
 	}
 
-	file, err := wl.createFile()
+	if wl.active == nil {
+		err := wl.activate()
+
+		if err != nil {
+			wl.logger.Error(fmt.Sprintf("unable write to file with error: %s", err.Error()))
+			return 0, err
+		}
+	}
+
+	data, err := wl.keyring.Encrypt(data)
 
 	if err != nil {
-		wl.logger.Error(fmt.Sprintf("unable write to file with error: %s", err.Error()))
+		wl.logger.Error(err.Error())
 		return 0, err
 	}
 
-	defer file.Close()
-
 	wl.logger.Debug("started write to file")
 
-	count, err := file.Write(data)
-	file.Sync()
+	count, err := wl.active.file.Write(data)
+	wl.activeSize += count
 
 	if err != nil {
 		wl.logger.Error(fmt.Sprintf("writing file done with error: %s", err.Error()))
+		wl.discard(count)
 		return count, err
 	}
 
+	if !wl.skipSync {
+		err = wl.active.file.Sync()
+
+		if err != nil {
+			wl.logger.Error(fmt.Sprintf("syncing file done with error: %s", err.Error()))
+			wl.discard(count)
+			return count, err
+		}
+	}
+
+	if wl.activeSize >= wl.fileMaxSize {
+		wl.rotate()
+	}
+
 	wl.logger.Debug("writing is success")
 
 	return count, nil
 }
 
-func (wl *writeLevel) checkFileIsExist() {
-	wl.logger.Debug("check existing of file")
-	var stringInd = strconv.Itoa(wl.nextFileIndex)
-	_, err := os.Stat(wl.filePath + wl.fileName + stringInd + fileExtension)
+func (wl *writeLevel) findLastIndex() (int, error) {
+	wl.logger.Debug("started search of last segment")
+
+	segments, err := wl.manifest.Segments()
 
-	for !errors.Is(err, os.ErrNotExist) {
-		wl.nextFileIndex++
-		var stringInd string = strconv.Itoa(wl.nextFileIndex)
-		_, err = os.Stat(wl.filePath + wl.fileName + stringInd + fileExtension)
+	if err != nil {
+		return 0, err
 	}
 
-	wl.logger.Debug("found free file name")
-}
+	var lastIndex int
 
-func (wl *writeLevel) createFile() (*os.File, error) {
+	if len(segments) != 0 {
+		lastIndex = int(segments[len(segments)-1].Index)
+	}
+
+	wl.logger.Debug(fmt.Sprintf("found last segment %d", lastIndex))
+
+	return lastIndex, nil
+}
 
-	wl.checkFileIsExist()
+func (wl *writeLevel) openSegment(index int) (*segment, error) {
+	seg, err := wl.manifest.Add(uint64(index))
 
-	wl.logger.Debug("creating new file")
-	var stringInd = strconv.Itoa(wl.nextFileIndex)
+	if err != nil {
+		wl.logger.Error(err.Error())
+		return nil, errors.New("could not create the file")
+	}
 
-	probablyLastFileName := wl.filePath + wl.fileName + stringInd + fileExtension
-	file, err := os.Create(probablyLastFileName)
+	file, err := os.OpenFile(wl.manifest.Path(seg), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
 
 	if err != nil {
 		wl.logger.Error(err.Error())
 		return nil, errors.New("could not create the file")
 	}
 
-	wl.LastFileName = probablyLastFileName
+	return &segment{index: index, file: file}, nil
+}
+
+func (wl *writeLevel) activate() error {
+	index := wl.sealedIndex + 1
+
+	active := wl.next
+	wl.next = nil
+
+	if active == nil || active.index != index {
+		wl.dropNext(active)
+
+		var err error
+
+		active, err = wl.openSegment(index)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	stat, err := active.file.Stat()
+
+	if err != nil {
+		active.file.Close()
+		return errors.New("could not get stats of file")
+	}
+
+	if stat.Size() != 0 && !wl.matchesEncryption(active) {
+		wl.logger.Info(fmt.Sprintf("segment %d is encrypted differently, starting the next one", index))
+
+		active.file.Close()
+		wl.sealedIndex = index
+
+		return wl.activate()
+	}
+
+	wl.active = active
+	wl.activeSize = int(stat.Size())
+	wl.LastFileName = active.file.Name()
+
+	wl.logger.Debug(fmt.Sprintf("segment %d is active", index))
+
+	wl.preallocate(index + 1)
+
+	return nil
+}
+
+// matchesEncryption keeps a segment either encrypted or plain, so appends
+// never mix both after the encryption is switched.
+func (wl *writeLevel) matchesEncryption(seg *segment) bool {
+	file, err := os.Open(seg.file.Name())
+
+	if err != nil {
+		return false
+	}
+
+	defer file.Close()
+
+	head := make([]byte, encryption.HeaderSize)
+	n, _ := io.ReadFull(file, head)
+
+	return encryption.IsEncrypted(head[:n]) == (wl.keyring != nil)
+}
+
+func (wl *writeLevel) preallocate(index int) {
+	next, err := wl.openSegment(index)
+
+	if err != nil {
+		wl.logger.Warn(fmt.Sprintf("could not preallocate segment %d", index))
+		return
+	}
+
+	wl.next = next
+}
+
+func (wl *writeLevel) dropNext(next *segment) {
+	if next == nil {
+		return
+	}
+
+	next.file.Close()
 
-	wl.nextFileIndex++
+	stat, err := os.Stat(next.file.Name())
 
-	wl.logger.Debug("creating of new file is success")
+	if err == nil && stat.Size() == 0 {
+		os.Remove(next.file.Name())
+	}
+}
+
+func (wl *writeLevel) rotate() {
+	if wl.active == nil {
+		return
+	}
+
+	wl.logger.Debug(fmt.Sprintf("segment %d is sealed", wl.active.index))
+
+	wl.active.file.Close()
+
+	wl.sealedIndex = wl.active.index
+	wl.active = nil
+	wl.activeSize = 0
+}
+
+// discard cuts a failed write off the active segment, so the records
+// appended after the disk recovers do not follow a torn one.
+func (wl *writeLevel) discard(count int) {
+	if count == 0 {
+		return
+	}
+
+	wl.activeSize -= count
+
+	err := wl.active.file.Truncate(int64(wl.activeSize))
+
+	if err != nil {
+		wl.logger.Error(fmt.Sprintf("could not discard failed write of segment %d", wl.active.index))
+	}
+}
+
+func (wl *writeLevel) Seal() uint64 {
+	if wl.active != nil && wl.activeSize != 0 {
+		wl.rotate()
+	}
+
+	return uint64(wl.sealedIndex)
+}
+
+func (wl *writeLevel) SkipTo(segment uint64) {
+	if int(segment) <= wl.sealedIndex {
+		return
+	}
+
+	if wl.active != nil && wl.active.index <= int(segment) {
+		wl.rotate()
+	}
+
+	if wl.active != nil {
+		return
+	}
+
+	if wl.next != nil && wl.next.index <= int(segment) {
+		wl.dropNext(wl.next)
+		wl.next = nil
+	}
 
-	return file, nil
+	wl.sealedIndex = int(segment)
 }
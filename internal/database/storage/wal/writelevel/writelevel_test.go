@@ -2,8 +2,9 @@ package writelevel
 
 import (
 	"errors"
+	"inmemorykvdb/internal/database/storage/encryption"
+	"inmemorykvdb/internal/database/storage/wal/manifest"
 	"os"
-	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,7 +18,7 @@ func Test_NewWriteLevel(t *testing.T) {
 	type testCase struct {
 		name string
 
-		options []writeLevelOptions
+		options []WriteLevelOption
 		logger  *zap.Logger
 
 		expectedNilObj bool
@@ -28,7 +29,7 @@ func Test_NewWriteLevel(t *testing.T) {
 		{
 			name: "default",
 
-			options: []writeLevelOptions{},
+			options: []WriteLevelOption{},
 			logger:  zap.NewNop(),
 
 			expectedNilObj: false,
@@ -37,7 +38,7 @@ func Test_NewWriteLevel(t *testing.T) {
 		{
 			name: "without logger",
 
-			options: []writeLevelOptions{},
+			options: []WriteLevelOption{},
 			logger:  nil,
 
 			expectedNilObj: true,
@@ -46,7 +47,7 @@ func Test_NewWriteLevel(t *testing.T) {
 		{
 			name: "custom",
 
-			options: []writeLevelOptions{
+			options: []WriteLevelOption{
 				WithFileMaxSize(2048),
 				WithFileName("logs"),
 				WithFilePath("../")},
@@ -74,31 +75,23 @@ func Test_NewWriteLevel(t *testing.T) {
 	}
 }
 
-func Test_createFile(t *testing.T) {
+func Test_findLastIndex(t *testing.T) {
 	t.Parallel()
 
-	wl, _ := NewWriteLevel(zap.NewNop())
+	dir := t.TempDir() + "/"
 
-	file, err := wl.createFile()
-
-	require.Nil(t, err)
-
-	_, err = file.Stat()
-
-	assert.NotEqual(t, os.ErrNotExist, err)
-}
-
-func Test_checkFileIsExist(t *testing.T) {
+	for _, name := range []string{"wal2.log", "wal10.log", "wal9.log", "wal.log", "walx.log", "other11.log"} {
+		os.WriteFile(dir+name, nil, 0644)
+	}
 
-	wl, _ := NewWriteLevel(zap.NewNop())
+	wl, _ := NewWriteLevel(zap.NewNop(), WithFilePath(dir), WithFileName("wal"))
 
-	stringInd := strconv.Itoa(wl.nextFileIndex)
-	expectedIndex := wl.nextFileIndex + 1
+	lastIndex, err := wl.findLastIndex()
 
-	os.Create(wl.filePath + wl.fileName + stringInd + fileExtension)
-	wl.checkFileIsExist()
+	require.Nil(t, err)
 
-	assert.Equal(t, expectedIndex, wl.nextFileIndex)
+	assert.Equal(t, 10, lastIndex)
+	assert.Equal(t, 9, wl.sealedIndex)
 }
 
 func Test_Write(t *testing.T) {
@@ -140,10 +133,148 @@ func Test_Write(t *testing.T) {
 			assert.Equal(t, test.expectedErr, err)
 
 			if err == nil {
-				actualData, _ := os.ReadFile("C:/go/InMemoryKeyValueDB/test/wal/wl/write_ahead1.log")
+				actualData, _ := os.ReadFile("C:/go/InMemoryKeyValueDB/test/wal/wl/" + manifest.SegmentName(defaultFileName, 1))
 				assert.Equal(t, test.data, actualData)
-				os.Remove("C:/go/InMemoryKeyValueDB/test/wal/wl/write_ahead1.log")
+				os.Remove("C:/go/InMemoryKeyValueDB/test/wal/wl/" + manifest.SegmentName(defaultFileName, 1))
 			}
 		})
 	}
 }
+
+func Test_WriteAppendsAndRotates(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	wl, _ := NewWriteLevel(zap.NewNop(), WithFilePath(dir), WithFileName("wal"), WithFileMaxSize(10))
+
+	wl.Write([]byte("biba"))
+	wl.Write([]byte("boba"))
+
+	assert.Equal(t, dir+manifest.SegmentName("wal", 1), wl.LastFileName)
+
+	wl.Write([]byte("aboba"))
+	wl.Write([]byte("biba"))
+
+	assert.Equal(t, dir+manifest.SegmentName("wal", 2), wl.LastFileName)
+
+	first, _ := os.ReadFile(dir + manifest.SegmentName("wal", 1))
+	second, _ := os.ReadFile(dir + manifest.SegmentName("wal", 2))
+	preallocated, err := os.Stat(dir + manifest.SegmentName("wal", 3))
+
+	assert.Equal(t, []byte("bibabobaaboba"), first)
+	assert.Equal(t, []byte("biba"), second)
+	assert.NoError(t, err)
+	assert.Zero(t, preallocated.Size())
+}
+
+func Test_WriteResumesActiveSegment(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	wl, _ := NewWriteLevel(zap.NewNop(), WithFilePath(dir), WithFileName("wal"))
+
+	wl.Write([]byte("biba"))
+
+	restarted, _ := NewWriteLevel(zap.NewNop(), WithFilePath(dir), WithFileName("wal"))
+
+	restarted.Write([]byte("boba"))
+
+	assert.Equal(t, dir+manifest.SegmentName("wal", 2), restarted.LastFileName)
+
+	data, _ := os.ReadFile(dir + manifest.SegmentName("wal", 2))
+
+	assert.Equal(t, []byte("boba"), data)
+
+	os.Remove(dir + manifest.SegmentName("wal", 3))
+
+	resumed, _ := NewWriteLevel(zap.NewNop(), WithFilePath(dir), WithFileName("wal"))
+
+	resumed.Write([]byte("aboba"))
+
+	data, _ = os.ReadFile(dir + manifest.SegmentName("wal", 2))
+
+	assert.Equal(t, []byte("bobaaboba"), data)
+}
+
+func Test_SealAndSkipTo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	wl, _ := NewWriteLevel(zap.NewNop(), WithFilePath(dir))
+
+	assert.Equal(t, uint64(0), wl.Seal())
+
+	wl.Write([]byte("SET biba boba\n"))
+
+	assert.Equal(t, uint64(1), wl.Seal())
+	assert.Equal(t, uint64(1), wl.Seal())
+
+	wl.Write([]byte("SET boba biba\n"))
+
+	assert.Equal(t, uint64(2), wl.Seal())
+
+	wl.SkipTo(10)
+	wl.Write([]byte("DEL biba\n"))
+
+	assert.Equal(t, dir+manifest.SegmentName(defaultFileName, 11), wl.LastFileName)
+	assert.Equal(t, uint64(11), wl.Seal())
+
+	_, err := os.Stat(dir + manifest.SegmentName(defaultFileName, 3))
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	wl.SkipTo(5)
+
+	assert.Equal(t, uint64(11), wl.Seal())
+}
+
+func Test_WriteResumesLegacySegment(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	os.WriteFile(dir+"wal3.log", []byte("biba"), 0644)
+
+	wl, _ := NewWriteLevel(zap.NewNop(), WithFilePath(dir), WithFileName("wal"))
+
+	wl.Write([]byte("boba"))
+
+	assert.Equal(t, dir+"wal3.log", wl.LastFileName)
+
+	data, _ := os.ReadFile(dir + "wal3.log")
+
+	assert.Equal(t, []byte("bibaboba"), data)
+}
+
+func Test_WriteEncrypted(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir() + "/"
+
+	os.WriteFile(dir+"wal1.log", []byte("biba"), 0644)
+
+	keyring, _ := encryption.NewKeyring([]byte("1:000102030405060708090a0b0c0d0e0f"))
+
+	wl, err := NewWriteLevel(zap.NewNop(), WithFilePath(dir), WithFileName("wal"), WithKeyring(keyring))
+
+	require.NoError(t, err)
+
+	_, err = wl.Write([]byte("boba"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, dir+manifest.SegmentName("wal", 2), wl.LastFileName)
+
+	plaintext, _ := os.ReadFile(dir + "wal1.log")
+	encrypted, _ := os.ReadFile(dir + manifest.SegmentName("wal", 2))
+
+	assert.Equal(t, []byte("biba"), plaintext)
+	assert.True(t, encryption.IsEncrypted(encrypted))
+
+	plain, _, err := keyring.Decrypt(encrypted)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("boba"), plain)
+}
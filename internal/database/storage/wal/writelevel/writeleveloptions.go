@@ -1,10 +1,13 @@
 package writelevel
 
-import "errors"
+import (
+	"errors"
+	"inmemorykvdb/internal/database/storage/encryption"
+)
 
-type writeLevelOptions func(*writeLevel) error
+type WriteLevelOption func(*writeLevel) error
 
-func WithFileName(fileName string) writeLevelOptions {
+func WithFileName(fileName string) WriteLevelOption {
 	return func(wl *writeLevel) error {
 		if fileName == "" {
 			return errors.New("file name could not be a empty string")
@@ -15,14 +18,14 @@ func WithFileName(fileName string) writeLevelOptions {
 	}
 }
 
-func WithFilePath(path string) writeLevelOptions {
+func WithFilePath(path string) WriteLevelOption {
 	return func(wl *writeLevel) error {
 		wl.filePath = path
 		return nil
 	}
 }
 
-func WithFileMaxSize(maxSize int) writeLevelOptions {
+func WithFileMaxSize(maxSize int) WriteLevelOption {
 	return func(wl *writeLevel) error {
 		if maxSize == 0 {
 			return errors.New("max file size could not be a zero")
@@ -32,3 +35,21 @@ func WithFileMaxSize(maxSize int) writeLevelOptions {
 		return nil
 	}
 }
+
+func WithSync(enabled bool) WriteLevelOption {
+	return func(wl *writeLevel) error {
+		wl.skipSync = !enabled
+		return nil
+	}
+}
+
+func WithKeyring(keyring *encryption.Keyring) WriteLevelOption {
+	return func(wl *writeLevel) error {
+		if keyring == nil {
+			return errors.New("keyring could not be nil")
+		}
+
+		wl.keyring = keyring
+		return nil
+	}
+}
@@ -2,6 +2,7 @@ package writelevel
 
 import (
 	"errors"
+	"inmemorykvdb/internal/database/storage/encryption"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -103,3 +104,37 @@ func Test_WithFilePath(t *testing.T) {
 
 	assert.Equal(t, expectedWL, currentWL)
 }
+
+func Test_WithSync(t *testing.T) {
+	t.Parallel()
+
+	var wl writeLevel
+
+	err := WithSync(false)(&wl)
+
+	assert.Nil(t, err)
+	assert.True(t, wl.skipSync)
+
+	err = WithSync(true)(&wl)
+
+	assert.Nil(t, err)
+	assert.False(t, wl.skipSync)
+}
+
+func Test_WithKeyring(t *testing.T) {
+	t.Parallel()
+
+	keyring, _ := encryption.NewKeyring([]byte("1:000102030405060708090a0b0c0d0e0f"))
+
+	var wl writeLevel
+
+	err := WithKeyring(nil)(&wl)
+
+	assert.Equal(t, errors.New("keyring could not be nil"), err)
+	assert.Nil(t, wl.keyring)
+
+	err = WithKeyring(keyring)(&wl)
+
+	assert.Nil(t, err)
+	assert.Equal(t, keyring, wl.keyring)
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"slices"
+	"strconv"
+)
+
+func (s *Storage) sortedSetToEngine(req request.Request, write func(request.Request) func() error) (string, error) {
+	key := req.Args[0]
+
+	switch req.RequestType {
+
+	case commands.ZAddCommand:
+		s.logger.Debug("started zadd command")
+
+		scores, members, err := parseScoredMembers(req.Args[1:])
+
+		if err != nil {
+			return "", err
+		}
+
+		return countAnswer(s.engine.ZADD(key, scores, members, commitRequest(req, write)))
+
+	case commands.ZRemCommand:
+		s.logger.Debug("started zrem command")
+
+		return countAnswer(s.engine.ZREM(key, req.Args[1:]))
+
+	case commands.ZIncrByCommand:
+		s.logger.Debug("started zincrby command")
+
+		delta, err := parseScore(req.Args[1])
+
+		if err != nil {
+			return "", err
+		}
+
+		score, err := s.engine.ZINCRBY(key, delta, req.Args[2], commitScore(key, req.Args[2], write))
+
+		if err != nil {
+			return "", err
+		}
+
+		return formatScore(score), nil
+
+	case commands.ZScoreCommand:
+		s.logger.Debug("started zscore command")
+
+		score, found, err := s.engine.ZSCORE(key, req.Args[1])
+
+		return elementAnswer(formatScore(score), found, err)
+
+	case commands.ZRankCommand:
+		s.logger.Debug("started zrank command")
+
+		rank, found, err := s.engine.ZRANK(key, req.Args[1])
+
+		return elementAnswer(strconv.Itoa(rank), found, err)
+
+	case commands.ZRangeCommand:
+		s.logger.Debug("started zrange command")
+
+		start, startErr := strconv.Atoi(req.Args[1])
+		stop, stopErr := strconv.Atoi(req.Args[2])
+
+		if startErr != nil || stopErr != nil {
+			return "", errors.New("incorrect index")
+		}
+
+		members, scores, err := s.engine.ZRANGE(key, start, stop)
+
+		return scoredAnswer(members, scores, withScores(req.Args), err)
+
+	case commands.ZRangeByScoreCommand:
+		s.logger.Debug("started zrangebyscore command")
+
+		min, minErr := parseScore(req.Args[1])
+		max, maxErr := parseScore(req.Args[2])
+
+		if minErr != nil || maxErr != nil {
+			return "", errors.New("incorrect score")
+		}
+
+		members, scores, err := s.engine.ZRANGEBYSCORE(key, min, max)
+
+		return scoredAnswer(members, scores, withScores(req.Args), err)
+	}
+
+	return "", errors.New("incorrect request type")
+}
+
+func parseScore(unparsed string) (float64, error) {
+	score, err := strconv.ParseFloat(unparsed, 64)
+
+	if err != nil {
+		return 0, errors.New("incorrect score")
+	}
+
+	return score, nil
+}
+
+func parseScoredMembers(args []string) ([]float64, []string, error) {
+	unparsedScores, members := splitPairs(args)
+
+	scores := make([]float64, len(unparsedScores))
+
+	for i, unparsed := range unparsedScores {
+		score, err := parseScore(unparsed)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		scores[i] = score
+	}
+
+	return scores, members, nil
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+func withScores(args []string) bool {
+	return slices.Contains(args, commands.WithScoresModifier)
+}
+
+func scoredAnswer(members []string, scores []float64, withScores bool, err error) (string, error) {
+	if err != nil || !withScores {
+		return elementsAnswer(members, err)
+	}
+
+	formatted := make([]string, len(scores))
+
+	for i, score := range scores {
+		formatted[i] = formatScore(score)
+	}
+
+	return formatPairs(members, formatted), nil
+}
+
+func commitScore(key string, member string, write func(request.Request) func() error) func(score float64) func() error {
+	if write == nil {
+		return nil
+	}
+
+	return func(score float64) func() error {
+		return write(request.Request{RequestType: commands.ZAddCommand, Args: []string{key, formatScore(score), member}})
+	}
+}
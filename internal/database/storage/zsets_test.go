@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"errors"
+	"inmemorykvdb/internal/database/commands"
+	"inmemorykvdb/internal/database/request"
+	"inmemorykvdb/internal/database/storage/engine"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_HandleSortedSetRequests(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	type testCase struct {
+		name string
+
+		request request.Request
+
+		expectedAnswer string
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "zadd",
+
+			request: request.Request{RequestType: commands.ZAddCommand, Args: []string{"board", "10", "biba", "5", "boba", "7.5", "aboba"}},
+
+			expectedAnswer: "3",
+		},
+		{
+			name: "zadd with incorrect score",
+
+			request: request.Request{RequestType: commands.ZAddCommand, Args: []string{"board", "ten", "biba"}},
+
+			expectedErr: errors.New("incorrect score"),
+		},
+		{
+			name: "zscore",
+
+			request: request.Request{RequestType: commands.ZScoreCommand, Args: []string{"board", "aboba"}},
+
+			expectedAnswer: "7.5",
+		},
+		{
+			name: "zscore of missing member",
+
+			request: request.Request{RequestType: commands.ZScoreCommand, Args: []string{"board", "pupa"}},
+
+			expectedAnswer: notFound,
+		},
+		{
+			name: "zrank",
+
+			request: request.Request{RequestType: commands.ZRankCommand, Args: []string{"board", "biba"}},
+
+			expectedAnswer: "2",
+		},
+		{
+			name: "zrange",
+
+			request: request.Request{RequestType: commands.ZRangeCommand, Args: []string{"board", "0", "-1"}},
+
+			expectedAnswer: "boba\naboba\nbiba",
+		},
+		{
+			name: "zrange with scores",
+
+			request: request.Request{RequestType: commands.ZRangeCommand, Args: []string{"board", "0", "1", commands.WithScoresModifier}},
+
+			expectedAnswer: "boba 5\naboba 7.5",
+		},
+		{
+			name: "zrangebyscore",
+
+			request: request.Request{RequestType: commands.ZRangeByScoreCommand, Args: []string{"board", "6", "10"}},
+
+			expectedAnswer: "aboba\nbiba",
+		},
+		{
+			name: "zrangebyscore without matches",
+
+			request: request.Request{RequestType: commands.ZRangeByScoreCommand, Args: []string{"board", "100", "200"}},
+
+			expectedAnswer: notFound,
+		},
+		{
+			name: "zincrby",
+
+			request: request.Request{RequestType: commands.ZIncrByCommand, Args: []string{"board", "-6", "biba"}},
+
+			expectedAnswer: "4",
+		},
+		{
+			name: "zrem",
+
+			request: request.Request{RequestType: commands.ZRemCommand, Args: []string{"board", "boba", "pupa"}},
+
+			expectedAnswer: "1",
+		},
+		{
+			name: "get on sorted set",
+
+			request: request.Request{RequestType: commands.GetCommand, Args: []string{"board"}},
+
+			expectedErr: errWrongType,
+		},
+	}
+
+	for _, test := range testCases {
+		answer, err := stor.HandleRequest(test.request)
+
+		assert.Equal(t, test.expectedAnswer, answer, test.name)
+		assert.Equal(t, test.expectedErr, err, test.name)
+	}
+}
+
+func Test_ZIncrByLogsResultingScore(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+	testWal := &recordingWal{}
+
+	stor, _ := NewStorage(zap.NewNop(), eng, WithWal(testWal))
+
+	stor.HandleRequest(request.Request{RequestType: commands.ZIncrByCommand, Args: []string{"board", "2.5", "biba"}})
+	stor.HandleRequest(request.Request{RequestType: commands.ZIncrByCommand, Args: []string{"board", "1", "biba"}})
+
+	assert.Equal(t, []request.Request{
+		{RequestType: commands.ZAddCommand, Args: []string{"board", "2.5", "biba"}},
+		{RequestType: commands.ZAddCommand, Args: []string{"board", "3.5", "biba"}},
+	}, testWal.requests)
+}
+
+func Test_recoverSortedSetData(t *testing.T) {
+	t.Parallel()
+
+	eng, _ := engine.NewInMemoryEngine(zap.NewNop())
+
+	stor, _ := NewStorage(zap.NewNop(), eng)
+
+	batch := request.NewBatch(100)
+
+	err := batch.LoadData(encodeRequests(
+		request.Request{RequestType: commands.ZAddCommand, Args: []string{"board", "1", "biba", "2", "boba"}},
+		request.Request{RequestType: commands.ZAddCommand, Args: []string{"board", "3", "biba"}},
+		request.Request{RequestType: commands.ZRemCommand, Args: []string{"board", "boba"}},
+	))
+
+	assert.NoError(t, err)
+
+	stor.recoverData(batch)
+
+	members, scores, _ := eng.ZRANGE("board", 0, -1)
+
+	assert.Equal(t, []string{"biba"}, members)
+	assert.Equal(t, []float64{3}, scores)
+}
@@ -0,0 +1,53 @@
+package initialization
+
+import (
+	"errors"
+	"inmemorykvdb/internal/config"
+	"inmemorykvdb/internal/database/storage/wal/compaction"
+
+	"go.uber.org/zap"
+)
+
+func createCompactor(logger *zap.Logger, cnfg *config.WalConfig, replicaCnfg *config.ReplicaConfig) (compactionLayer, error) {
+	if logger == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	if replicaCnfg != nil && replicaCnfg.ReplicaType == slave {
+		return nil, nil
+	}
+
+	if cnfg == nil {
+		return nil, nil
+	}
+
+	pattern := cnfg.FileName
+
+	if pattern == "" {
+		pattern = defaultPattern
+	}
+
+	keyring, err := createKeyring(cnfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]compaction.CompactorOption, 0, 2)
+
+	if cnfg.DataDirectory != "" {
+		options = append(options, compaction.WithDirectory(cnfg.DataDirectory))
+	}
+
+	if keyring != nil {
+		options = append(options, compaction.WithKeyring(keyring))
+	}
+
+	compactor, err := compaction.NewCompactor(logger, pattern, options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return compactor, nil
+}
@@ -0,0 +1,15 @@
+package initialization
+
+import (
+	"inmemorykvdb/internal/config"
+	"inmemorykvdb/internal/database/storage/encryption"
+)
+
+// createKeyring returns nil when the key file is not configured, the data stays in plaintext then.
+func createKeyring(cnfg *config.WalConfig) (*encryption.Keyring, error) {
+	if cnfg == nil || cnfg.KeyFile == "" {
+		return nil, nil
+	}
+
+	return encryption.LoadKeyring(cnfg.KeyFile)
+}
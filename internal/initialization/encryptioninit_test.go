@@ -0,0 +1,70 @@
+package initialization
+
+import (
+	"errors"
+	"inmemorykvdb/internal/config"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_createKeyring(t *testing.T) {
+	keyFile := t.TempDir() + "/wal.key"
+
+	os.WriteFile(keyFile, []byte("1:000102030405060708090a0b0c0d0e0f\n"), 0600)
+
+	type testCase struct {
+		name string
+
+		cnfg *config.WalConfig
+
+		expectedNilObj bool
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "nil config",
+
+			expectedNilObj: true,
+			expectedErr:    nil,
+		},
+
+		{
+			name: "without key file",
+
+			cnfg: &config.WalConfig{},
+
+			expectedNilObj: true,
+			expectedErr:    nil,
+		},
+
+		{
+			name: "with key file",
+
+			cnfg: &config.WalConfig{KeyFile: keyFile},
+
+			expectedNilObj: false,
+			expectedErr:    nil,
+		},
+
+		{
+			name: "missing key file",
+
+			cnfg: &config.WalConfig{KeyFile: keyFile + ".old"},
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("could not read key file " + keyFile + ".old"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			keyring, err := createKeyring(test.cnfg)
+
+			assert.Equal(t, test.expectedNilObj, keyring == nil)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"errors"
 	"inmemorykvdb/internal/config"
 	"inmemorykvdb/internal/database/storage/engine"
+	"inmemorykvdb/pkg/parsing"
 
 	"go.uber.org/zap"
 )
 
 const (
 	inMemoryType = "in_memory"
+	orderedType  = "ordered"
+
+	minSizeLen = 2
 )
 
 func createEngine(config *config.EngineConfig, logger *zap.Logger) (engineLayer, error) {
@@ -22,15 +26,46 @@ func createEngine(config *config.EngineConfig, logger *zap.Logger) (engineLayer,
 		return engine.NewInMemoryEngine(logger)
 	}
 
+	options, err := createEngineOptions(config)
+
+	if err != nil {
+		return nil, err
+	}
+
 	var initEngine engineLayer
-	var err error
 
 	switch config.EngineType {
 	case inMemoryType:
-		initEngine, err = engine.NewInMemoryEngine(logger) // TODO: new engine type
+		initEngine, err = engine.NewInMemoryEngine(logger, options...)
+	case orderedType:
+		initEngine, err = engine.NewOrderedEngine(logger, options...)
 	default:
-		initEngine, err = engine.NewInMemoryEngine(logger)
+		initEngine, err = engine.NewInMemoryEngine(logger, options...)
 	}
 
 	return initEngine, err
 }
+
+func createEngineOptions(config *config.EngineConfig) ([]engine.EngineOption, error) {
+	if config.MaxMemory == "" {
+		return nil, nil
+	}
+
+	if len(config.MaxMemory) < minSizeLen {
+		return nil, errors.New("incorrect max memory")
+	}
+
+	maxMemory, err := parsing.ParseSize(config.MaxMemory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	policy := config.EvictionPolicy
+
+	if policy == "" {
+		policy = engine.NoEviction
+	}
+
+	return []engine.EngineOption{engine.WithMaxMemory(maxMemory, policy)}, nil
+}
@@ -35,6 +35,17 @@ func Test_createEngine(t *testing.T) {
 			expectedNilObject: false,
 			expectedErr:       nil,
 		},
+		{
+			name: "ordered engine",
+
+			engconf: &config.EngineConfig{
+				EngineType: "ordered",
+			},
+			logger: zap.NewNop(),
+
+			expectedNilObject: false,
+			expectedErr:       nil,
+		},
 		{
 			name: "nil config",
 
@@ -80,6 +91,8 @@ func Test_createEngine(t *testing.T) {
 					switch test.engconf.EngineType {
 					case inMemoryType:
 						testEngine, _ = engine.NewInMemoryEngine(test.logger)
+					case orderedType:
+						testEngine, _ = engine.NewOrderedEngine(test.logger)
 					default:
 						testEngine, _ = engine.NewInMemoryEngine(test.logger)
 					}
@@ -100,3 +113,108 @@ func Test_createEngine(t *testing.T) {
 		})
 	}
 }
+
+func Test_createEngineOptions(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		engconf *config.EngineConfig
+
+		expectedLen int
+		expectedErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "without max memory",
+
+			engconf: &config.EngineConfig{
+				EngineType: "in_memory",
+			},
+
+			expectedLen: 0,
+			expectedErr: nil,
+		},
+		{
+			name: "with max memory and default policy",
+
+			engconf: &config.EngineConfig{
+				EngineType: "in_memory",
+				MaxMemory:  "64MB",
+			},
+
+			expectedLen: 1,
+			expectedErr: nil,
+		},
+		{
+			name: "with max memory and policy",
+
+			engconf: &config.EngineConfig{
+				EngineType:     "in_memory",
+				MaxMemory:      "64MB",
+				EvictionPolicy: engine.AllKeysLFU,
+			},
+
+			expectedLen: 1,
+			expectedErr: nil,
+		},
+		{
+			name: "too short max memory",
+
+			engconf: &config.EngineConfig{
+				MaxMemory: "B",
+			},
+
+			expectedLen: 0,
+			expectedErr: errors.New("incorrect max memory"),
+		},
+		{
+			name: "max memory in gigabytes",
+
+			engconf: &config.EngineConfig{
+				MaxMemory: "64GB",
+			},
+
+			expectedLen: 1,
+			expectedErr: nil,
+		},
+		{
+			name: "incorrect max memory",
+
+			engconf: &config.EngineConfig{
+				MaxMemory: "64TB",
+			},
+
+			expectedLen: 0,
+			expectedErr: errors.New("unknown or forbidden buffer size"),
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			options, err := createEngineOptions(test.engconf)
+
+			assert.Len(t, options, test.expectedLen)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
+
+func Test_createEngineWithMaxMemory(t *testing.T) {
+	t.Parallel()
+
+	engconf := &config.EngineConfig{
+		EngineType:     "in_memory",
+		MaxMemory:      "1KB",
+		EvictionPolicy: "biba",
+	}
+
+	actualEngine, actualErr := createEngine(engconf, zap.NewNop())
+
+	assert.Nil(t, actualEngine)
+	assert.Equal(t, errors.New("unknown eviction policy"), actualErr)
+}
@@ -8,27 +8,79 @@ import (
 	"inmemorykvdb/internal/database/request"
 	"inmemorykvdb/internal/database/storage"
 	"inmemorykvdb/internal/network"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type engineLayer interface {
-	SET(key string, value string)
+	SET(key string, value string, commit func() func() error) error
+	SETEX(key string, value string, deadline time.Time, commit func() func() error) error
 	GET(key string) (string, bool)
 	DEL(key string)
+	EXPIREAT(key string, deadline time.Time) bool
+	TTL(key string) (time.Duration, bool)
+	PERSIST(key string) bool
+	SCAN(cursor uint64, match string, count int) (uint64, []string, error)
+	MGET(keys []string) ([]string, []bool)
+	MSET(keys []string, values []string, commit func() func() error) error
+	MDEL(keys []string) int
+	INCRBY(key string, delta int64, commit func(value string, deadline time.Time) func() error) (int64, error)
+	INCRBYFLOAT(key string, delta float64, commit func(value string, deadline time.Time) func() error) (float64, error)
+	SETIF(key string, value string, deadline time.Time, condition func(current string, found bool, version uint64) bool,
+		commit func(value string, deadline time.Time) func() error) (bool, error)
+	CAS(key string, expected string, value string, commit func(value string, deadline time.Time) func() error) (bool, error)
+	VERSION(key string) (uint64, bool)
+	TYPE(key string) string
+	HSET(key string, fields []string, values []string, commit func() func() error) (int, error)
+	HGET(key string, field string) (string, bool, error)
+	HDEL(key string, fields []string) (int, error)
+	HGETALL(key string) ([]string, []string, error)
+	LPUSH(key string, elements []string, commit func() func() error) (int, error)
+	RPUSH(key string, elements []string, commit func() func() error) (int, error)
+	LPOP(key string) (string, bool, error)
+	RPOP(key string) (string, bool, error)
+	LRANGE(key string, start int, stop int) ([]string, error)
+	SADD(key string, members []string, commit func() func() error) (int, error)
+	SREM(key string, members []string) (int, error)
+	SMEMBERS(key string) ([]string, error)
+	SISMEMBER(key string, member string) (bool, error)
+	ZADD(key string, scores []float64, members []string, commit func() func() error) (int, error)
+	ZREM(key string, members []string) (int, error)
+	ZINCRBY(key string, delta float64, member string, commit func(score float64) func() error) (float64, error)
+	ZSCORE(key string, member string) (float64, bool, error)
+	ZRANK(key string, member string) (int, bool, error)
+	ZRANGE(key string, start int, stop int) ([]string, []float64, error)
+	ZRANGEBYSCORE(key string, min float64, max float64) ([]string, []float64, error)
+	DUMP(visit func(key string, kind string, items []string, deadline time.Time))
+	DUMPKEYS(keys []string, visit func(key string, kind string, items []string, deadline time.Time))
 }
 
 type WAL interface {
-	Write(req request.Request)
+	Write(req request.Request) func() (uint64, error)
+	WriteGroup(reqs []request.Request) func() (uint64, error)
 	Read() *request.Batch
 }
 
 type readingLayer interface {
 	Read() ([][]byte, error)
+	ReadAfter(segment uint64) ([][]byte, error)
+	Remove(segment uint64) error
 }
 
 type writingLayer interface {
 	Write([]byte) (int, error)
+	Seal() uint64
+	SkipTo(segment uint64)
+}
+
+type snapshotLayer interface {
+	Save(segment uint64, lsn uint64, reqs []request.Request) error
+	Load() (uint64, *request.Batch, error)
+}
+
+type compactionLayer interface {
+	Compact(upTo uint64) error
 }
 
 type replica interface {
@@ -80,13 +132,25 @@ func NewInitializer(cnfg *config.Config) (*Initializer, error) {
 		return nil, err
 	}
 
-	repl, err := createReplica(logger, cnfg.Replication, cnfg.WalConfig)
+	repl, err := createReplica(logger, cnfg.Replication, cnfg.WalConfig, writeAheadLog)
+
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := createSnapshot(logger, cnfg.WalConfig, cnfg.Replication)
+
+	if err != nil {
+		return nil, err
+	}
+
+	compactor, err := createCompactor(logger, cnfg.WalConfig, cnfg.Replication)
 
 	if err != nil {
 		return nil, err
 	}
 
-	storage, err := createStorage(engine, writeAheadLog, logger, repl)
+	storage, err := createStorage(engine, writeAheadLog, logger, repl, snap, compactor, cnfg.WalConfig)
 
 	if err != nil {
 		return nil, err
@@ -114,11 +178,17 @@ func NewInitializer(cnfg *config.Config) (*Initializer, error) {
 }
 
 func (i *Initializer) StartDatabase() {
-	i.server.HandleConnections(func(request []byte) []byte {
-		response, err := i.database.HandleRequest(string(request))
-		if err != nil {
-			return []byte(err.Error())
+	defer i.storage.Close()
+
+	i.server.HandleSessions(func() network.HandleRequest {
+		session := i.database.NewSession()
+
+		return func(request []byte) []byte {
+			response, err := session.HandleRequest(string(request))
+			if err != nil {
+				return []byte(err.Error())
+			}
+			return []byte(response)
 		}
-		return []byte(response)
 	})
 }
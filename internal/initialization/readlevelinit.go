@@ -28,13 +28,30 @@ func createReadLevel(logger *zap.Logger, cnfg *config.WalConfig) (readingLayer,
 
 		probablyMaxSegSize, err := parsing.ParseSize(cnfg.MaxSegmentSize)
 
-		if err != nil {
+		if err == nil {
 			maxSegSize = probablyMaxSegSize
 		}
 	}
 
-	rl, err := readlevel.NewReadLevel(logger, cnfg.FileName,
-		readlevel.WithFileMaxSize(maxSegSize), readlevel.WithDirectory(cnfg.DataDirectory))
+	pattern := cnfg.FileName
+
+	if pattern == "" {
+		pattern = defaultPattern
+	}
+
+	keyring, err := createKeyring(cnfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options := []readlevel.ReadLevelOption{readlevel.WithFileMaxSize(maxSegSize), readlevel.WithDirectory(cnfg.DataDirectory)}
+
+	if keyring != nil {
+		options = append(options, readlevel.WithKeyring(keyring))
+	}
+
+	rl, err := readlevel.NewReadLevel(logger, pattern, options...)
 
 	if err != nil {
 		return nil, err
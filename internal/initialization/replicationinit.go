@@ -5,6 +5,7 @@ import (
 	"inmemorykvdb/internal/config"
 	"inmemorykvdb/internal/database/storage/replication"
 	"inmemorykvdb/internal/network"
+	"inmemorykvdb/pkg/parsing"
 
 	"go.uber.org/zap"
 )
@@ -12,9 +13,17 @@ import (
 const (
 	slave  = "slave"
 	master = "master"
+
+	defaultChunkSize = 1 << 20
+	// chunkOverhead covers the file names, the token and the json of a chunk.
+	chunkOverhead = 4096
 )
 
-func createReplica(logger *zap.Logger, replCnfg *config.ReplicaConfig, walCnfg *config.WalConfig) (replica, error) {
+type flushingWal interface {
+	Flushed() <-chan struct{}
+}
+
+func createReplica(logger *zap.Logger, replCnfg *config.ReplicaConfig, walCnfg *config.WalConfig, wal WAL) (replica, error) {
 	if logger == nil {
 		return nil, errors.New("could not create replica without logger")
 	}
@@ -23,26 +32,88 @@ func createReplica(logger *zap.Logger, replCnfg *config.ReplicaConfig, walCnfg *
 		return nil, nil
 	}
 
+	pattern := walCnfg.FileName
+
+	if pattern == "" {
+		pattern = defaultPattern
+	}
+
+	keyring, err := createKeyring(walCnfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	chunkSize := createChunkSize(replCnfg)
+
 	switch replCnfg.ReplicaType {
 	case slave:
-		client, err := network.NewClient(replCnfg.MasterAddress)
+		// base64 of the records in json takes 4/3 of the chunk
+		client, err := network.NewClient(replCnfg.MasterAddress, network.WithClientFraming(),
+			network.WithClientMaxBufferSize(2*chunkSize+chunkOverhead))
 
 		if err != nil {
 			return nil, errors.New("could not create client for slave")
 		}
 
-		return replication.NewSlave(client, logger, replication.WithInterval(replCnfg.SyncInterval),
-			replication.WithDirectorySlave(walCnfg.DataDirectory))
+		options := []replication.SlaveOption{replication.WithInterval(replCnfg.SyncInterval),
+			replication.WithDirectorySlave(walCnfg.DataDirectory), replication.WithPatternSlave(pattern)}
+
+		if keyring != nil {
+			options = append(options, replication.WithKeyringSlave(keyring))
+		}
+
+		if replCnfg.Streaming {
+			options = append(options, replication.WithStreaming())
+		}
+
+		return replication.NewSlave(client, logger, options...)
 	case master:
-		server, err := network.NewServer(replCnfg.MasterAddress, logger)
+		// a slave acknowledges after its pull, a shorter timeout fails the writes of healthy slaves
+		if replCnfg.MinReplicasToAck > 0 && replCnfg.AckTimeout != 0 && replCnfg.AckTimeout <= replCnfg.SyncInterval {
+			return nil, errors.New("ack timeout should be greater than the sync interval")
+		}
+
+		server, err := network.NewServer(replCnfg.MasterAddress, logger, network.WithServerFraming())
 
 		if err != nil {
 			return nil, errors.New("could not create server for master")
 		}
 
-		return replication.NewMaster(server, logger,
-			replication.WithDirectoryMaster(walCnfg.DataDirectory))
+		options := []replication.MasterOption{replication.WithDirectoryMaster(walCnfg.DataDirectory),
+			replication.WithPatternMaster(pattern), replication.WithMaxChunkSize(chunkSize)}
+
+		if keyring != nil {
+			options = append(options, replication.WithKeyringMaster(keyring))
+		}
+
+		if replCnfg.MinReplicasToAck > 0 {
+			options = append(options, replication.WithQuorum(replCnfg.MinReplicasToAck, replCnfg.AckTimeout))
+		}
+
+		if flushing, ok := wal.(flushingWal); ok {
+			options = append(options, replication.WithFlushes(flushing))
+		}
+
+		return replication.NewMaster(server, logger, options...)
 	}
 
 	return nil, errors.New("unknown replica type")
 }
+
+// createChunkSize limits the records of one replication response,
+// a record bigger than the chunk is still sent whole.
+func createChunkSize(cnfg *config.ReplicaConfig) int {
+	chunkSize := defaultChunkSize
+
+	if len(cnfg.MaxChunkSize) > 2 {
+
+		probablyChunkSize, err := parsing.ParseSize(cnfg.MaxChunkSize)
+
+		if err == nil && probablyChunkSize > 0 {
+			chunkSize = probablyChunkSize
+		}
+	}
+
+	return chunkSize
+}
@@ -102,11 +102,42 @@ func Test_createReplication(t *testing.T) {
 			expectedNilObj: false,
 			expectedErr:    nil,
 		},
+
+		{
+			name: "master replica with quorum",
+
+			logger: zap.NewNop(),
+			cnfg: &config.ReplicaConfig{
+				ReplicaType:      master,
+				MasterAddress:    ":2223",
+				MinReplicasToAck: 1,
+				AckTimeout:       time.Second,
+			},
+
+			expectedNilObj: false,
+			expectedErr:    nil,
+		},
+
+		{
+			name: "master replica with ack timeout below sync interval",
+
+			logger: zap.NewNop(),
+			cnfg: &config.ReplicaConfig{
+				ReplicaType:      master,
+				MasterAddress:    ":2224",
+				SyncInterval:     time.Second,
+				MinReplicasToAck: 1,
+				AckTimeout:       500 * time.Millisecond,
+			},
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("ack timeout should be greater than the sync interval"),
+		},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.name, func(t *testing.T) {
-			repl, err := createReplica(test.logger, test.cnfg, walCnfg)
+			repl, err := createReplica(test.logger, test.cnfg, walCnfg, nil)
 
 			if test.expectedNilObj {
 				assert.Nil(t, repl)
@@ -118,3 +149,30 @@ func Test_createReplication(t *testing.T) {
 		})
 	}
 }
+
+func Test_createChunkSize(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		maxChunkSize string
+
+		expected int
+	}
+
+	testCases := []testCase{
+		{name: "default chunk size", maxChunkSize: "", expected: defaultChunkSize},
+		{name: "chunk size in kilobytes", maxChunkSize: "64KB", expected: 64 * 1024},
+		{name: "incorrect chunk size", maxChunkSize: "bibaMB", expected: defaultChunkSize},
+		{name: "zero chunk size", maxChunkSize: "0MB", expected: defaultChunkSize},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, createChunkSize(&config.ReplicaConfig{MaxChunkSize: test.maxChunkSize}))
+		})
+	}
+}
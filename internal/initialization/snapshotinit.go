@@ -0,0 +1,54 @@
+package initialization
+
+import (
+	"errors"
+	"inmemorykvdb/internal/config"
+	"inmemorykvdb/internal/database/storage/snapshot"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSnapshotDirectory = "snapshot"
+)
+
+func createSnapshot(logger *zap.Logger, cnfg *config.WalConfig, replicaCnfg *config.ReplicaConfig) (snapshotLayer, error) {
+	if logger == nil {
+		return nil, errors.New("logger is nil")
+	}
+
+	if replicaCnfg != nil && replicaCnfg.ReplicaType == slave {
+		return nil, nil
+	}
+
+	if cnfg == nil {
+		return nil, nil
+	}
+
+	directory := cnfg.SnapshotDirectory
+
+	if directory == "" {
+		directory = filepath.Join(cnfg.DataDirectory, defaultSnapshotDirectory)
+	}
+
+	keyring, err := createKeyring(cnfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options := []snapshot.SnapshotOption{snapshot.WithDirectory(directory)}
+
+	if keyring != nil {
+		options = append(options, snapshot.WithKeyring(keyring))
+	}
+
+	snap, err := snapshot.NewSnapshot(logger, options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
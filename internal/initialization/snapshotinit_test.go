@@ -0,0 +1,87 @@
+package initialization
+
+import (
+	"errors"
+	"inmemorykvdb/internal/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_createSnapshot(t *testing.T) {
+	type testCase struct {
+		name string
+
+		logger   *zap.Logger
+		cnfg     *config.WalConfig
+		replCnfg *config.ReplicaConfig
+
+		expectedNilObj bool
+		expectedErr    error
+	}
+
+	testCases := []testCase{
+		{
+			name: "nil config",
+
+			logger: zap.NewNop(),
+
+			expectedNilObj: true,
+			expectedErr:    nil,
+		},
+
+		{
+			name: "nil logger",
+
+			logger: nil,
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("logger is nil"),
+		},
+
+		{
+			name: "not nil config",
+
+			logger: zap.NewNop(),
+			cnfg: &config.WalConfig{
+				DataDirectory:    t.TempDir(),
+				SnapshotInterval: time.Minute,
+			},
+
+			expectedNilObj: false,
+			expectedErr:    nil,
+		},
+
+		{
+			name: "slave node",
+
+			logger: zap.NewNop(),
+			cnfg: &config.WalConfig{
+				DataDirectory:    t.TempDir(),
+				SnapshotInterval: time.Minute,
+			},
+			replCnfg: &config.ReplicaConfig{
+				ReplicaType: slave,
+			},
+
+			expectedNilObj: true,
+			expectedErr:    nil,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			snap, err := createSnapshot(test.logger, test.cnfg, test.replCnfg)
+
+			if test.expectedNilObj {
+				assert.Nil(t, snap)
+			} else {
+				assert.NotNil(t, snap)
+			}
+
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
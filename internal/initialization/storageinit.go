@@ -2,13 +2,16 @@ package initialization
 
 import (
 	"errors"
+	"inmemorykvdb/internal/config"
 	"inmemorykvdb/internal/database/request"
 	"inmemorykvdb/internal/database/storage"
+	"time"
 
 	"go.uber.org/zap"
 )
 
-func createStorage(engine engineLayer, wal WAL, logger *zap.Logger, replication replica) (*storage.Storage, error) {
+func createStorage(engine engineLayer, wal WAL, logger *zap.Logger, replication replica, snap snapshotLayer,
+	compactor compactionLayer, walCnfg *config.WalConfig) (*storage.Storage, error) {
 	if logger == nil {
 		return nil, errors.New("logger is nil")
 	}
@@ -18,6 +21,12 @@ func createStorage(engine engineLayer, wal WAL, logger *zap.Logger, replication
 	}
 
 	var dataChan chan *request.Batch
+	var snapshotInterval, compactionInterval time.Duration
+
+	if walCnfg != nil {
+		snapshotInterval = walCnfg.SnapshotInterval
+		compactionInterval = walCnfg.CompactionInterval
+	}
 
 	if replication != nil && !replication.IsMaster() {
 		dataChan = replication.DataChan()
@@ -26,7 +35,11 @@ func createStorage(engine engineLayer, wal WAL, logger *zap.Logger, replication
 	storage, err := storage.NewStorage(logger, engine,
 		storage.WithWal(wal),
 		storage.WithReplica(replication),
-		storage.WithDataChan(dataChan))
+		storage.WithDataChan(dataChan),
+		storage.WithSnapshot(snap),
+		storage.WithSnapshotInterval(snapshotInterval),
+		storage.WithCompactor(compactor),
+		storage.WithCompactionInterval(compactionInterval))
 
 	return storage, err
 }
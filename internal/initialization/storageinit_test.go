@@ -118,7 +118,7 @@ func Test_createStorage(t *testing.T) {
 				slave, _ = replication.NewSlave(client, zap.NewNop())
 			}
 
-			stor, err := createStorage(eng, writeAheadLog, test.logger, slave)
+			stor, err := createStorage(eng, writeAheadLog, test.logger, slave, nil, nil, nil)
 
 			if test.expectedNilObj {
 				assert.Nil(t, stor)
@@ -21,6 +21,7 @@ func createWal(cnfg *config.WalConfig, logger *zap.Logger, writeLevel writingLay
 		logger,
 		wal.WithBatchSize(cnfg.BatchSize),
 		wal.WithBatchTimeout(cnfg.BatchTimeout),
+		wal.WithDurability(cnfg.Durability),
 		wal.WithWriter(writeLevel),
 		wal.WithReader(readLevel),
 	)
@@ -75,6 +75,24 @@ func Test_createWal(t *testing.T) {
 			expectedNilObj: false,
 			expectedErr:    nil,
 		},
+
+		{
+			name: "wal with unknown durability",
+
+			nilReadLevel:  false,
+			nilWriteLevel: false,
+			logger:        zap.NewNop(),
+			cnfg: &config.WalConfig{
+				BatchSize:     100,
+				BatchTimeout:  time.Millisecond,
+				DataDirectory: dataDir,
+				FileName:      "wrahlo",
+				Durability:    "sometimes",
+			},
+
+			expectedNilObj: true,
+			expectedErr:    errors.New("unknown durability mode sometimes"),
+		},
 	}
 
 	for _, test := range testCases {
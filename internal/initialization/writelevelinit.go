@@ -3,6 +3,7 @@ package initialization
 import (
 	"errors"
 	"inmemorykvdb/internal/config"
+	"inmemorykvdb/internal/database/storage/wal"
 	"inmemorykvdb/internal/database/storage/wal/writelevel"
 	"inmemorykvdb/pkg/parsing"
 
@@ -32,17 +33,29 @@ func createWriteLevel(logger *zap.Logger, cnfg *config.WalConfig, replicaCnfg *c
 
 		probablyMaxSegSize, err := parsing.ParseSize(cnfg.MaxSegmentSize)
 
-		if err != nil {
+		if err == nil {
 			maxSegSize = probablyMaxSegSize
 		}
 	}
 
-	wl, err := writelevel.NewWriteLevel(
-		logger,
+	keyring, err := createKeyring(cnfg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options := []writelevel.WriteLevelOption{
 		writelevel.WithFileMaxSize(maxSegSize),
 		writelevel.WithFileName(cnfg.FileName),
 		writelevel.WithFilePath(cnfg.DataDirectory),
-	)
+		writelevel.WithSync(cnfg.Durability != wal.DurabilityNone),
+	}
+
+	if keyring != nil {
+		options = append(options, writelevel.WithKeyring(keyring))
+	}
+
+	wl, err := writelevel.NewWriteLevel(logger, options...)
 
 	if err != nil {
 		return nil, err
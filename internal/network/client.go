@@ -2,6 +2,7 @@ package network
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"time"
 )
@@ -14,6 +15,7 @@ type Client struct {
 	Connection  net.Conn
 	IdleTimeout time.Duration
 	BufferSize  int
+	Framed      bool
 }
 
 func NewClient(address string, options ...ClientOption) (*Client, error) {
@@ -41,6 +43,10 @@ func NewClient(address string, options ...ClientOption) (*Client, error) {
 }
 
 func (c *Client) Send(message []byte) ([]byte, error) {
+	if c.Framed {
+		return c.sendFramed(message)
+	}
+
 	_, err := c.Connection.Write(message)
 
 	if err != nil {
@@ -62,6 +68,22 @@ func (c *Client) Send(message []byte) ([]byte, error) {
 	return response[:responeSize], nil
 }
 
+func (c *Client) sendFramed(message []byte) ([]byte, error) {
+	_, err := c.Connection.Write(frame(message))
+
+	if err != nil {
+		return nil, errors.New("failed to write data")
+	}
+
+	response, err := readFrame(c.Connection, c.BufferSize)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data: %s", err.Error())
+	}
+
+	return response, nil
+}
+
 func (c *Client) Close() {
 	if c.Connection != nil {
 		c.Connection.Close()
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
 const (
@@ -135,3 +136,32 @@ func Test_Send(t *testing.T) {
 		})
 	}
 }
+
+func Test_SendFramed(t *testing.T) {
+	const framedAddress = "localhost:7778"
+
+	server, _ := NewServer(framedAddress, zap.NewNop(), WithServerFraming())
+
+	defer server.Listener.Close()
+
+	response := strings.Repeat("biba", testBufferSize)
+
+	go server.HandleConnections(func(data []byte) []byte {
+		return []byte(response)
+	})
+
+	client, err := NewClient(framedAddress, WithClientFraming(), WithClientMaxBufferSize(len(response)))
+
+	assert.NoError(t, err)
+
+	resp, err := client.Send([]byte("client request"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, response, string(resp))
+
+	small, _ := NewClient(framedAddress, WithClientFraming(), WithClientMaxBufferSize(testBufferSize))
+
+	_, err = small.Send([]byte("client request"))
+
+	assert.Equal(t, errors.New("failed to read data: frame is bigger than buffer size"), err)
+}
@@ -0,0 +1,47 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	frameHeaderSize = 4
+)
+
+// frame prefixes the message with its length, a framed message
+// is read whole even when it arrives in several packets.
+func frame(message []byte) []byte {
+	framed := make([]byte, 0, frameHeaderSize+len(message))
+
+	framed = binary.BigEndian.AppendUint32(framed, uint32(len(message)))
+
+	return append(framed, message...)
+}
+
+func readFrame(reader io.Reader, maxSize int) ([]byte, error) {
+	header := make([]byte, frameHeaderSize)
+
+	_, err := io.ReadFull(reader, header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header)
+
+	if uint64(size) > uint64(maxSize) {
+		return nil, errors.New("frame is bigger than buffer size")
+	}
+
+	message := make([]byte, size)
+
+	_, err = io.ReadFull(reader, message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
@@ -0,0 +1,72 @@
+package network
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readFrame(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name string
+
+		data    []byte
+		maxSize int
+
+		expectedMessage []byte
+		expectedErr     error
+	}
+
+	testCases := []testCase{
+		{
+			name: "whole frame",
+
+			data:    frame([]byte("biba")),
+			maxSize: 4,
+
+			expectedMessage: []byte("biba"),
+			expectedErr:     nil,
+		},
+		{
+			name: "empty frame",
+
+			data:    frame(nil),
+			maxSize: 4,
+
+			expectedMessage: []byte{},
+			expectedErr:     nil,
+		},
+		{
+			name: "frame bigger than buffer",
+
+			data:    frame([]byte("boba")),
+			maxSize: 3,
+
+			expectedErr: errors.New("frame is bigger than buffer size"),
+		},
+		{
+			name: "truncated frame",
+
+			data:    frame([]byte("boba"))[:6],
+			maxSize: 4,
+
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			message, err := readFrame(bytes.NewReader(test.data), test.maxSize)
+
+			assert.Equal(t, test.expectedMessage, message)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+}
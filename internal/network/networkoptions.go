@@ -24,6 +24,14 @@ func WithServerMaxConnections(maxConnections int) ServerOption {
 	}
 }
 
+// WithServerFraming makes the server read and write length-prefixed messages,
+// the clients have to be created with WithClientFraming.
+func WithServerFraming() ServerOption {
+	return func(s *Server) {
+		s.Framed = true
+	}
+}
+
 type ClientOption func(*Client)
 
 func WithClientTimeout(timeout time.Duration) ClientOption {
@@ -37,3 +45,9 @@ func WithClientMaxBufferSize(maxBufferSize int) ClientOption {
 		c.BufferSize = maxBufferSize
 	}
 }
+
+func WithClientFraming() ClientOption {
+	return func(c *Client) {
+		c.Framed = true
+	}
+}
@@ -76,3 +76,19 @@ func Test_WithClientMaxBufferSize(t *testing.T) {
 
 	assert.Equal(t, expectedClient, actualClient)
 }
+
+func Test_WithFraming(t *testing.T) {
+	t.Parallel()
+
+	var actualServer Server
+
+	WithServerFraming()(&actualServer)
+
+	assert.Equal(t, Server{Framed: true}, actualServer)
+
+	var actualClient Client
+
+	WithClientFraming()(&actualClient)
+
+	assert.Equal(t, Client{Framed: true}, actualClient)
+}
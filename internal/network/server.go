@@ -25,6 +25,7 @@ type Server struct {
 	IdleTimeout    time.Duration
 	MaxBufferSize  int
 	MaxConnections int
+	Framed         bool
 	Logger         *zap.Logger
 
 	semaphore *serversync.Semaphore
@@ -66,6 +67,12 @@ func NewServer(address string, logger *zap.Logger, options ...ServerOption) (*Se
 }
 
 func (s *Server) HandleConnections(handleFunc HandleRequest) {
+	s.HandleSessions(func() HandleRequest {
+		return handleFunc
+	})
+}
+
+func (s *Server) HandleSessions(newSession func() HandleRequest) {
 
 	defer s.Listener.Close()
 
@@ -88,7 +95,7 @@ func (s *Server) HandleConnections(handleFunc HandleRequest) {
 				continue
 			}
 
-			go s.handleConnection(conn, handleFunc)
+			go s.handleConnection(conn, newSession())
 		}
 	}()
 
@@ -130,17 +137,13 @@ func (s *Server) handleConnection(conn net.Conn, handleFunc HandleRequest) {
 			}
 		}
 
-		size, err := conn.Read(request)
+		message, err := s.read(conn, request)
 
 		if err != nil {
 			s.Logger.Error("failed to read data")
 			break
 		}
 
-		if size == s.MaxBufferSize {
-			s.Logger.Warn("buffer size got maximum on reading")
-		}
-
 		if s.IdleTimeout != 0 {
 			err := conn.SetWriteDeadline(time.Now().Add(s.IdleTimeout))
 			if err != nil {
@@ -148,7 +151,13 @@ func (s *Server) handleConnection(conn net.Conn, handleFunc HandleRequest) {
 			}
 		}
 
-		size, err = conn.Write(handleFunc(request[:size]))
+		response := handleFunc(message)
+
+		if s.Framed {
+			response = frame(response)
+		}
+
+		size, err := conn.Write(response)
 
 		if err != nil {
 			s.Logger.Error("failed to write data")
@@ -160,3 +169,21 @@ func (s *Server) handleConnection(conn net.Conn, handleFunc HandleRequest) {
 		}
 	}
 }
+
+func (s *Server) read(conn net.Conn, buffer []byte) ([]byte, error) {
+	if s.Framed {
+		return readFrame(conn, s.MaxBufferSize)
+	}
+
+	size, err := conn.Read(buffer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if size == s.MaxBufferSize {
+		s.Logger.Warn("buffer size got maximum on reading")
+	}
+
+	return buffer[:size], nil
+}
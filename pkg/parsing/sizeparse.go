@@ -9,6 +9,7 @@ const (
 	byteMultiply     = 1
 	kilobyteMultiply = 1024
 	megabyteMultiply = 1048576
+	gigabyteMultiply = 1073741824
 )
 
 func ParseSize(unparsedSize string) (int, error) {
@@ -52,6 +53,8 @@ func ParseMultiply(unparsedMultiply string) (int, error) {
 		return kilobyteMultiply, nil
 	case "MB":
 		return megabyteMultiply, nil
+	case "GB":
+		return gigabyteMultiply, nil
 	case "B":
 		return byteMultiply, nil
 	}
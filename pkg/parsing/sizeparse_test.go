@@ -26,6 +26,14 @@ func Test_parseSize(t *testing.T) {
 			expectedSize: megabyteMultiply * 10,
 			expectedErr:  nil,
 		},
+		{
+			name: "correct message size with gigabytes",
+
+			unparsedSize: "2GB",
+
+			expectedSize: gigabyteMultiply * 2,
+			expectedErr:  nil,
+		},
 		{
 			name: "correct message size with bytes",
 
@@ -75,6 +83,14 @@ func Test_parseMultiply(t *testing.T) {
 			expectedMultiply: megabyteMultiply,
 			expectedErr:      nil,
 		},
+		{
+			name: "gigabyte",
+
+			unparsedMultiply: "GB",
+
+			expectedMultiply: gigabyteMultiply,
+			expectedErr:      nil,
+		},
 		{
 			name: "kilobyte",
 